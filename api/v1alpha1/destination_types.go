@@ -28,6 +28,20 @@ type StateStoreCoreFields struct {
 	//   <StateStore.Spec.Path>/<Destination.Spec.Path>/<Destination.Metadata.Namespace>/<Destination.Metadata.Name>/
 	//+kubebuilder:validation:Optional
 	Path string `json:"path,omitempty"`
+	// PathTemplate replaces the default layout of the directories within the
+	// Path of the StateStore. It is a Go template of the directory each group
+	// of workloads is written to, with the fields .Destination.Name,
+	// .Destination.Namespace, .Destination.Path, .Destination.Labels, .Type
+	// (resources or dependencies), .PromiseName, .ResourceNamespace,
+	// .ResourceName and .ID. The directory of the Type is the rendered path
+	// up to .Type, which must be a directory of its own. It must use
+	// .Destination.Name before .Type, and render a different directory for
+	// each group of workloads. The PathTemplate of a
+	// Destination takes precedence over that of its StateStore. When it
+	// changes, Kratix moves the workloads to the new directories.
+	//   e.g. clusters/{{ .Destination.Name }}/{{ .Type }}/{{ .PromiseName }}/{{ .ResourceNamespace }}-{{ .ResourceName }}-{{ .ID }}
+	//+kubebuilder:validation:Optional
+	PathTemplate string `json:"pathTemplate,omitempty"`
 	// SecretRef specifies the Secret containing authentication credentials
 	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
 }
@@ -58,17 +72,74 @@ type DestinationSpec struct {
 	// to this destination, unless the destination label set is also empty
 	// +kubebuilder:validation:Optional
 	StrictMatchLabels bool `json:"strictMatchLabels,omitempty"`
+
+	// Unschedulable cordons the Destination: no new resource request
+	// workloads are scheduled to it. Workloads already on it, and Promise
+	// dependencies, are not affected.
+	// +kubebuilder:validation:Optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
+
+	// Drain migrates the resource request workloads on the Destination to
+	// other eligible Destinations, one WorkPlacement at a time. A draining
+	// Destination is unschedulable.
+	// +kubebuilder:validation:Optional
+	Drain bool `json:"drain,omitempty"`
+
+	// DeletionPolicy decides what happens to the workloads on the Destination
+	// when it is deleted. With Delete, the workloads are removed from the
+	// State Store; with Orphan, they are left in place. In both cases the
+	// WorkPlacements on the Destination are removed, and resource request
+	// workloads are rescheduled to other eligible Destinations.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +kubebuilder:default=Delete
+	DeletionPolicy DestinationDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+type DestinationDeletionPolicy string
+
+const (
+	DestinationDeletionPolicyDelete DestinationDeletionPolicy = "Delete"
+	DestinationDeletionPolicyOrphan DestinationDeletionPolicy = "Orphan"
+)
+
 // DestinationStatus defines the observed state of Destination
 type DestinationStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of destination
-	// Important: Run "make" to regenerate code after modifying this file
+	// CanaryPaths are the directories, within the Path of the StateStore,
+	// Kratix last wrote its canary files to
+	// +optional
+	CanaryPaths []string `json:"canaryPaths,omitempty"`
+	// Drain shows the progress of draining the Destination
+	// +optional
+	Drain *DrainStatus `json:"drain,omitempty"`
+}
+
+type DrainPhase string
+
+const (
+	DrainPhaseDraining DrainPhase = "Draining"
+	DrainPhaseDrained  DrainPhase = "Drained"
+)
+
+type DrainStatus struct {
+	Phase DrainPhase `json:"phase"`
+	// Migrated is the number of WorkPlacements moved to other Destinations
+	Migrated int `json:"migrated"`
+	// Remaining is the number of resource request WorkPlacements still on the
+	// Destination
+	Remaining int `json:"remaining"`
+	// Current is the WorkPlacement being migrated
+	// +optional
+	Current string `json:"current,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster,path=destinations
+//+kubebuilder:printcolumn:JSONPath=".spec.unschedulable",name="Unschedulable",type=boolean
+//+kubebuilder:printcolumn:JSONPath=".status.drain.phase",name="Drain",type=string
 
 // Destination is the Schema for the Destinations API
 type Destination struct {
@@ -79,6 +150,18 @@ type Destination struct {
 	Status DestinationStatus `json:"status,omitempty"`
 }
 
+// Schedulable returns whether new resource request workloads can be
+// scheduled to the Destination
+func (d *Destination) Schedulable() bool {
+	return !d.Spec.Unschedulable && !d.Spec.Drain
+}
+
+// OrphansWorkloads returns whether the workloads of the Destination are left
+// in the State Store when it is deleted
+func (d *Destination) OrphansWorkloads() bool {
+	return d.Spec.DeletionPolicy == DestinationDeletionPolicyOrphan
+}
+
 //+kubebuilder:object:root=true
 
 // DestinationList contains a list of Destination
@@ -90,7 +173,7 @@ type DestinationList struct {
 
 // StateStoreReference is a reference to a StateStore
 type StateStoreReference struct {
-	// +kubebuilder:validation:Enum=BucketStateStore;GitStateStore
+	// +kubebuilder:validation:Enum=BucketStateStore;GitStateStore;KubernetesStateStore
 	Kind string `json:"kind"`
 	Name string `json:"name"`
 }
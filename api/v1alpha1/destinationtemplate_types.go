@@ -0,0 +1,86 @@
+/*
+Copyright 2021 Syntasso.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DestinationTemplateSpec defines the Destinations registered for the Cluster
+// API Clusters selected by the DestinationTemplate
+type DestinationTemplateSpec struct {
+	// ClusterSelector selects the Cluster API Clusters to register as
+	// Destinations. An empty selector selects every Cluster.
+	//+kubebuilder:validation:Optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// ClusterNamespace restricts the selected Clusters to a namespace. By
+	// default, Clusters in every namespace are selected.
+	//+kubebuilder:validation:Optional
+	ClusterNamespace string `json:"clusterNamespace,omitempty"`
+
+	// Template holds the defaults of the registered Destinations. The labels of
+	// the Cluster are copied onto the Destination, over the template labels.
+	Template DestinationTemplateResource `json:"template"`
+}
+
+type DestinationTemplateResource struct {
+	//+kubebuilder:validation:Optional
+	Metadata DestinationTemplateMetadata `json:"metadata,omitempty"`
+	Spec     DestinationSpec             `json:"spec"`
+}
+
+type DestinationTemplateMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// DestinationTemplateStatus defines the observed state of DestinationTemplate
+type DestinationTemplateStatus struct {
+	// Destinations are the names of the Destinations registered from the
+	// selected Clusters
+	// +optional
+	Destinations []string `json:"destinations,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,path=destinationtemplates
+
+// DestinationTemplate registers a Destination, named <namespace>-<name> of
+// the Cluster, for each Cluster API Cluster it selects, and removes it once
+// the Cluster is deleted or no longer selected
+type DestinationTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DestinationTemplateSpec   `json:"spec,omitempty"`
+	Status DestinationTemplateStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DestinationTemplateList contains a list of DestinationTemplate
+type DestinationTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DestinationTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DestinationTemplate{}, &DestinationTemplateList{})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 Syntasso.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubernetesStateStoreKubeconfigKey is the key of the Secret referenced by a
+// KubernetesStateStore holding the kubeconfig of the cluster
+const KubernetesStateStoreKubeconfigKey = "kubeconfig"
+
+// KubernetesStateStoreSpec defines the desired state of KubernetesStateStore.
+// Instead of writing the workloads for a GitOps agent to pick up, Kratix
+// server-side applies them directly to the cluster of the kubeconfig in the
+// Secret referenced by SecretRef, and prunes the objects removed since.
+type KubernetesStateStoreSpec struct {
+	StateStoreCoreFields `json:",inline"`
+
+	// InventoryNamespace is the namespace, on the cluster, of the ConfigMaps
+	// recording the objects Kratix applied. It cannot be kratix-worker-system,
+	// which Kratix removes with its canary files.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default:=kratix-inventory
+	InventoryNamespace string `json:"inventoryNamespace,omitempty"`
+}
+
+// KubernetesStateStoreStatus defines the observed state of KubernetesStateStore
+type KubernetesStateStoreStatus struct {
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,path=kubernetesstatestores
+
+// KubernetesStateStore is the Schema for the kubernetesstatestores API
+type KubernetesStateStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubernetesStateStoreSpec   `json:"spec,omitempty"`
+	Status KubernetesStateStoreStatus `json:"status,omitempty"`
+}
+
+func (k *KubernetesStateStore) GetSecretRef() *corev1.SecretReference {
+	return k.Spec.SecretRef
+}
+
+//+kubebuilder:object:root=true
+
+// KubernetesStateStoreList contains a list of KubernetesStateStore
+type KubernetesStateStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubernetesStateStore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubernetesStateStore{}, &KubernetesStateStoreList{})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Syntasso.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// The resource (or Promise) was seen for the first time
+	PipelineRunTriggerCreate = "Create"
+	// The spec of the resource (or Promise) changed since the last run
+	PipelineRunTriggerSpecChange = "SpecChange"
+	// The kratix.io/manual-reconciliation label was set by a user
+	PipelineRunTriggerManualReconciliation = "ManualReconciliation"
+	// The Promise was updated, causing all of its resources to be reconciled
+	PipelineRunTriggerPromiseUpdate = "PromiseUpdate"
+	// The resource (or Promise) was deleted, running its delete pipeline
+	PipelineRunTriggerDelete = "Delete"
+
+	PipelineRunResultRunning   = "Running"
+	PipelineRunResultSucceeded = "Succeeded"
+	PipelineRunResultFailed    = "Failed"
+	PipelineRunResultSuspended = "Suspended"
+	PipelineRunResultUnknown   = "Unknown"
+)
+
+// PipelineRunSpec defines the desired state of PipelineRun
+type PipelineRunSpec struct {
+	PromiseName string `json:"promiseName,omitempty"`
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+	// +kubebuilder:validation:Enum=resource;promise
+	WorkflowType string `json:"workflowType,omitempty"`
+	// +kubebuilder:validation:Enum=configure;delete
+	WorkflowAction string `json:"workflowAction,omitempty"`
+	// What caused the pipeline to run
+	// +kubebuilder:validation:Enum=Create;SpecChange;ManualReconciliation;PromiseUpdate;Delete
+	Trigger string `json:"trigger,omitempty"`
+	// Name of the Job executing the pipeline
+	JobName string `json:"jobName,omitempty"`
+	// Hash of the object spec the pipeline was run against
+	ResourceHash string `json:"resourceHash,omitempty"`
+}
+
+// PipelineRunStatus defines the observed state of PipelineRun
+type PipelineRunStatus struct {
+	Result         string       `json:"result,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// +optional
+	Containers []PipelineRunContainerStatus `json:"containers,omitempty"`
+}
+
+type PipelineRunContainerStatus struct {
+	Name string `json:"name,omitempty"`
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Tail of the container logs, captured once the pipeline finishes
+	// +optional
+	Logs string `json:"logs,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:JSONPath=".spec.promiseName",name="Promise",type=string
+//+kubebuilder:printcolumn:JSONPath=".spec.resourceName",name="Resource",type=string
+//+kubebuilder:printcolumn:JSONPath=".spec.trigger",name="Trigger",type=string
+//+kubebuilder:printcolumn:JSONPath=".status.result",name="Result",type=string
+//+kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name="Age",type=date
+
+// PipelineRun records a single execution of a Promise or resource workflow
+type PipelineRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PipelineRunSpec   `json:"spec,omitempty"`
+	Status PipelineRunStatus `json:"status,omitempty"`
+}
+
+func (p *PipelineRun) IsFinished() bool {
+	return p.Status.CompletionTime != nil
+}
+
+//+kubebuilder:object:root=true
+
+// PipelineRunList contains a list of PipelineRun
+type PipelineRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PipelineRun `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PipelineRun{}, &PipelineRunList{})
+}
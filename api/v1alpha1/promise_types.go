@@ -21,14 +21,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const (
@@ -63,7 +68,116 @@ type PromiseSpec struct {
 
 	Dependencies Dependencies `json:"dependencies,omitempty"`
 
+	// DependencyGroups are dependencies scheduled with their own
+	// destination selectors, on top of those of the Promise. Each group
+	// becomes its own WorkloadGroup, written to its directory.
+	// +optional
+	DependencyGroups []DependencyGroup `json:"dependencyGroups,omitempty"`
+
 	DestinationSelectors []PromiseScheduling `json:"destinationSelectors,omitempty"`
+
+	// ResourcePlacement sets how many Destinations the workloads of each
+	// resource request are scheduled to. A placement.yaml written by the
+	// resource configure pipeline takes precedence.
+	// +optional
+	ResourcePlacement *Placement `json:"resourcePlacement,omitempty"`
+
+	// Reschedule sets whether workloads on a Destination that no longer
+	// matches the destination selectors are moved to a matching Destination.
+	// Defaults to Never.
+	// +kubebuilder:validation:Enum=Never;Automatic
+	// +optional
+	Reschedule ReschedulePolicy `json:"reschedule,omitempty"`
+
+	// Config is made available to every resource configure pipeline at
+	// /kratix/input/promise-config.yaml
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+
+	// ConfigSecretRefs are Secrets whose data is added to the config made
+	// available to every resource configure pipeline
+	// +optional
+	ConfigSecretRefs []PromiseConfigSecretRef `json:"configSecretRefs,omitempty"`
+
+	// WorkloadPolicy restricts the manifests resource configure pipelines
+	// may output. Pipelines outputting other manifests, or YAML and JSON
+	// documents without an apiVersion and kind, fail.
+	// +optional
+	WorkloadPolicy *WorkloadPolicy `json:"workloadPolicy,omitempty"`
+
+	// StampWorkloads adds labels and annotations identifying the Promise, the
+	// resource request and the pipeline run to every manifest output by the
+	// configure pipelines
+	// +optional
+	StampWorkloads bool `json:"stampWorkloads,omitempty"`
+}
+
+// WorkloadPolicy restricts the manifests in the output of a pipeline
+type WorkloadPolicy struct {
+	// AllowedKinds are the kinds of the manifests. Any kind is allowed when
+	// empty.
+	// +optional
+	AllowedKinds []AllowedWorkloadKind `json:"allowedKinds,omitempty"`
+	// AllowedNamespaces are the namespaces of the manifests, including the
+	// names of Namespaces. Any namespace is allowed when empty. Manifests
+	// without a namespace are not restricted.
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+type AllowedWorkloadKind struct {
+	// Group of the kind, empty for the core group
+	// +optional
+	Group string `json:"group,omitempty"`
+	// Version of the kind. Any version is allowed when empty.
+	// +optional
+	Version string `json:"version,omitempty"`
+	Kind    string `json:"kind"`
+}
+
+// Check returns an error when the policy does not allow a manifest of the
+// kind in the namespace. A nil policy allows every manifest.
+func (p *WorkloadPolicy) Check(gvk schema.GroupVersionKind, namespace string) error {
+	if p == nil {
+		return nil
+	}
+
+	if len(p.AllowedKinds) > 0 && !p.allowsKind(gvk) {
+		return fmt.Errorf("kind %s is not allowed by the Promise", gvk.String())
+	}
+
+	if namespace != "" && len(p.AllowedNamespaces) > 0 && !slices.Contains(p.AllowedNamespaces, namespace) {
+		return fmt.Errorf("namespace %s is not allowed by the Promise", namespace)
+	}
+	return nil
+}
+
+func (p *WorkloadPolicy) allowsKind(gvk schema.GroupVersionKind) bool {
+	for _, allowed := range p.AllowedKinds {
+		if allowed.Group == gvk.Group && allowed.Kind == gvk.Kind && (allowed.Version == "" || allowed.Version == gvk.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+type ReschedulePolicy string
+
+const (
+	// RescheduleNever keeps workloads on a misscheduled Destination; the
+	// WorkPlacement is only labelled as misscheduled
+	RescheduleNever ReschedulePolicy = "Never"
+	// RescheduleAutomatic places the workloads on a matching Destination and,
+	// once they are written there, removes them from the misscheduled one
+	RescheduleAutomatic ReschedulePolicy = "Automatic"
+)
+
+type PromiseConfigSecretRef struct {
+	// Name the Secret data is available under, in the secrets of the config
+	Name string `json:"name"`
+	// SecretRef references the Secret to include. If no namespace is given,
+	// the Secret is read from the kratix-platform-system namespace.
+	SecretRef corev1.SecretReference `json:"secretRef"`
 }
 
 type Requirement struct {
@@ -95,16 +209,86 @@ type Dependency struct {
 	unstructured.Unstructured `json:",inline"`
 }
 
+// DependencyGroup is a set of dependencies scheduled together
+type DependencyGroup struct {
+	// Directory the dependencies are written to, relative to the root of
+	// the Work. Must be unique across the groups.
+	// +kubebuilder:validation:MinLength=1
+	Directory string `json:"directory"`
+	// DestinationSelectors select the Destinations the group is scheduled
+	// to, in addition to the destinationSelectors of the Promise
+	// +optional
+	DestinationSelectors []PromiseScheduling `json:"destinationSelectors,omitempty"`
+	// Wave orders the writes of the group to a Destination, see
+	// WorkloadGroup. The dependencies of the Promise are in wave 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Wave         int          `json:"wave,omitempty"`
+	Dependencies Dependencies `json:"dependencies"`
+}
+
+// DependenciesFile is the file, within its directory, the dependencies of a
+// DependencyGroup are written to
+const DependenciesFile = "dependencies.yaml"
+
+// ValidateDependencyGroups checks each DependencyGroup has a unique directory
+// within the Work, other than its root
+func (p *PromiseSpec) ValidateDependencyGroups() error {
+	directories := map[string]bool{}
+	for _, group := range p.DependencyGroups {
+		directory := filepath.Clean(group.Directory)
+		if group.Directory == "" || directory == DefaultWorkloadGroupDirectory || filepath.IsAbs(directory) || directory == ".." || strings.HasPrefix(directory, "../") {
+			return fmt.Errorf("invalid dependency group directory %q: must be a subdirectory of the Work", group.Directory)
+		}
+		if directories[directory] {
+			return fmt.Errorf("duplicate dependency group directory %q", group.Directory)
+		}
+		directories[directory] = true
+	}
+	return nil
+}
+
+// DependencyGroupScheduling returns the directories and destination selectors
+// of the DependencyGroups, as scheduled by a destination-selectors.yaml
+func (p *PromiseSpec) DependencyGroupScheduling() []WorkflowDestinationSelectors {
+	scheduling := []WorkflowDestinationSelectors{}
+	for _, group := range p.DependencyGroups {
+		selector := SquashPromiseScheduling(group.DestinationSelectors)
+		scheduling = append(scheduling, WorkflowDestinationSelectors{
+			Directory:        filepath.Clean(group.Directory),
+			Wave:             group.Wave,
+			MatchLabels:      selector.MatchLabels,
+			MatchExpressions: selector.MatchExpressions,
+		})
+	}
+	return scheduling
+}
+
 // For Promise spec
 type PromiseScheduling struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// +optional
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+func (p PromiseScheduling) LabelSelector() metav1.LabelSelector {
+	return metav1.LabelSelector{MatchLabels: p.MatchLabels, MatchExpressions: p.MatchExpressions}
 }
 
 // For /kratix/metadata/destination-selectors.yaml
 type WorkflowDestinationSelectors struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
 	// +optional
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+	// +optional
 	Directory string `json:"directory,omitempty"`
+	// Wave of the WorkloadGroup of the directory, see WorkloadGroup
+	// +optional
+	Wave int `json:"wave,omitempty"`
+}
+
+func (w WorkflowDestinationSelectors) LabelSelector() metav1.LabelSelector {
+	return metav1.LabelSelector{MatchLabels: w.MatchLabels, MatchExpressions: w.MatchExpressions}
 }
 
 // PromiseStatus defines the observed state of Promise
@@ -161,19 +345,50 @@ type PromisePipelines struct {
 
 var ErrNoAPI = fmt.Errorf("promise does not contain an API")
 
-func SquashPromiseScheduling(scheduling []PromiseScheduling) map[string]string {
-	if len(scheduling) == 0 {
-		return nil
+// SquashPromiseScheduling merges the scheduling of a Promise into a single
+// selector; the first item in the array gets priority
+func SquashPromiseScheduling(scheduling []PromiseScheduling) metav1.LabelSelector {
+	selectors := make([]metav1.LabelSelector, len(scheduling))
+	for i := range scheduling {
+		selectors[i] = scheduling[i].LabelSelector()
 	}
+	return MergeLabelSelectors(selectors...)
+}
+
+// MergeLabelSelectors merges selectors given from highest to lowest priority.
+// A label key constrained by a selector, through matchLabels or
+// matchExpressions, is no longer constrained by selectors of lower priority.
+func MergeLabelSelectors(selectors ...metav1.LabelSelector) metav1.LabelSelector {
+	merged := metav1.LabelSelector{}
+	constrainedKeys := map[string]bool{}
+
+	for _, selector := range selectors {
+		keys := map[string]bool{}
+		for key, value := range selector.MatchLabels {
+			if constrainedKeys[key] {
+				continue
+			}
+			if merged.MatchLabels == nil {
+				merged.MatchLabels = map[string]string{}
+			}
+			merged.MatchLabels[key] = value
+			keys[key] = true
+		}
+
+		for _, requirement := range selector.MatchExpressions {
+			if constrainedKeys[requirement.Key] {
+				continue
+			}
+			merged.MatchExpressions = append(merged.MatchExpressions, requirement)
+			keys[requirement.Key] = true
+		}
 
-	labels := map[string]string{}
-	//Reverse order, first item in the array gets priority this way
-	for i := len(scheduling) - 1; i >= 0; i-- {
-		for key, value := range scheduling[i].MatchLabels {
-			labels[key] = value
+		for key := range keys {
+			constrainedKeys[key] = true
 		}
 	}
-	return labels
+
+	return merged
 }
 
 func (p *Promise) GetSchedulingSelectors() map[string]string {
@@ -4,7 +4,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 var _ = Describe("Promise", func() {
@@ -39,6 +41,91 @@ var _ = Describe("Promise", func() {
 			selectors := promise.GetSchedulingSelectors()
 			Expect(labels.FormatLabels(selectors)).To(Equal(`environment=dev,pci=false,secure=false`))
 		})
+
+		It("squashes matchLabels and matchExpressions, the first item taking priority", func() {
+			selector := platformv1alpha1.SquashPromiseScheduling([]platformv1alpha1.PromiseScheduling{
+				{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"eu-west"}},
+					},
+				},
+				{
+					MatchLabels: map[string]string{"region": "us-east", "environment": "dev"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "gpu", Operator: metav1.LabelSelectorOpExists},
+					},
+				},
+			})
+
+			Expect(selector).To(Equal(metav1.LabelSelector{
+				MatchLabels: map[string]string{"environment": "dev"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "region", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"eu-west"}},
+					{Key: "gpu", Operator: metav1.LabelSelectorOpExists},
+				},
+			}))
+		})
 	})
 
+	Describe("MergeLabelSelectors", func() {
+		It("only keeps the constraints of the highest priority selector for each key", func() {
+			promise := metav1.LabelSelector{
+				MatchLabels: map[string]string{"environment": "prod"},
+			}
+			promiseWorkflow := metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "environment", Operator: metav1.LabelSelectorOpIn, Values: []string{"dev", "staging"}},
+					{Key: "zone", Operator: metav1.LabelSelectorOpDoesNotExist},
+				},
+			}
+			resourceWorkflow := metav1.LabelSelector{
+				MatchLabels: map[string]string{"zone": "a", "size": "large"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "size", Operator: metav1.LabelSelectorOpIn, Values: []string{"large", "xlarge"}},
+				},
+			}
+
+			merged := platformv1alpha1.MergeLabelSelectors(promise, promiseWorkflow, resourceWorkflow)
+			Expect(merged).To(Equal(metav1.LabelSelector{
+				MatchLabels: map[string]string{"environment": "prod", "size": "large"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "zone", Operator: metav1.LabelSelectorOpDoesNotExist},
+					{Key: "size", Operator: metav1.LabelSelectorOpIn, Values: []string{"large", "xlarge"}},
+				},
+			}))
+		})
+
+		It("returns an empty selector when there is nothing to merge", func() {
+			Expect(platformv1alpha1.MergeLabelSelectors()).To(Equal(metav1.LabelSelector{}))
+		})
+	})
+
+	Describe("WorkloadPolicy", func() {
+		deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+		It("allows every manifest when unset", func() {
+			var policy *platformv1alpha1.WorkloadPolicy
+			Expect(policy.Check(deployment, "anywhere")).To(Succeed())
+		})
+
+		It("allows the kinds in the list, in any version unless one is set", func() {
+			policy := &platformv1alpha1.WorkloadPolicy{AllowedKinds: []platformv1alpha1.AllowedWorkloadKind{
+				{Group: "apps", Kind: "Deployment"},
+				{Version: "v1", Kind: "Service"},
+			}}
+
+			Expect(policy.Check(deployment, "")).To(Succeed())
+			Expect(policy.Check(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, "")).To(Succeed())
+			Expect(policy.Check(schema.GroupVersionKind{Version: "v2", Kind: "Service"}, "")).To(MatchError("kind /v2, Kind=Service is not allowed by the Promise"))
+			Expect(policy.Check(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, "")).To(HaveOccurred())
+		})
+
+		It("allows the namespaces in the list and manifests without a namespace", func() {
+			policy := &platformv1alpha1.WorkloadPolicy{AllowedNamespaces: []string{"team-a"}}
+
+			Expect(policy.Check(deployment, "team-a")).To(Succeed())
+			Expect(policy.Check(deployment, "")).To(Succeed())
+			Expect(policy.Check(deployment, "kube-system")).To(MatchError("namespace kube-system is not allowed by the Promise"))
+		})
+	})
 })
@@ -99,6 +99,10 @@ func (p *Promise) ValidateCreate() (admission.Warnings, error) {
 		return nil, err
 	}
 
+	if err := p.Spec.ValidateDependencyGroups(); err != nil {
+		return nil, err
+	}
+
 	return warnings, nil
 }
 
@@ -141,6 +145,10 @@ func (p *Promise) ValidateUpdate(old runtime.Object) (admission.Warnings, error)
 		return nil, err
 	}
 
+	if err := p.Spec.ValidateDependencyGroups(); err != nil {
+		return nil, err
+	}
+
 	oldCrd, errOldCrd := oldPromise.GetAPIAsCRD()
 	newCrd, errNewCrd := p.GetAPIAsCRD()
 	if errOldCrd == ErrNoAPI {
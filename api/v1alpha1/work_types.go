@@ -17,6 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/syntasso/kratix/lib/hash"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -28,10 +33,26 @@ const DefaultWorkloadGroupDirectory = "."
 // WorkStatus defines the observed state of Work
 type WorkStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// PlacedReplicas is the lowest number of Destinations any WorkloadGroup is
+	// scheduled to
+	// +optional
+	PlacedReplicas int `json:"placedReplicas,omitempty"`
+	// +optional
+	WorkloadGroups []WorkloadGroupStatus `json:"workloadGroups,omitempty"`
+}
+
+type WorkloadGroupStatus struct {
+	ID        string `json:"id"`
+	Directory string `json:"directory,omitempty"`
+	// PlacedReplicas is the number of Destinations the WorkloadGroup is
+	// scheduled to
+	PlacedReplicas int `json:"placedReplicas"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:JSONPath=".spec.replicas",name="Replicas",type=integer
+//+kubebuilder:printcolumn:JSONPath=".status.placedReplicas",name="Placed",type=integer
 
 // Work is the Schema for the works API
 type Work struct {
@@ -44,9 +65,16 @@ type Work struct {
 
 // WorkSpec defines the desired state of Work
 type WorkSpec struct {
-	// -1 denotes dependencies, 1 denotes Resource Request
+	// Replicas is the number of Destinations each WorkloadGroup is scheduled
+	// to. -1 denotes dependencies, which are scheduled to every matching
+	// Destination.
 	Replicas int `json:"replicas,omitempty"`
 
+	// SpreadConstraint spreads the replicas of Resource Requests across the
+	// values of a Destination label
+	// +optional
+	SpreadConstraint *SpreadConstraint `json:"spreadConstraint,omitempty"`
+
 	WorkloadCoreFields `json:",inline"`
 }
 
@@ -94,24 +122,116 @@ func NewPromiseDependenciesWork(promise *Promise) (*Work, error) {
 
 	if len(promise.Spec.DestinationSelectors) > 0 {
 		work.Spec.WorkloadGroups[0].DestinationSelectors = []WorkloadGroupScheduling{
-			{
-				MatchLabels: SquashPromiseScheduling(promise.Spec.DestinationSelectors),
-				Source:      "promise",
+			NewWorkloadGroupScheduling(SquashPromiseScheduling(promise.Spec.DestinationSelectors), "promise"),
+		}
+	}
+
+	if err := promise.Spec.ValidateDependencyGroups(); err != nil {
+		return nil, err
+	}
+
+	dependencyGroupScheduling := promise.Spec.DependencyGroupScheduling()
+	for i, group := range promise.Spec.DependencyGroups {
+		yamlBytes, err := group.Dependencies.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		directory := filepath.Clean(group.Directory)
+		workloadGroup := WorkloadGroup{
+			ID:        hash.ComputeHash(directory),
+			Directory: directory,
+			Wave:      group.Wave,
+			Workloads: []Workload{
+				{
+					Content:  string(yamlBytes),
+					Filepath: filepath.Join(directory, DependenciesFile),
+				},
 			},
 		}
+
+		// the selectors of the Promise take precedence over those of the group
+		workloadGroup.DestinationSelectors = append(
+			[]WorkloadGroupScheduling{NewWorkloadGroupScheduling(dependencyGroupScheduling[i].LabelSelector(), "promise-dependency-group")},
+			work.Spec.WorkloadGroups[0].DestinationSelectors...,
+		)
+		work.Spec.WorkloadGroups = append(work.Spec.WorkloadGroups, workloadGroup)
 	}
 
 	return work, nil
 }
 
 func (w *Work) IsResourceRequest() bool {
-	return w.Spec.Replicas == ResourceRequestReplicas
+	return w.Spec.Replicas >= ResourceRequestReplicas
 }
 
 func (w *Work) IsDependency() bool {
 	return w.Spec.Replicas == DependencyReplicas
 }
 
+// Placement describes how many Destinations the workloads of a Resource
+// Request are scheduled to. It is set on the Promise, or by the resource
+// configure pipeline in /kratix/metadata/placement.yaml
+type Placement struct {
+	// Replicas is the number of Destinations to schedule each WorkloadGroup
+	// to. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int `json:"replicas,omitempty"`
+	// +optional
+	SpreadConstraint *SpreadConstraint `json:"spreadConstraint,omitempty"`
+}
+
+// SpreadConstraint spreads replicas evenly across the domains defined by the
+// values of a Destination label, e.g. one replica per zone. Destinations
+// without the label are not scheduled to.
+type SpreadConstraint struct {
+	// TopologyKey is the Destination label whose values are the domains
+	TopologyKey string `json:"topologyKey"`
+	// MaxReplicasPerDomain limits the replicas scheduled to Destinations in
+	// the same domain. 0 means no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReplicasPerDomain int `json:"maxReplicasPerDomain,omitempty"`
+}
+
+// Merge returns the placement with the fields unset in p taken from
+// fallback
+func (p *Placement) Merge(fallback *Placement) *Placement {
+	if p == nil {
+		return fallback
+	}
+	if fallback == nil {
+		return p
+	}
+	merged := *p
+	if merged.Replicas == 0 {
+		merged.Replicas = fallback.Replicas
+	}
+	if merged.SpreadConstraint == nil {
+		merged.SpreadConstraint = fallback.SpreadConstraint
+	}
+	return &merged
+}
+
+func (p *Placement) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Replicas < 0 {
+		return fmt.Errorf("replicas must be at least 1, got %d", p.Replicas)
+	}
+	if p.SpreadConstraint != nil {
+		if p.SpreadConstraint.TopologyKey == "" {
+			return fmt.Errorf("spreadConstraint.topologyKey is required")
+		}
+		if p.SpreadConstraint.MaxReplicasPerDomain < 0 {
+			return fmt.Errorf("spreadConstraint.maxReplicasPerDomain must not be negative, got %d", p.SpreadConstraint.MaxReplicasPerDomain)
+		}
+	}
+	return nil
+}
+
 // WorkloadGroup represents the workloads in a particular directory that should
 // be scheduled to a to Destination
 type WorkloadGroup struct {
@@ -120,18 +240,93 @@ type WorkloadGroup struct {
 	Directory            string                    `json:"directory,omitempty"`
 	ID                   string                    `json:"id,omitempty"`
 	DestinationSelectors []WorkloadGroupScheduling `json:"destinationSelectors,omitempty"`
+	// Wave orders the writes of the WorkloadGroups of the Work to a
+	// Destination: a WorkloadGroup is written once those of all lower waves
+	// are. The WorkloadGroups of a resource request are also written once
+	// the Promise dependencies of the same or lower waves are. Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Wave int `json:"wave,omitempty"`
+}
+
+// WorkloadsHash returns the hash of the workloads in the WorkloadGroup, with
+// which WorkPlacements reference the version of the workloads to write
+func (w WorkloadGroup) WorkloadsHash() string {
+	content, _ := json.Marshal(w.Workloads)
+	return hash.ComputeHash(string(content))
 }
 
 type WorkloadGroupScheduling struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
-	Source      string            `json:"source,omitempty"`
+	// +optional
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+	Source           string                            `json:"source,omitempty"`
+}
+
+func NewWorkloadGroupScheduling(selector metav1.LabelSelector, source string) WorkloadGroupScheduling {
+	return WorkloadGroupScheduling{
+		MatchLabels:      selector.MatchLabels,
+		MatchExpressions: selector.MatchExpressions,
+		Source:           source,
+	}
+}
+
+func (w WorkloadGroupScheduling) LabelSelector() metav1.LabelSelector {
+	return metav1.LabelSelector{MatchLabels: w.MatchLabels, MatchExpressions: w.MatchExpressions}
 }
 
 // Workload represents the manifest workload to be deployed on destination
 type Workload struct {
 	// +optional
 	Filepath string `json:"filepath,omitempty"`
-	Content  string `json:"content,omitempty"`
+	// Content of the workload, encoded as set in Encoding. Empty when the
+	// content is stored in the ConfigMaps referenced by ContentFrom.
+	// +optional
+	Content string `json:"content,omitempty"`
+	// Encoding of the content; plain text when unset
+	// +optional
+	// +kubebuilder:validation:Enum=base64;gzip+base64
+	Encoding WorkloadEncoding `json:"encoding,omitempty"`
+	// Mode is the permission bits of the file, e.g. 0755. Files are written
+	// with 0644 when unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	Mode *int32 `json:"mode,omitempty"`
+	// ContentFrom references the ConfigMaps holding the encoded content of
+	// workloads too large to store in the Work
+	// +optional
+	ContentFrom *WorkloadContentSource `json:"contentFrom,omitempty"`
+	// Digest is the sha256 of the decoded content, set when the content is
+	// encoded
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+type WorkloadEncoding string
+
+const (
+	// WorkloadEncodingBase64 is base64 encoded content, used for binary files
+	WorkloadEncodingBase64 WorkloadEncoding = "base64"
+	// WorkloadEncodingGzipBase64 is gzip compressed content, base64 encoded
+	WorkloadEncodingGzipBase64 WorkloadEncoding = "gzip+base64"
+)
+
+// DefaultWorkloadFileMode is the mode of workloads without a Mode
+const DefaultWorkloadFileMode os.FileMode = 0644
+
+// FileMode returns the mode to write the workload with
+func (w Workload) FileMode() os.FileMode {
+	if w.Mode == nil {
+		return DefaultWorkloadFileMode
+	}
+	return os.FileMode(*w.Mode).Perm()
+}
+
+type WorkloadContentSource struct {
+	// ConfigMaps, in the namespace of the Work, whose chunks of the encoded
+	// content are concatenated in order
+	ConfigMaps []string `json:"configMaps"`
 }
 
 //+kubebuilder:object:root=true
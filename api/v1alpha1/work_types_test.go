@@ -4,6 +4,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/hash"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var _ = Describe("Work", func() {
@@ -67,4 +70,71 @@ var _ = Describe("Work", func() {
 		})
 	})
 
+	Describe("NewPromiseDependenciesWork", func() {
+		var promise *v1alpha1.Promise
+
+		dependency := func(kind, name string) v1alpha1.Dependency {
+			return v1alpha1.Dependency{Unstructured: unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       kind,
+				"metadata":   map[string]interface{}{"name": name},
+			}}}
+		}
+
+		BeforeEach(func() {
+			promise = &v1alpha1.Promise{
+				ObjectMeta: metav1.ObjectMeta{Name: "redis"},
+				Spec: v1alpha1.PromiseSpec{
+					Dependencies:         v1alpha1.Dependencies{dependency("Namespace", "redis-crds")},
+					DestinationSelectors: []v1alpha1.PromiseScheduling{{MatchLabels: map[string]string{"environment": "dev"}}},
+					DependencyGroups: []v1alpha1.DependencyGroup{
+						{
+							Directory:            "operator/",
+							DestinationSelectors: []v1alpha1.PromiseScheduling{{MatchLabels: map[string]string{"tier": "data"}}},
+							Wave:                 1,
+							Dependencies:         v1alpha1.Dependencies{dependency("Deployment", "redis-operator")},
+						},
+					},
+				},
+			}
+		})
+
+		It("creates a WorkloadGroup for each dependency group", func() {
+			work, err := v1alpha1.NewPromiseDependenciesWork(promise)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(work.Spec.WorkloadGroups).To(HaveLen(2))
+			Expect(work.Spec.WorkloadGroups[0].Directory).To(Equal("."))
+			Expect(work.Spec.WorkloadGroups[0].Workloads[0].Content).To(ContainSubstring("redis-crds"))
+
+			group := work.Spec.WorkloadGroups[1]
+			Expect(group.Directory).To(Equal("operator"))
+			Expect(group.ID).To(Equal(hash.ComputeHash("operator")))
+			Expect(group.Wave).To(Equal(1))
+			Expect(work.Spec.WorkloadGroups[0].Wave).To(Equal(0))
+			Expect(group.Workloads).To(HaveLen(1))
+			Expect(group.Workloads[0].Filepath).To(Equal("operator/dependencies.yaml"))
+			Expect(group.Workloads[0].Content).To(ContainSubstring("redis-operator"))
+			Expect(group.Workloads[0].Content).NotTo(ContainSubstring("redis-crds"))
+			Expect(group.DestinationSelectors).To(ConsistOf(
+				v1alpha1.WorkloadGroupScheduling{MatchLabels: map[string]string{"tier": "data"}, Source: "promise-dependency-group"},
+				v1alpha1.WorkloadGroupScheduling{MatchLabels: map[string]string{"environment": "dev"}, Source: "promise"},
+			))
+		})
+
+		DescribeTable("rejects invalid dependency group directories",
+			func(directories []string, message string) {
+				promise.Spec.DependencyGroups = nil
+				for _, directory := range directories {
+					promise.Spec.DependencyGroups = append(promise.Spec.DependencyGroups, v1alpha1.DependencyGroup{Directory: directory})
+				}
+				_, err := v1alpha1.NewPromiseDependenciesWork(promise)
+				Expect(err).To(MatchError(message))
+			},
+			Entry("the root directory", []string{"."}, `invalid dependency group directory ".": must be a subdirectory of the Work`),
+			Entry("outside the Work", []string{"../operator"}, `invalid dependency group directory "../operator": must be a subdirectory of the Work`),
+			Entry("an absolute path", []string{"/operator"}, `invalid dependency group directory "/operator": must be a subdirectory of the Work`),
+			Entry("a duplicate", []string{"operator", "operator/"}, `duplicate dependency group directory "operator/"`),
+		)
+	})
 })
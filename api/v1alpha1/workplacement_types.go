@@ -22,17 +22,38 @@ import (
 
 // WorkPlacementSpec defines the desired state of WorkPlacement
 type WorkPlacementSpec struct {
-	TargetDestinationName string     `json:"targetDestinationName,omitempty"`
-	Workloads             []Workload `json:"workloads,omitempty"`
-	PromiseName           string     `json:"promiseName,omitempty"`
+	TargetDestinationName string `json:"targetDestinationName,omitempty"`
+	// Workloads are only set on WorkPlacements created by earlier versions of
+	// Kratix. WorkPlacements now reference the workloads of their Work through
+	// WorkName, ID and WorkloadsHash.
+	// +optional
+	Workloads   []Workload `json:"workloads,omitempty"`
+	PromiseName string     `json:"promiseName,omitempty"`
 	// +optional
 	ResourceName string `json:"resourceName,omitempty"`
-	ID           string `json:"id,omitempty"`
+	// ID of the WorkloadGroup in the Work
+	ID string `json:"id,omitempty"`
+	// WorkName is the name of the Work, in the namespace of the WorkPlacement,
+	// holding the workloads
+	// +optional
+	WorkName string `json:"workName,omitempty"`
+	// WorkloadsHash is the hash of the WorkloadGroup workloads to write
+	// +optional
+	WorkloadsHash string `json:"workloadsHash,omitempty"`
+	// Wave of the WorkloadGroup. The workloads are written once the
+	// WorkPlacements of lower waves of the Work, on the same Destination, are
+	// written.
+	// +optional
+	Wave int `json:"wave,omitempty"`
 }
 
 // WorkPlacementStatus defines the observed state of WorkPlacement
 type WorkPlacementStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Path is the directory, within the Path of the StateStore, the workloads
+	// were last written to
+	// +optional
+	Path string `json:"path,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Syntasso.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// destination-bootstrap prints the Flux or Argo CD manifests that point the
+// GitOps agent of a Destination at its directories in the State Store. It
+// reads the Destination and State Store from the platform cluster of the
+// current kubeconfig context. Apply the output to the Destination:
+//
+//	destination-bootstrap -destination worker-1 | kubectl --context worker apply -f -
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/bootstrap"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func main() {
+	var destinationName, format, namespace, secretName string
+	flag.StringVar(&destinationName, "destination", "", "Name of the Destination to bootstrap")
+	flag.StringVar(&format, "format", string(bootstrap.FormatFlux), "Manifests to generate, flux or argocd")
+	flag.StringVar(&namespace, "namespace", "", "Namespace of the generated objects, defaults to flux-system or argocd")
+	flag.StringVar(&secretName, "secret-name", "", "Secret on the Destination holding the State Store credentials of the GitOps agent")
+	flag.Parse()
+
+	if destinationName == "" {
+		fmt.Fprintln(os.Stderr, "-destination is required")
+		os.Exit(1)
+	}
+
+	if err := run(destinationName, bootstrap.Format(format), bootstrap.Options{Namespace: namespace, SecretName: secretName}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(destinationName string, format bootstrap.Format, opts bootstrap.Options) error {
+	scheme := runtime.NewScheme()
+	if err := platformv1alpha1.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+	k8sClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	destination := platformv1alpha1.Destination{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: destinationName}, &destination); err != nil {
+		return fmt.Errorf("failed to get Destination %s: %w", destinationName, err)
+	}
+	if destination.Spec.StateStoreRef == nil {
+		return fmt.Errorf("Destination %s has no stateStoreRef", destinationName)
+	}
+
+	var stateStore client.Object
+	switch kind := destination.Spec.StateStoreRef.Kind; kind {
+	case "GitStateStore":
+		stateStore = &platformv1alpha1.GitStateStore{}
+	case "BucketStateStore":
+		stateStore = &platformv1alpha1.BucketStateStore{}
+	case "KubernetesStateStore":
+		stateStore = &platformv1alpha1.KubernetesStateStore{}
+	default:
+		return fmt.Errorf("unsupported kind %s", kind)
+	}
+	stateStoreRef := client.ObjectKey{Name: destination.Spec.StateStoreRef.Name, Namespace: destination.GetNamespace()}
+	if err := k8sClient.Get(ctx, stateStoreRef, stateStore); err != nil {
+		return fmt.Errorf("failed to get %s %s: %w", destination.Spec.StateStoreRef.Kind, stateStoreRef.Name, err)
+	}
+
+	manifests, err := bootstrap.Manifests(format, destination, stateStore, opts)
+	if err != nil {
+		return err
+	}
+	output, err := bootstrap.ToYAML(manifests)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(output)
+	return err
+}
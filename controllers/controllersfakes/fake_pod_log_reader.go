@@ -0,0 +1,125 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package controllersfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/syntasso/kratix/controllers"
+)
+
+type FakePodLogReader struct {
+	TailLogsStub        func(context.Context, string, string, string, int64) (string, error)
+	tailLogsMutex       sync.RWMutex
+	tailLogsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 int64
+	}
+	tailLogsReturns struct {
+		result1 string
+		result2 error
+	}
+	tailLogsReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakePodLogReader) TailLogs(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 int64) (string, error) {
+	fake.tailLogsMutex.Lock()
+	ret, specificReturn := fake.tailLogsReturnsOnCall[len(fake.tailLogsArgsForCall)]
+	fake.tailLogsArgsForCall = append(fake.tailLogsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 int64
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.TailLogsStub
+	fakeReturns := fake.tailLogsReturns
+	fake.recordInvocation("TailLogs", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.tailLogsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePodLogReader) TailLogsCallCount() int {
+	fake.tailLogsMutex.RLock()
+	defer fake.tailLogsMutex.RUnlock()
+	return len(fake.tailLogsArgsForCall)
+}
+
+func (fake *FakePodLogReader) TailLogsCalls(stub func(context.Context, string, string, string, int64) (string, error)) {
+	fake.tailLogsMutex.Lock()
+	defer fake.tailLogsMutex.Unlock()
+	fake.TailLogsStub = stub
+}
+
+func (fake *FakePodLogReader) TailLogsArgsForCall(i int) (context.Context, string, string, string, int64) {
+	fake.tailLogsMutex.RLock()
+	defer fake.tailLogsMutex.RUnlock()
+	argsForCall := fake.tailLogsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakePodLogReader) TailLogsReturns(result1 string, result2 error) {
+	fake.tailLogsMutex.Lock()
+	defer fake.tailLogsMutex.Unlock()
+	fake.TailLogsStub = nil
+	fake.tailLogsReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePodLogReader) TailLogsReturnsOnCall(i int, result1 string, result2 error) {
+	fake.tailLogsMutex.Lock()
+	defer fake.tailLogsMutex.Unlock()
+	fake.TailLogsStub = nil
+	if fake.tailLogsReturnsOnCall == nil {
+		fake.tailLogsReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.tailLogsReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePodLogReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.tailLogsMutex.RLock()
+	defer fake.tailLogsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakePodLogReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ controllers.PodLogReader = new(FakePodLogReader)
@@ -19,32 +19,50 @@ package controllers
 import (
 	"context"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/yaml"
 
 	"github.com/go-logr/logr"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/resourceutil"
 	"github.com/syntasso/kratix/lib/writers"
 )
 
+const workPlacementCleanupDestinationFinalizer = "finalizers.destination.kratix.io/workplacement-cleanup"
+
+const (
+	canaryNamespaceFile = "kratix-canary-namespace.yaml"
+	canaryConfigMapFile = "kratix-canary-configmap.yaml"
+)
+
+var destinationFinalizers = []string{workPlacementCleanupDestinationFinalizer}
+
 // DestinationReconciler reconciles a Destination object
 type DestinationReconciler struct {
-	Client    client.Client
-	Log       logr.Logger
-	Scheduler *Scheduler
+	Client client.Client
+	Log    logr.Logger
 }
 
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=destinations,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=platform.kratix.io,resources=bucketstatestores;gitstatestores,verbs=get;list;watch
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=bucketstatestores;gitstatestores;kubernetesstatestores,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=destinations/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=destinations/finalizers,verbs=update
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=workplacements,verbs=get;list;watch;update;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the destination closer to the desired state.
@@ -70,7 +88,15 @@ func (r *DestinationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		logger: logger,
 	}
 
-	writer, err := newWriter(opts, *destination)
+	if !destination.DeletionTimestamp.IsZero() {
+		return r.deleteDestination(opts, destination)
+	}
+
+	if resourceutil.FinalizersAreMissing(destination, destinationFinalizers) {
+		return addFinalizers(opts, destination, destinationFinalizers)
+	}
+
+	writer, layout, err := newWriter(opts, *destination)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return defaultRequeue, nil
@@ -78,30 +104,241 @@ func (r *DestinationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	//destination.Spec.Path is optional, may be empty
-	path := filepath.Join(destination.Spec.Path, destination.Name)
-	logger = logger.WithValues("path", path)
+	dependenciesDir, resourcesDir, err := typeDirs(layout)
+	if err != nil {
+		logger.Error(err, "invalid State Store layout")
+		return ctrl.Result{}, err
+	}
+	logger = logger.WithValues("dependenciesDir", dependenciesDir, "resourcesDir", resourcesDir)
 
-	if err := r.createDependenciesPathWithExample(writer); err != nil {
+	if err := r.createDependenciesPathWithExample(writer, dependenciesDir); err != nil {
 		logger.Error(err, "unable to write dependencies to state store")
 		return defaultRequeue, nil
 	}
 
-	if err := r.createResourcePathWithExample(writer); err != nil {
+	if err := r.createResourcePathWithExample(writer, resourcesDir); err != nil {
 		logger.Error(err, "unable to write dependencies to state store")
 		return defaultRequeue, nil
 	}
 
-	// TODO: Instead of this function call, we could watch for changes to the Destination
-	// CRD and triggering all Works to reconcile on every change.
-	if err := r.Scheduler.ReconcileAllDependencyWorks(); err != nil {
-		logger.Error(err, "unable to schedule destination resources")
+	if err := r.removeMovedCanaries(ctx, writer, destination, uniqueDirs(dependenciesDir, resourcesDir), logger); err != nil {
+		logger.Error(err, "unable to remove canary files from previous directories")
+		return defaultRequeue, nil
+	}
+
+	return r.reconcileDrain(ctx, destination, logger)
+}
+
+// deleteDestination removes the WorkPlacements on the Destination before
+// letting it go. With the Delete policy the WorkPlacement controller removes
+// their workloads from the State Store; with the Orphan policy their cleanup
+// finalizer is dropped first, so the workloads are left in place. Either way,
+// the Scheduler then places resource request workloads on other Destinations.
+func (r *DestinationReconciler) deleteDestination(o opts, destination *platformv1alpha1.Destination) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(destination, workPlacementCleanupDestinationFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	workPlacements, err := r.destinationWorkPlacements(o.ctx, destination.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(workPlacements) > 0 {
+		o.logger.Info("waiting for workplacements to be removed from destination",
+			"deletionPolicy", destination.Spec.DeletionPolicy,
+			"workPlacements", len(workPlacements),
+		)
+		for i := range workPlacements {
+			if err := r.removeWorkPlacement(o, &workPlacements[i], destination.OrphansWorkloads()); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		return defaultRequeue, nil
 	}
-	return ctrl.Result{}, nil
+
+	if !destination.OrphansWorkloads() {
+		writer, layout, err := newWriter(o, *destination)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return defaultRequeue, nil
+			}
+			return ctrl.Result{}, err
+		}
+
+		dependenciesDir, resourcesDir, err := typeDirs(layout)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		o.logger.Info("cleaning up destination files on state store")
+		//MinIO needs a trailing slash to delete a directory
+		for _, dir := range uniqueDirs(append([]string{resourcesDir, dependenciesDir}, destination.Status.CanaryPaths...)...) {
+			if err := writer.RemoveObject(dir + "/"); err != nil {
+				o.logger.Error(err, "error removing destination files from state store, will try again in 5 seconds", "dir", dir)
+				return defaultRequeue, nil
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(destination, workPlacementCleanupDestinationFinalizer)
+	return ctrl.Result{}, r.Client.Update(o.ctx, destination)
+}
+
+func (r *DestinationReconciler) removeWorkPlacement(o opts, workPlacement *platformv1alpha1.WorkPlacement, orphan bool) error {
+	if orphan && controllerutil.ContainsFinalizer(workPlacement, repoCleanupWorkPlacementFinalizer) {
+		controllerutil.RemoveFinalizer(workPlacement, repoCleanupWorkPlacementFinalizer)
+		if err := r.Client.Update(o.ctx, workPlacement); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+
+	if !workPlacement.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	o.logger.Info("deleting workplacement", "workPlacement", client.ObjectKeyFromObject(workPlacement))
+	return client.IgnoreNotFound(r.Client.Delete(o.ctx, workPlacement))
 }
 
-func (r *DestinationReconciler) createResourcePathWithExample(writer writers.StateStoreWriter) error {
+// reconcileDrain migrates the resource request WorkPlacements off a draining
+// Destination one at a time. The current WorkPlacement is labelled as
+// draining, which makes the Scheduler move it to another eligible Destination
+// once its replacement is written.
+func (r *DestinationReconciler) reconcileDrain(ctx context.Context, destination *platformv1alpha1.Destination, logger logr.Logger) (ctrl.Result, error) {
+	if !destination.Spec.Drain {
+		if destination.Status.Drain == nil {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Info("drain cancelled")
+		if current := destination.Status.Drain.Current; current != "" {
+			if err := r.setWorkPlacementDraining(ctx, current, false); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		destination.Status.Drain = nil
+		return ctrl.Result{}, r.Client.Status().Update(ctx, destination)
+	}
+
+	workPlacements, err := r.resourceWorkPlacements(ctx, destination.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	drain := &platformv1alpha1.DrainStatus{Phase: platformv1alpha1.DrainPhaseDraining}
+	if destination.Status.Drain != nil {
+		drain = destination.Status.Drain.DeepCopy()
+	}
+
+	if drain.Current != "" && !containsWorkPlacement(workPlacements, drain.Current) {
+		logger.Info("workplacement migrated", "workPlacement", drain.Current)
+		drain.Migrated++
+		drain.Current = ""
+		drain.Message = ""
+	}
+	drain.Remaining = len(workPlacements)
+
+	result := defaultRequeue
+	if len(workPlacements) == 0 {
+		drain.Phase = platformv1alpha1.DrainPhaseDrained
+		drain.Message = "All resource request WorkPlacements migrated"
+		result = ctrl.Result{}
+	} else {
+		drain.Phase = platformv1alpha1.DrainPhaseDraining
+		if drain.Current == "" {
+			drain.Current = client.ObjectKeyFromObject(&workPlacements[0]).String()
+			logger.Info("migrating workplacement", "workPlacement", drain.Current)
+			if err := r.setWorkPlacementDraining(ctx, drain.Current, true); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		drain.Message = "Migrating WorkPlacement " + drain.Current
+		for _, workPlacement := range workPlacements {
+			if client.ObjectKeyFromObject(&workPlacement).String() != drain.Current {
+				continue
+			}
+			if condition := meta.FindStatusCondition(workPlacement.Status.Conditions, drainingConditionType); condition != nil {
+				drain.Message += ": " + condition.Message
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(destination.Status.Drain, drain) {
+		destination.Status.Drain = drain
+		if err := r.Client.Status().Update(ctx, destination); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// destinationWorkPlacements returns all WorkPlacements on the Destination,
+// including the ones being deleted
+func (r *DestinationReconciler) destinationWorkPlacements(ctx context.Context, destinationName string) ([]platformv1alpha1.WorkPlacement, error) {
+	workPlacementList := &platformv1alpha1.WorkPlacementList{}
+	if err := r.Client.List(ctx, workPlacementList, client.MatchingFields{WorkPlacementTargetDestinationField: destinationName}); err != nil {
+		return nil, err
+	}
+	return workPlacementList.Items, nil
+}
+
+// resourceWorkPlacements returns the resource request WorkPlacements on the
+// Destination, sorted by namespace and name
+func (r *DestinationReconciler) resourceWorkPlacements(ctx context.Context, destinationName string) ([]platformv1alpha1.WorkPlacement, error) {
+	destinationWorkPlacements, err := r.destinationWorkPlacements(ctx, destinationName)
+	if err != nil {
+		return nil, err
+	}
+
+	workPlacements := []platformv1alpha1.WorkPlacement{}
+	for _, workPlacement := range destinationWorkPlacements {
+		if workPlacement.Spec.ResourceName == "" || !workPlacement.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		workPlacements = append(workPlacements, workPlacement)
+	}
+
+	sort.Slice(workPlacements, func(i, j int) bool {
+		return client.ObjectKeyFromObject(&workPlacements[i]).String() < client.ObjectKeyFromObject(&workPlacements[j]).String()
+	})
+	return workPlacements, nil
+}
+
+func containsWorkPlacement(workPlacements []platformv1alpha1.WorkPlacement, key string) bool {
+	for _, workPlacement := range workPlacements {
+		if client.ObjectKeyFromObject(&workPlacement).String() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *DestinationReconciler) setWorkPlacementDraining(ctx context.Context, key string, draining bool) error {
+	namespace, name, _ := strings.Cut(key, "/")
+	workPlacement := &platformv1alpha1.WorkPlacement{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, workPlacement); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	labels := workPlacement.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	if draining {
+		labels[drainingLabel] = "true"
+	} else {
+		delete(labels, drainingLabel)
+	}
+	workPlacement.SetLabels(labels)
+	return r.Client.Update(ctx, workPlacement)
+}
+
+func (r *DestinationReconciler) createResourcePathWithExample(writer writers.StateStoreWriter, dir string) error {
 	kratixConfigMap := &v1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ConfigMap",
@@ -117,13 +354,13 @@ func (r *DestinationReconciler) createResourcePathWithExample(writer writers.Sta
 	}
 	nsBytes, _ := yaml.Marshal(kratixConfigMap)
 
-	return writer.WriteDirWithObjects(writers.PreserveExistingContentsInDir, resourcesDir, platformv1alpha1.Workload{
-		Filepath: "kratix-canary-configmap.yaml",
+	return writer.WriteDirWithObjects(writers.PreserveExistingContentsInDir, dir, platformv1alpha1.Workload{
+		Filepath: canaryConfigMapFile,
 		Content:  string(nsBytes),
 	})
 }
 
-func (r *DestinationReconciler) createDependenciesPathWithExample(writer writers.StateStoreWriter) error {
+func (r *DestinationReconciler) createDependenciesPathWithExample(writer writers.StateStoreWriter, dir string) error {
 	kratixNamespace := &v1.Namespace{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Namespace",
@@ -133,15 +370,94 @@ func (r *DestinationReconciler) createDependenciesPathWithExample(writer writers
 	}
 	nsBytes, _ := yaml.Marshal(kratixNamespace)
 
-	return writer.WriteDirWithObjects(writers.PreserveExistingContentsInDir, dependenciesDir, platformv1alpha1.Workload{
-		Filepath: "kratix-canary-namespace.yaml",
+	return writer.WriteDirWithObjects(writers.PreserveExistingContentsInDir, dir, platformv1alpha1.Workload{
+		Filepath: canaryNamespaceFile,
 		Content:  string(nsBytes),
 	})
 }
 
+// removeMovedCanaries removes the canary files from the directories they
+// were written to before the layout of the Destination changed, and records
+// the directories they are written to now. Destinations registered before
+// the directories were recorded had their canary files written with the
+// default layout.
+func (r *DestinationReconciler) removeMovedCanaries(ctx context.Context, writer writers.StateStoreWriter, destination *platformv1alpha1.Destination, canaryPaths []string, logger logr.Logger) error {
+	previous := destination.Status.CanaryPaths
+	if len(previous) == 0 {
+		dependenciesDir, resourcesDir, err := typeDirs(writers.DefaultLayout(*destination))
+		if err != nil {
+			return err
+		}
+		previous = uniqueDirs(dependenciesDir, resourcesDir)
+	}
+
+	for _, dir := range previous {
+		if slices.Contains(canaryPaths, dir) {
+			continue
+		}
+		logger.Info("removing canary files from previous directory", "dir", dir)
+		for _, file := range []string{canaryNamespaceFile, canaryConfigMapFile} {
+			if err := writer.RemoveObject(filepath.Join(dir, file)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if slices.Equal(destination.Status.CanaryPaths, canaryPaths) {
+		return nil
+	}
+	destination.Status.CanaryPaths = canaryPaths
+	return r.Client.Status().Update(ctx, destination)
+}
+
+// typeDirs returns the directories of the dependencies and of the resources
+// of the Destination
+func typeDirs(layout writers.Layout) (string, string, error) {
+	dependenciesDir, err := layout.TypeDir(writers.DependenciesType)
+	if err != nil {
+		return "", "", err
+	}
+	resourcesDir, err := layout.TypeDir(writers.ResourcesType)
+	if err != nil {
+		return "", "", err
+	}
+	return dependenciesDir, resourcesDir, nil
+}
+
+func uniqueDirs(dirs ...string) []string {
+	unique := []string{}
+	for _, dir := range dirs {
+		if !slices.Contains(unique, dir) {
+			unique = append(unique, dir)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// drainingDestinationForWorkPlacement returns the Destination the WorkPlacement
+// is scheduled to when it is being drained, so that the drain progresses as
+// soon as its WorkPlacements are migrated
+func (r *DestinationReconciler) drainingDestinationForWorkPlacement(ctx context.Context, obj client.Object) []reconcile.Request {
+	workPlacement := obj.(*platformv1alpha1.WorkPlacement)
+	destination := &platformv1alpha1.Destination{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: workPlacement.Spec.TargetDestinationName}, destination); err != nil {
+		return nil
+	}
+
+	if !destination.Spec.Drain && destination.Status.Drain == nil {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(destination)}}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *DestinationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&platformv1alpha1.Destination{}).
+		Watches(
+			&platformv1alpha1.WorkPlacement{},
+			handler.EnqueueRequestsFromMapFunc(r.drainingDestinationForWorkPlacement),
+		).
 		Complete(r)
 }
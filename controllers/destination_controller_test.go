@@ -0,0 +1,510 @@
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/controllers"
+	"github.com/syntasso/kratix/lib/workloadcontent"
+)
+
+var _ = Describe("DestinationReconciler", func() {
+	var (
+		ctx         context.Context
+		reconciler  *controllers.DestinationReconciler
+		destination *platformv1alpha1.Destination
+	)
+
+	const destinationFinalizer = "finalizers.destination.kratix.io/workplacement-cleanup"
+	const workPlacementFinalizer = "finalizers.workplacement.kratix.io/repo-cleanup"
+
+	reconcile := func() ctrl.Result {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(destination)})
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	createWorkPlacement := func(name, destinationName string) {
+		workPlacement := &platformv1alpha1.WorkPlacement{
+			ObjectMeta: v1.ObjectMeta{
+				Name:       name,
+				Namespace:  "default",
+				Finalizers: []string{workPlacementFinalizer},
+			},
+			Spec: platformv1alpha1.WorkPlacementSpec{
+				TargetDestinationName: destinationName,
+				ID:                    name,
+			},
+		}
+		Expect(fakeK8sClient.Create(ctx, workPlacement)).To(Succeed())
+	}
+
+	getWorkPlacement := func(name string) (*platformv1alpha1.WorkPlacement, error) {
+		workPlacement := &platformv1alpha1.WorkPlacement{}
+		err := fakeK8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, workPlacement)
+		return workPlacement, err
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		reconciler = &controllers.DestinationReconciler{
+			Client: fakeK8sClient,
+			Log:    ctrl.Log.WithName("controllers").WithName("Destination"),
+		}
+
+		destination = &platformv1alpha1.Destination{
+			ObjectMeta: v1.ObjectMeta{Name: "worker-1"},
+		}
+		Expect(fakeK8sClient.Create(ctx, destination)).To(Succeed())
+	})
+
+	It("adds the finalizer to the Destination", func() {
+		reconcile()
+
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+		Expect(destination.GetFinalizers()).To(ConsistOf(destinationFinalizer))
+	})
+
+	When("the Destination is deleted", func() {
+		BeforeEach(func() {
+			reconcile()
+
+			createWorkPlacement("on-worker-1", "worker-1")
+			createWorkPlacement("on-worker-2", "worker-2")
+
+			Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+		})
+
+		When("the deletion policy is Delete", func() {
+			BeforeEach(func() {
+				Expect(fakeK8sClient.Delete(ctx, destination)).To(Succeed())
+			})
+
+			It("deletes its WorkPlacements, leaving the State Store cleanup to their finalizer", func() {
+				result := reconcile()
+				Expect(result.RequeueAfter).ToNot(BeZero())
+
+				workPlacement, err := getWorkPlacement("on-worker-1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(workPlacement.GetDeletionTimestamp().IsZero()).To(BeFalse())
+				Expect(workPlacement.GetFinalizers()).To(ConsistOf(workPlacementFinalizer))
+
+				workPlacement, err = getWorkPlacement("on-worker-2")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(workPlacement.GetDeletionTimestamp().IsZero()).To(BeTrue())
+			})
+
+			It("blocks the deletion until the WorkPlacements are resolved", func() {
+				reconcile()
+				reconcile()
+
+				Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+				Expect(destination.GetFinalizers()).To(ConsistOf(destinationFinalizer))
+			})
+		})
+
+		When("its PathTemplate does not use its name before the type", func() {
+			It("does not remove the directories it shares with other Destinations", func() {
+				stateStore := &platformv1alpha1.BucketStateStore{
+					ObjectMeta: v1.ObjectMeta{Name: "shared-store"},
+					Spec: platformv1alpha1.BucketStateStoreSpec{
+						BucketName: "kratix",
+						Endpoint:   "localhost:9000",
+						AuthMethod: "IAM",
+					},
+				}
+				Expect(fakeK8sClient.Create(ctx, stateStore)).To(Succeed())
+
+				destination.Spec.StateStoreRef = &platformv1alpha1.StateStoreReference{Kind: "BucketStateStore", Name: "shared-store"}
+				destination.Spec.PathTemplate = "{{ .Type }}/{{ .Destination.Name }}/{{ .PromiseName }}/{{ .ResourceNamespace }}/{{ .ResourceName }}/{{ .ID }}"
+				Expect(fakeK8sClient.Update(ctx, destination)).To(Succeed())
+				Expect(fakeK8sClient.Delete(ctx, destination)).To(Succeed())
+				Expect(fakeK8sClient.Delete(ctx, &platformv1alpha1.WorkPlacement{ObjectMeta: v1.ObjectMeta{Name: "on-worker-1", Namespace: "default"}})).To(Succeed())
+				workPlacement, err := getWorkPlacement("on-worker-1")
+				Expect(err).ToNot(HaveOccurred())
+				workPlacement.SetFinalizers(nil)
+				Expect(fakeK8sClient.Update(ctx, workPlacement)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(destination)})
+				Expect(err).To(MatchError(ContainSubstring("it must use .Destination.Name before .Type")))
+
+				Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(destination), destination)).To(Succeed())
+				Expect(destination.GetFinalizers()).To(ConsistOf(destinationFinalizer))
+			})
+		})
+
+		When("the deletion policy is Orphan", func() {
+			BeforeEach(func() {
+				destination.Spec.DeletionPolicy = platformv1alpha1.DestinationDeletionPolicyOrphan
+				Expect(fakeK8sClient.Update(ctx, destination)).To(Succeed())
+				Expect(fakeK8sClient.Delete(ctx, destination)).To(Succeed())
+			})
+
+			It("removes its WorkPlacements without cleaning up the State Store, then the Destination", func() {
+				reconcile()
+
+				_, err := getWorkPlacement("on-worker-1")
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+				_, err = getWorkPlacement("on-worker-2")
+				Expect(err).ToNot(HaveOccurred())
+
+				result := reconcile()
+				Expect(result).To(Equal(ctrl.Result{}))
+				err = fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(destination), destination)
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+})
+
+var _ = Describe("WorkPlacementReconciler", func() {
+	var (
+		ctx        context.Context
+		reconciler *controllers.WorkPlacementReconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		reconciler = &controllers.WorkPlacementReconciler{
+			Client: fakeK8sClient,
+			Log:    ctrl.Log.WithName("controllers").WithName("WorkPlacement"),
+		}
+	})
+
+	When("the Work no longer holds the workloads the WorkPlacement references", func() {
+		It("waits for the Scheduler to update the WorkPlacement without writing", func() {
+			stateStore := &platformv1alpha1.BucketStateStore{
+				ObjectMeta: v1.ObjectMeta{Name: "store"},
+				Spec: platformv1alpha1.BucketStateStoreSpec{
+					BucketName: "kratix",
+					Endpoint:   "localhost:9000",
+					AuthMethod: "IAM",
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, stateStore)).To(Succeed())
+
+			destination := &platformv1alpha1.Destination{
+				ObjectMeta: v1.ObjectMeta{Name: "worker-1"},
+				Spec: platformv1alpha1.DestinationSpec{
+					StateStoreRef: &platformv1alpha1.StateStoreReference{Kind: "BucketStateStore", Name: "store"},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, destination)).To(Succeed())
+
+			workloadGroup := platformv1alpha1.WorkloadGroup{
+				ID:        "group-id",
+				Workloads: []platformv1alpha1.Workload{{Filepath: "new.yaml", Content: "new: content"}},
+			}
+			work := &platformv1alpha1.Work{
+				ObjectMeta: v1.ObjectMeta{Name: "work", Namespace: "default"},
+				Spec: platformv1alpha1.WorkSpec{
+					WorkloadCoreFields: platformv1alpha1.WorkloadCoreFields{
+						WorkloadGroups: []platformv1alpha1.WorkloadGroup{workloadGroup},
+					},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, work)).To(Succeed())
+
+			workPlacement := &platformv1alpha1.WorkPlacement{
+				ObjectMeta: v1.ObjectMeta{
+					Name:       "work.worker-1",
+					Namespace:  "default",
+					Finalizers: []string{"finalizers.workplacement.kratix.io/repo-cleanup"},
+				},
+				Spec: platformv1alpha1.WorkPlacementSpec{
+					TargetDestinationName: "worker-1",
+					WorkName:              "work",
+					ID:                    "group-id",
+					WorkloadsHash:         "previous-hash",
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, workPlacement)).To(Succeed())
+			Expect(workPlacement.Spec.WorkloadsHash).NotTo(Equal(workloadGroup.WorkloadsHash()))
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(workPlacement)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).ToNot(BeZero())
+
+			Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(workPlacement), workPlacement)).To(Succeed())
+			Expect(workPlacement.Status.Conditions).To(BeEmpty())
+		})
+	})
+
+	When("the WorkPlacement is in a later wave", func() {
+		var earlierWave, laterWave *platformv1alpha1.WorkPlacement
+
+		newWorkPlacement := func(name, destination string, wave int, workloadsHash string) *platformv1alpha1.WorkPlacement {
+			workPlacement := &platformv1alpha1.WorkPlacement{
+				ObjectMeta: v1.ObjectMeta{
+					Name:       name,
+					Namespace:  "default",
+					Labels:     map[string]string{"kratix.io/work": "waves"},
+					Finalizers: []string{"finalizers.workplacement.kratix.io/repo-cleanup"},
+				},
+				Spec: platformv1alpha1.WorkPlacementSpec{
+					TargetDestinationName: destination,
+					WorkName:              "waves",
+					ID:                    "group-" + name,
+					WorkloadsHash:         workloadsHash,
+					Wave:                  wave,
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, workPlacement)).To(Succeed())
+			return workPlacement
+		}
+
+		BeforeEach(func() {
+			stateStore := &platformv1alpha1.BucketStateStore{
+				ObjectMeta: v1.ObjectMeta{Name: "waves-store"},
+				Spec: platformv1alpha1.BucketStateStoreSpec{
+					BucketName: "kratix",
+					Endpoint:   "localhost:9000",
+					AuthMethod: "IAM",
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, stateStore)).To(Succeed())
+
+			destination := &platformv1alpha1.Destination{
+				ObjectMeta: v1.ObjectMeta{Name: "waves-destination"},
+				Spec: platformv1alpha1.DestinationSpec{
+					StateStoreRef: &platformv1alpha1.StateStoreReference{Kind: "BucketStateStore", Name: "waves-store"},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, destination)).To(Succeed())
+
+			workloadGroup := platformv1alpha1.WorkloadGroup{
+				ID:        "group-later",
+				Workloads: []platformv1alpha1.Workload{{Filepath: "cr.yaml", Content: "kind: Redis"}},
+				Wave:      1,
+			}
+			work := &platformv1alpha1.Work{
+				ObjectMeta: v1.ObjectMeta{Name: "waves", Namespace: "default"},
+				Spec: platformv1alpha1.WorkSpec{
+					WorkloadCoreFields: platformv1alpha1.WorkloadCoreFields{
+						WorkloadGroups: []platformv1alpha1.WorkloadGroup{workloadGroup},
+					},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, work)).To(Succeed())
+
+			earlierWave = newWorkPlacement("earlier", "waves-destination", 0, "")
+			laterWave = newWorkPlacement("later", "waves-destination", 1, workloadGroup.WorkloadsHash())
+		})
+
+		writtenCondition := func() *v1.Condition {
+			Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(laterWave), laterWave)).To(Succeed())
+			return meta.FindStatusCondition(laterWave.Status.Conditions, "WorkloadsWritten")
+		}
+
+		It("waits for the WorkPlacements of earlier waves on the Destination to be written", func() {
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(laterWave)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).ToNot(BeZero())
+
+			condition := writtenCondition()
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(v1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("WaitingForEarlierWaves"))
+			Expect(condition.Message).To(Equal("Waiting for WorkPlacement earlier of wave 0 to be written"))
+		})
+
+		It("writes once the earlier waves are written", func() {
+			meta.SetStatusCondition(&earlierWave.Status.Conditions, v1.Condition{
+				Type:               "WorkloadsWritten",
+				Status:             v1.ConditionTrue,
+				Reason:             "WorkloadsWrittenToStateStore",
+				ObservedGeneration: earlierWave.GetGeneration(),
+			})
+			Expect(fakeK8sClient.Status().Update(ctx, earlierWave)).To(Succeed())
+
+			// no State Store is listening, so the write is attempted and fails
+			_, _ = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(laterWave)})
+			Expect(writtenCondition().Reason).To(Equal("StateStoreWriteFailed"))
+		})
+
+		It("waits for the WorkPlacements of the Promise dependencies on the Destination to be written", func() {
+			meta.SetStatusCondition(&earlierWave.Status.Conditions, v1.Condition{
+				Type:               "WorkloadsWritten",
+				Status:             v1.ConditionTrue,
+				Reason:             "WorkloadsWrittenToStateStore",
+				ObservedGeneration: earlierWave.GetGeneration(),
+			})
+			Expect(fakeK8sClient.Status().Update(ctx, earlierWave)).To(Succeed())
+
+			laterWave.Spec.PromiseName = "redis"
+			laterWave.Spec.ResourceName = "my-redis"
+			Expect(fakeK8sClient.Update(ctx, laterWave)).To(Succeed())
+
+			dependencies := &platformv1alpha1.WorkPlacement{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "redis.waves-destination",
+					Namespace: "kratix-platform-system",
+					Labels:    map[string]string{"kratix.io/work": "redis"},
+				},
+				Spec: platformv1alpha1.WorkPlacementSpec{
+					TargetDestinationName: "waves-destination",
+					PromiseName:           "redis",
+					WorkName:              "redis",
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, dependencies)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(laterWave)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writtenCondition().Message).To(Equal("Waiting for WorkPlacement redis.waves-destination of wave 0 to be written"))
+
+			meta.SetStatusCondition(&dependencies.Status.Conditions, v1.Condition{
+				Type:               "WorkloadsWritten",
+				Status:             v1.ConditionTrue,
+				Reason:             "WorkloadsWrittenToStateStore",
+				ObservedGeneration: dependencies.GetGeneration(),
+			})
+			Expect(fakeK8sClient.Status().Update(ctx, dependencies)).To(Succeed())
+
+			_, _ = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(laterWave)})
+			Expect(writtenCondition().Reason).To(Equal("StateStoreWriteFailed"))
+		})
+
+		It("does not wait for WorkPlacements on other Destinations", func() {
+			earlierWave.Spec.TargetDestinationName = "other-destination"
+			Expect(fakeK8sClient.Update(ctx, earlierWave)).To(Succeed())
+
+			_, _ = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(laterWave)})
+			Expect(writtenCondition().Reason).To(Equal("StateStoreWriteFailed"))
+		})
+	})
+
+	When("a rendered template does not meet the WorkloadPolicy of the Promise", func() {
+		It("does not write the workloads", func() {
+			stateStore := &platformv1alpha1.BucketStateStore{
+				ObjectMeta: v1.ObjectMeta{Name: "policy-store"},
+				Spec: platformv1alpha1.BucketStateStoreSpec{
+					BucketName: "kratix",
+					Endpoint:   "localhost:9000",
+					AuthMethod: "IAM",
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, stateStore)).To(Succeed())
+
+			destination := &platformv1alpha1.Destination{
+				ObjectMeta: v1.ObjectMeta{Name: "policy-destination"},
+				Spec: platformv1alpha1.DestinationSpec{
+					StateStoreRef: &platformv1alpha1.StateStoreReference{Kind: "BucketStateStore", Name: "policy-store"},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, destination)).To(Succeed())
+
+			promise := &platformv1alpha1.Promise{
+				ObjectMeta: v1.ObjectMeta{Name: "policy-promise"},
+				Spec: platformv1alpha1.PromiseSpec{
+					WorkloadPolicy: &platformv1alpha1.WorkloadPolicy{
+						AllowedKinds: []platformv1alpha1.AllowedWorkloadKind{{Kind: "ConfigMap"}},
+					},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, promise)).To(Succeed())
+
+			workloadGroup := platformv1alpha1.WorkloadGroup{
+				ID: "policy-group",
+				Workloads: []platformv1alpha1.Workload{{
+					Filepath: "secret.yaml.kratix.tmpl",
+					Content:  "apiVersion: v1\nkind: Secret\nmetadata:\n  name: {{ .Destination.Name }}\n",
+				}},
+			}
+			work := &platformv1alpha1.Work{
+				ObjectMeta: v1.ObjectMeta{Name: "policy-work", Namespace: "default"},
+				Spec: platformv1alpha1.WorkSpec{
+					WorkloadCoreFields: platformv1alpha1.WorkloadCoreFields{
+						PromiseName:    "policy-promise",
+						ResourceName:   "policy-resource",
+						WorkloadGroups: []platformv1alpha1.WorkloadGroup{workloadGroup},
+					},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, work)).To(Succeed())
+
+			workPlacement := &platformv1alpha1.WorkPlacement{
+				ObjectMeta: v1.ObjectMeta{
+					Name:       "policy-work.policy-destination",
+					Namespace:  "default",
+					Finalizers: []string{"finalizers.workplacement.kratix.io/repo-cleanup"},
+				},
+				Spec: platformv1alpha1.WorkPlacementSpec{
+					TargetDestinationName: "policy-destination",
+					PromiseName:           "policy-promise",
+					ResourceName:          "policy-resource",
+					WorkName:              "policy-work",
+					ID:                    "policy-group",
+					WorkloadsHash:         workloadGroup.WorkloadsHash(),
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, workPlacement)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(workPlacement)})
+			Expect(err).To(MatchError(ContainSubstring("invalid workloads in template secret.yaml")))
+
+			Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(workPlacement), workPlacement)).To(Succeed())
+			condition := meta.FindStatusCondition(workPlacement.Status.Conditions, "WorkloadsWritten")
+			Expect(condition.Reason).To(Equal("StateStoreWriteFailed"))
+			Expect(condition.Message).To(ContainSubstring("kind /v1, Kind=Secret is not allowed by the Promise"))
+		})
+	})
+
+	When("the Destination is relabelled", func() {
+		It("rewrites its WorkPlacements, re-rendering their templates with the new labels", func() {
+			destination := &platformv1alpha1.Destination{
+				ObjectMeta: v1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"region": "eu-west"}, Generation: 1},
+			}
+			relabelled := destination.DeepCopy()
+			relabelled.Labels["region"] = "us-east"
+			Expect(controllers.DestinationChangedPredicate.Update(event.UpdateEvent{ObjectOld: destination, ObjectNew: relabelled})).To(BeTrue())
+
+			annotated := destination.DeepCopy()
+			annotated.Annotations = map[string]string{"example.com/ingress-domain": "worker-1.example.com"}
+			Expect(controllers.DestinationChangedPredicate.Update(event.UpdateEvent{ObjectOld: destination, ObjectNew: annotated})).To(BeTrue())
+
+			drained := destination.DeepCopy()
+			drained.Status.Drain = &platformv1alpha1.DrainStatus{Phase: platformv1alpha1.DrainPhaseDrained}
+			Expect(controllers.DestinationChangedPredicate.Update(event.UpdateEvent{ObjectOld: destination, ObjectNew: drained})).To(BeFalse())
+
+			workloads := []platformv1alpha1.Workload{{Filepath: "region.yaml.kratix.tmpl", Content: "region: {{ .Destination.Labels.region }}\n"}}
+			rendered, err := workloadcontent.Render(workloads, *destination, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered[0].Content).To(Equal("region: eu-west\n"))
+			rendered, err = workloadcontent.Render(workloads, *relabelled, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rendered[0].Content).To(Equal("region: us-east\n"))
+		})
+	})
+
+	When("the Destination of a deleted WorkPlacement no longer exists", func() {
+		It("removes the WorkPlacement without cleaning up the State Store", func() {
+
+			workPlacement := &platformv1alpha1.WorkPlacement{
+				ObjectMeta: v1.ObjectMeta{
+					Name:       "orphaned",
+					Namespace:  "default",
+					Finalizers: []string{"finalizers.workplacement.kratix.io/repo-cleanup"},
+				},
+				Spec: platformv1alpha1.WorkPlacementSpec{TargetDestinationName: "deleted-destination"},
+			}
+			Expect(fakeK8sClient.Create(ctx, workPlacement)).To(Succeed())
+			Expect(fakeK8sClient.Delete(ctx, workPlacement)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(workPlacement)})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(workPlacement), workPlacement)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
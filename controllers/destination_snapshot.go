@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"sort"
+	"sync"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// DestinationSnapshot is an in-memory copy of the Destinations, indexed by
+// label, so that selecting the Destinations matching a label selector only
+// visits the Destinations that carry the selected labels.
+type DestinationSnapshot struct {
+	mu           sync.RWMutex
+	destinations map[string]platformv1alpha1.Destination
+	// byLabel maps label key to label value to Destination names
+	byLabel   map[string]map[string]map[string]bool
+	hasSynced func() bool
+}
+
+func NewDestinationSnapshot() *DestinationSnapshot {
+	return &DestinationSnapshot{
+		destinations: map[string]platformv1alpha1.Destination{},
+		byLabel:      map[string]map[string]map[string]bool{},
+	}
+}
+
+// Watch keeps the snapshot up to date with the Destinations in the informer
+func (s *DestinationSnapshot) Watch(informer cache.Informer) error {
+	registration, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if destination, ok := obj.(*platformv1alpha1.Destination); ok {
+				s.Set(*destination)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if destination, ok := obj.(*platformv1alpha1.Destination); ok {
+				s.Set(*destination)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if destination, ok := obj.(*platformv1alpha1.Destination); ok {
+				s.Remove(destination.GetName())
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hasSynced = registration.HasSynced
+	return nil
+}
+
+// Synced returns whether the snapshot holds every Destination known to the
+// informer it watches. A snapshot that does not watch an informer is always
+// synced.
+func (s *DestinationSnapshot) Synced() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hasSynced == nil || s.hasSynced()
+}
+
+// Set adds the Destination to the snapshot, replacing any previous version
+func (s *DestinationSnapshot) Set(destination platformv1alpha1.Destination) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(destination.GetName())
+	s.destinations[destination.GetName()] = *destination.DeepCopy()
+	for key, value := range destination.GetLabels() {
+		if s.byLabel[key] == nil {
+			s.byLabel[key] = map[string]map[string]bool{}
+		}
+		if s.byLabel[key][value] == nil {
+			s.byLabel[key][value] = map[string]bool{}
+		}
+		s.byLabel[key][value][destination.GetName()] = true
+	}
+}
+
+// Remove removes the Destination from the snapshot
+func (s *DestinationSnapshot) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(name)
+}
+
+func (s *DestinationSnapshot) remove(name string) {
+	destination, ok := s.destinations[name]
+	if !ok {
+		return
+	}
+
+	for key, value := range destination.GetLabels() {
+		delete(s.byLabel[key][value], name)
+		if len(s.byLabel[key][value]) == 0 {
+			delete(s.byLabel[key], value)
+		}
+		if len(s.byLabel[key]) == 0 {
+			delete(s.byLabel, key)
+		}
+	}
+	delete(s.destinations, name)
+}
+
+// Get returns the Destination with the given name
+func (s *DestinationSnapshot) Get(name string) (platformv1alpha1.Destination, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	destination, ok := s.destinations[name]
+	return destination, ok
+}
+
+// Select returns the Destinations matching the label selector, sorted by
+// name. An empty selector matches every Destination.
+func (s *DestinationSnapshot) Select(destinationSelectors metav1.LabelSelector) ([]platformv1alpha1.Destination, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&destinationSelectors)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates map[string]bool
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		names := s.namesWithLabel(requirement)
+		if names == nil {
+			continue
+		}
+		candidates = intersect(candidates, names)
+	}
+
+	if candidates == nil {
+		candidates = map[string]bool{}
+		for name := range s.destinations {
+			candidates[name] = true
+		}
+	}
+
+	destinations := []platformv1alpha1.Destination{}
+	for name := range candidates {
+		destination := s.destinations[name]
+		if selector.Matches(labels.Set(destination.GetLabels())) {
+			destinations = append(destinations, destination)
+		}
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].GetName() < destinations[j].GetName()
+	})
+	return destinations, nil
+}
+
+// namesWithLabel returns the names of the Destinations that can satisfy the
+// requirement, or nil when the requirement does not narrow down the
+// Destinations through the label index. The result may be the index itself
+// and must not be modified.
+func (s *DestinationSnapshot) namesWithLabel(requirement labels.Requirement) map[string]bool {
+	values := s.byLabel[requirement.Key()]
+	names := map[string]bool{}
+
+	switch requirement.Operator() {
+	case selection.Equals, selection.DoubleEquals, selection.In:
+		if requirement.Values().Len() == 1 {
+			value, _ := requirement.Values().PopAny()
+			if values[value] == nil {
+				return names
+			}
+			return values[value]
+		}
+		for value := range requirement.Values() {
+			for name := range values[value] {
+				names[name] = true
+			}
+		}
+	case selection.Exists:
+		for _, valueNames := range values {
+			for name := range valueNames {
+				names[name] = true
+			}
+		}
+	default:
+		return nil
+	}
+	return names
+}
+
+func intersect(a, b map[string]bool) map[string]bool {
+	if a == nil {
+		return b
+	}
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+
+	result := map[string]bool{}
+	for name := range a {
+		if b[name] {
+			result[name] = true
+		}
+	}
+	return result
+}
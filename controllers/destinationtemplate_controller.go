@@ -0,0 +1,296 @@
+/*
+Copyright 2021 Syntasso.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/syntasso/kratix/api/v1alpha1"
+)
+
+const (
+	destinationTemplateLabel = kratixPrefix + "destination-template"
+	clusterNameLabel         = kratixPrefix + "cluster-name"
+	clusterNamespaceLabel    = kratixPrefix + "cluster-namespace"
+	// templateFieldsAnnotation lists the operational switches of the
+	// Destination set by its template
+	templateFieldsAnnotation   = kratixPrefix + "template-fields"
+	unschedulableField         = "unschedulable"
+	drainField                 = "drain"
+	clusterDestinationLogField = "destination"
+)
+
+// DestinationTemplateReconciler registers a Destination for each Cluster API
+// Cluster selected by a DestinationTemplate
+type DestinationTemplateReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=destinationtemplates,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=destinationtemplates/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+func (r *DestinationTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	template := &v1alpha1.DestinationTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, template); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Error(err, "Failed getting DestinationTemplate", "namespacedName", req.NamespacedName)
+		return defaultRequeue, nil
+	}
+
+	// the Destinations are owned by the template, so are garbage collected
+	if !template.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	logger := r.Log.WithValues("destinationTemplate", template.GetName())
+	logger.Info("Reconciling DestinationTemplate")
+
+	clusters, err := r.selectedClusters(ctx, template)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	registered := []string{}
+	selected := map[string]bool{}
+	for i := range clusters {
+		cluster := &clusters[i]
+		if !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		name, err := r.registerCluster(ctx, logger, template, cluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if name != "" {
+			selected[name] = true
+			registered = append(registered, name)
+		}
+	}
+
+	destinations := &v1alpha1.DestinationList{}
+	if err := r.List(ctx, destinations, client.MatchingLabels{destinationTemplateLabel: template.GetName()}); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range destinations.Items {
+		destination := &destinations.Items[i]
+		if selected[destination.GetName()] || !destination.DeletionTimestamp.IsZero() {
+			continue
+		}
+		logger.Info("Cluster no longer selected, deleting Destination", clusterDestinationLogField, destination.GetName())
+		if err := r.Delete(ctx, destination); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	sort.Strings(registered)
+	if !slices.Equal(template.Status.Destinations, registered) {
+		template.Status.Destinations = registered
+		if err := r.Status().Update(ctx, template); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *DestinationTemplateReconciler) selectedClusters(ctx context.Context, template *v1alpha1.DestinationTemplate) ([]clusterv1.Cluster, error) {
+	selector := labels.Everything()
+	if template.Spec.ClusterSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(template.Spec.ClusterSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clusterSelector: %w", err)
+		}
+	}
+
+	clusters := &clusterv1.ClusterList{}
+	listOpts := []client.ListOption{client.MatchingLabelsSelector{Selector: selector}}
+	if template.Spec.ClusterNamespace != "" {
+		listOpts = append(listOpts, client.InNamespace(template.Spec.ClusterNamespace))
+	}
+	if err := r.List(ctx, clusters, listOpts...); err != nil {
+		return nil, err
+	}
+	return clusters.Items, nil
+}
+
+// registerCluster creates or updates the Destination of the Cluster, named
+// after its namespace and name, and returns its name. A Destination of the
+// same name not registered for this Cluster is left alone, and an empty name
+// returned.
+func (r *DestinationTemplateReconciler) registerCluster(ctx context.Context, logger logr.Logger, template *v1alpha1.DestinationTemplate, cluster *clusterv1.Cluster) (string, error) {
+	name := destinationNameForCluster(cluster)
+	destination := &v1alpha1.Destination{}
+	err := r.Get(ctx, client.ObjectKey{Name: name}, destination)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+	if err == nil && !registeredFor(destination, template, cluster) {
+		logger.Info("Destination already exists and was not registered for the Cluster, skipping",
+			clusterDestinationLogField, destination.GetName(), "cluster", client.ObjectKeyFromObject(cluster))
+		return "", nil
+	}
+
+	destination = &v1alpha1.Destination{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, destination, func() error {
+		destination.SetLabels(destinationLabels(template, cluster))
+
+		annotations := destination.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		for key, value := range template.Spec.Template.Metadata.Annotations {
+			annotations[key] = value
+		}
+
+		// cordoning and draining are operational switches: those set on the
+		// Destination are kept, while those set by the template follow it
+		owned := templateFields(annotations)
+		spec := *template.Spec.Template.Spec.DeepCopy()
+		spec.Unschedulable, owned[unschedulableField] = templateSwitch(spec.Unschedulable, destination.Spec.Unschedulable, owned[unschedulableField])
+		spec.Drain, owned[drainField] = templateSwitch(spec.Drain, destination.Spec.Drain, owned[drainField])
+		destination.Spec = spec
+		setTemplateFields(annotations, owned)
+		destination.SetAnnotations(annotations)
+
+		return controllerutil.SetControllerReference(template, destination, r.Scheme)
+	})
+	if err != nil {
+		return "", err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("Registered Destination for Cluster", clusterDestinationLogField, destination.GetName(),
+			"cluster", client.ObjectKeyFromObject(cluster), "operation", op)
+	}
+	return destination.GetName(), nil
+}
+
+// destinationNameForCluster returns the name of the Destination of the
+// Cluster. Clusters are namespaced and Destinations are not, so the name
+// includes the namespace of the Cluster.
+func destinationNameForCluster(cluster *clusterv1.Cluster) string {
+	return cluster.GetNamespace() + "-" + cluster.GetName()
+}
+
+// templateSwitch returns the value of an operational switch of the
+// Destination, and whether it is set by the template
+func templateSwitch(templateValue, destinationValue, setByTemplate bool) (bool, bool) {
+	if templateValue {
+		return true, true
+	}
+	if setByTemplate {
+		return false, false
+	}
+	return destinationValue, false
+}
+
+func templateFields(annotations map[string]string) map[string]bool {
+	fields := map[string]bool{}
+	if value := annotations[templateFieldsAnnotation]; value != "" {
+		for _, field := range strings.Split(value, ",") {
+			fields[field] = true
+		}
+	}
+	return fields
+}
+
+func setTemplateFields(annotations map[string]string, fields map[string]bool) {
+	set := []string{}
+	for field, owned := range fields {
+		if owned {
+			set = append(set, field)
+		}
+	}
+	if len(set) == 0 {
+		delete(annotations, templateFieldsAnnotation)
+		return
+	}
+	sort.Strings(set)
+	annotations[templateFieldsAnnotation] = strings.Join(set, ",")
+}
+
+func registeredFor(destination *v1alpha1.Destination, template *v1alpha1.DestinationTemplate, cluster *clusterv1.Cluster) bool {
+	destinationLabels := destination.GetLabels()
+	return destinationLabels[destinationTemplateLabel] == template.GetName() &&
+		destinationLabels[clusterNameLabel] == cluster.GetName() &&
+		destinationLabels[clusterNamespaceLabel] == cluster.GetNamespace()
+}
+
+func destinationLabels(template *v1alpha1.DestinationTemplate, cluster *clusterv1.Cluster) map[string]string {
+	destinationLabels := map[string]string{}
+	for key, value := range template.Spec.Template.Metadata.Labels {
+		destinationLabels[key] = value
+	}
+	for key, value := range cluster.GetLabels() {
+		destinationLabels[key] = value
+	}
+	destinationLabels[destinationTemplateLabel] = template.GetName()
+	destinationLabels[clusterNameLabel] = cluster.GetName()
+	destinationLabels[clusterNamespaceLabel] = cluster.GetNamespace()
+	return destinationLabels
+}
+
+// SetupWithManager sets up the controller with the Manager. The Cluster API
+// CRDs must be installed on the platform.
+func (r *DestinationTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.DestinationTemplate{}).
+		Owns(&v1alpha1.Destination{}).
+		Watches(&clusterv1.Cluster{}, handler.EnqueueRequestsFromMapFunc(r.templatesForCluster)).
+		Complete(r)
+}
+
+// templatesForCluster enqueues every DestinationTemplate, as a Cluster may
+// have stopped matching the selector of a template since it last changed
+func (r *DestinationTemplateReconciler) templatesForCluster(ctx context.Context, _ client.Object) []reconcile.Request {
+	templates := &v1alpha1.DestinationTemplateList{}
+	if err := r.List(ctx, templates); err != nil {
+		r.Log.Error(err, "Failed listing DestinationTemplates")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, template := range templates.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&template)})
+	}
+	return requests
+}
@@ -0,0 +1,187 @@
+package controllers_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/controllers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("DestinationTemplateReconciler", func() {
+	var (
+		ctx        context.Context
+		reconciler *controllers.DestinationTemplateReconciler
+		template   *v1alpha1.DestinationTemplate
+	)
+
+	newCluster := func(namespace, name string, clusterLabels map[string]string) *clusterv1.Cluster {
+		cluster := &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+				Labels:    clusterLabels,
+			},
+		}
+		Expect(fakeK8sClient.Create(ctx, cluster)).To(Succeed())
+		return cluster
+	}
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: template.GetName()}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	getDestination := func(name string) (*v1alpha1.Destination, error) {
+		destination := &v1alpha1.Destination{}
+		err := fakeK8sClient.Get(ctx, client.ObjectKey{Name: name}, destination)
+		return destination, err
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		reconciler = &controllers.DestinationTemplateReconciler{
+			Client: fakeK8sClient,
+			Scheme: scheme.Scheme,
+			Log:    ctrl.Log.WithName("controllers").WithName("DestinationTemplate"),
+		}
+
+		template = &v1alpha1.DestinationTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "capi",
+			},
+			Spec: v1alpha1.DestinationTemplateSpec{
+				ClusterSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"kratix": "true"},
+				},
+				Template: v1alpha1.DestinationTemplateResource{
+					Metadata: v1alpha1.DestinationTemplateMetadata{
+						Labels:      map[string]string{"environment": "dev", "region": "default"},
+						Annotations: map[string]string{"team": "platform"},
+					},
+					Spec: v1alpha1.DestinationSpec{
+						StateStoreRef: &v1alpha1.StateStoreReference{
+							Kind: "BucketStateStore",
+							Name: "default",
+						},
+						StrictMatchLabels: true,
+					},
+				},
+			},
+		}
+		Expect(fakeK8sClient.Create(ctx, template)).To(Succeed())
+
+		newCluster("clusters", "worker-1", map[string]string{"kratix": "true", "region": "eu"})
+		newCluster("clusters", "other", map[string]string{"kratix": "false"})
+		reconcile()
+	})
+
+	It("registers a Destination for each selected Cluster", func() {
+		destination, err := getDestination("clusters-worker-1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(destination.GetLabels()).To(Equal(map[string]string{
+			"environment":                    "dev",
+			"region":                         "eu",
+			"kratix":                         "true",
+			"kratix.io/destination-template": "capi",
+			"kratix.io/cluster-name":         "worker-1",
+			"kratix.io/cluster-namespace":    "clusters",
+		}))
+		Expect(destination.GetAnnotations()).To(HaveKeyWithValue("team", "platform"))
+		Expect(destination.Spec.StateStoreRef).To(Equal(&v1alpha1.StateStoreReference{Kind: "BucketStateStore", Name: "default"}))
+		Expect(destination.Spec.StrictMatchLabels).To(BeTrue())
+		Expect(destination.GetOwnerReferences()).To(ConsistOf(HaveField("Name", "capi")))
+
+		_, err = getDestination("clusters-other")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(template), template)).To(Succeed())
+		Expect(template.Status.Destinations).To(Equal([]string{"clusters-worker-1"}))
+	})
+
+	It("updates the Destination from the Cluster, keeping it cordoned", func() {
+		destination, err := getDestination("clusters-worker-1")
+		Expect(err).NotTo(HaveOccurred())
+		destination.Spec.Unschedulable = true
+		Expect(fakeK8sClient.Update(ctx, destination)).To(Succeed())
+
+		cluster := &clusterv1.Cluster{}
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: "worker-1"}, cluster)).To(Succeed())
+		cluster.SetLabels(map[string]string{"kratix": "true"})
+		Expect(fakeK8sClient.Update(ctx, cluster)).To(Succeed())
+		reconcile()
+
+		destination, err = getDestination("clusters-worker-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination.GetLabels()).To(HaveKeyWithValue("region", "default"))
+		Expect(destination.Spec.Unschedulable).To(BeTrue())
+	})
+
+	It("uncordons the Destination once the template no longer cordons it", func() {
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(template), template)).To(Succeed())
+		template.Spec.Template.Spec.Unschedulable = true
+		Expect(fakeK8sClient.Update(ctx, template)).To(Succeed())
+		reconcile()
+
+		destination, err := getDestination("clusters-worker-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination.Spec.Unschedulable).To(BeTrue())
+
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(template), template)).To(Succeed())
+		template.Spec.Template.Spec.Unschedulable = false
+		Expect(fakeK8sClient.Update(ctx, template)).To(Succeed())
+		reconcile()
+
+		destination, err = getDestination("clusters-worker-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination.Spec.Unschedulable).To(BeFalse())
+		Expect(destination.GetAnnotations()).NotTo(HaveKey("kratix.io/template-fields"))
+	})
+
+	It("registers a Destination for each of the Clusters of the same name in different namespaces", func() {
+		newCluster("other-clusters", "worker-1", map[string]string{"kratix": "true"})
+		reconcile()
+
+		destination, err := getDestination("other-clusters-worker-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination.GetLabels()).To(HaveKeyWithValue("kratix.io/cluster-namespace", "other-clusters"))
+
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(template), template)).To(Succeed())
+		Expect(template.Status.Destinations).To(Equal([]string{"clusters-worker-1", "other-clusters-worker-1"}))
+	})
+
+	It("deletes the Destination once the Cluster is no longer selected", func() {
+		cluster := &clusterv1.Cluster{}
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKey{Namespace: "clusters", Name: "worker-1"}, cluster)).To(Succeed())
+		Expect(fakeK8sClient.Delete(ctx, cluster)).To(Succeed())
+		reconcile()
+
+		_, err := getDestination("clusters-worker-1")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(template), template)).To(Succeed())
+		Expect(template.Status.Destinations).To(BeEmpty())
+	})
+
+	It("leaves alone Destinations it did not register", func() {
+		Expect(fakeK8sClient.Create(ctx, &v1alpha1.Destination{
+			ObjectMeta: metav1.ObjectMeta{Name: "clusters-worker-2"},
+		})).To(Succeed())
+		newCluster("clusters", "worker-2", map[string]string{"kratix": "true"})
+		reconcile()
+
+		destination, err := getDestination("clusters-worker-2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination.GetLabels()).To(BeEmpty())
+		Expect(destination.Spec.StateStoreRef).To(BeNil())
+	})
+})
@@ -58,11 +58,14 @@ type DynamicResourceRequestController struct {
 	CRD                         *apiextensionsv1.CustomResourceDefinition
 	PromiseDestinationSelectors []v1alpha1.PromiseScheduling
 	PromiseWorkflowSelectors    *v1alpha1.WorkloadGroupScheduling
+	PromiseConfig               map[string]string
+	PromiseConfigSecretRefs     []v1alpha1.PromiseConfigSecretRef
 	CanCreateResources          *bool
 }
 
 //+kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=create
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 func (r *DynamicResourceRequestController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	if !*r.Enabled {
@@ -122,14 +125,26 @@ func (r *DynamicResourceRequestController) Reconcile(ctx context.Context, req ct
 		return addFinalizers(opts, rr, []string{workFinalizer, removeAllWorkflowJobsFinalizer, runDeleteWorkflowsFinalizer})
 	}
 
+	promise := &v1alpha1.Promise{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: r.PromiseIdentifier}, promise); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	promiseConfig, err := r.promiseConfig(opts)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	pipelineResources, err := pipeline.NewConfigureResource(
 		rr,
-		r.CRD.Spec.Names.Plural,
+		r.CRD,
 		r.ConfigurePipelines,
 		resourceRequestIdentifier,
 		r.PromiseIdentifier,
 		r.PromiseDestinationSelectors,
 		r.PromiseWorkflowSelectors,
+		promise,
+		promiseConfig,
 		opts.logger,
 	)
 	if err != nil {
@@ -154,6 +169,29 @@ func (r *DynamicResourceRequestController) Reconcile(ctx context.Context, req ct
 	return ctrl.Result{}, nil
 }
 
+// promiseConfig returns the Promise config for the reader of the pipeline,
+// which reads the referenced Secrets itself. The Secrets are checked to exist
+// so a missing one is reported on the resource rather than in the pipeline.
+func (r *DynamicResourceRequestController) promiseConfig(o opts) (*pipeline.PromiseConfigSource, error) {
+	promiseConfig := &pipeline.PromiseConfigSource{
+		Config: r.PromiseConfig,
+	}
+
+	for _, ref := range r.PromiseConfigSecretRefs {
+		ref.SecretRef.Namespace = or(ref.SecretRef.Namespace, v1alpha1.KratixSystemNamespace)
+		key := types.NamespacedName{
+			Name:      ref.SecretRef.Name,
+			Namespace: ref.SecretRef.Namespace,
+		}
+		if err := r.Client.Get(o.ctx, key, &corev1.Secret{}); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s for promise config %s: %w", key, ref.Name, err)
+		}
+		promiseConfig.SecretRefs = append(promiseConfig.SecretRefs, ref)
+	}
+
+	return promiseConfig, nil
+}
+
 func isManualReconciliation(labels map[string]string) bool {
 	if labels == nil {
 		return false
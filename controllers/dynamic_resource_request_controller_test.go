@@ -11,6 +11,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -154,6 +155,11 @@ var _ = Describe("DynamicResourceRequestController", func() {
 						APIGroups: []string{"platform.kratix.io"},
 						Resources: []string{"works"},
 					},
+					rbacv1.PolicyRule{
+						Verbs:     []string{"get", "list", "create", "update", "delete"},
+						APIGroups: []string{""},
+						Resources: []string{"configmaps"},
+					},
 				))
 				Expect(role.GetLabels()).To(Equal(resourceLabels))
 			})
@@ -182,7 +188,9 @@ var _ = Describe("DynamicResourceRequestController", func() {
 				Expect(configMap.Data).To(HaveKey("destinationSelectors"))
 				space := regexp.MustCompile(`\s+`)
 				destinationSelectors := space.ReplaceAllString(configMap.Data["destinationSelectors"], " ")
-				Expect(strings.TrimSpace(destinationSelectors)).To(Equal(`- matchlabels: environment: dev source: promise`))
+				Expect(strings.TrimSpace(destinationSelectors)).To(Equal(`- matchLabels: environment: dev source: promise`))
+				Expect(configMap.Data).To(HaveKey("statusContract"))
+				Expect(configMap.Data["promise"]).To(ContainSubstring("name: " + promise.GetName()))
 			})
 
 			By("requeuing forever until jobs finishes", func() {
@@ -193,6 +201,21 @@ var _ = Describe("DynamicResourceRequestController", func() {
 				Expect(jobs.Items[0].Spec.Template.Spec.InitContainers[1].Image).To(Equal("configure:v0.1.0"))
 			})
 
+			By("recording the pipeline run", func() {
+				jobs := &batchv1.JobList{}
+				Expect(fakeK8sClient.List(ctx, jobs)).To(Succeed())
+
+				run := &v1alpha1.PipelineRun{}
+				Expect(fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(&jobs.Items[0]), run)).To(Succeed())
+				Expect(run.Spec.Trigger).To(Equal(v1alpha1.PipelineRunTriggerCreate))
+				Expect(run.Spec.PromiseName).To(Equal(promise.GetName()))
+				Expect(run.Spec.ResourceName).To(Equal(resReq.GetName()))
+				Expect(run.Spec.JobName).To(Equal(jobs.Items[0].GetName()))
+				Expect(run.Spec.ResourceHash).To(Equal(jobs.Items[0].GetLabels()["kratix-resource-hash"]))
+				Expect(run.GetOwnerReferences()).To(HaveLen(1))
+				Expect(run.GetOwnerReferences()[0].Name).To(Equal(resReq.GetName()))
+			})
+
 			By("finishing the creation once the job is finished", func() {
 				result, err := t.reconcileUntilCompletion(reconciler, promise, &opts{
 					funcs: []func(client.Object) error{
@@ -213,6 +236,54 @@ var _ = Describe("DynamicResourceRequestController", func() {
 			})
 		})
 
+		When("the Promise has config", func() {
+			BeforeEach(func() {
+				Expect(fakeK8sClient.Create(ctx, &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: v1alpha1.KratixSystemNamespace},
+					Data:       map[string][]byte{"password": []byte("secret")},
+				})).To(Succeed())
+				reconciler.PromiseConfig = map[string]string{"region": "eu-west-2"}
+				reconciler.PromiseConfigSecretRefs = []v1alpha1.PromiseConfigSecretRef{
+					{Name: "registry", SecretRef: v1.SecretReference{Name: "registry-credentials"}},
+				}
+			})
+
+			It("renders it, referencing the Secrets, for the pipeline", func() {
+				_, err := t.reconcileUntilCompletion(reconciler, resReq)
+				Expect(err).To(MatchError("reconcile loop detected"))
+
+				configMap := &v1.ConfigMap{}
+				Expect(fakeK8sClient.Get(ctx, types.NamespacedName{Name: "destination-selectors-" + promise.GetName(), Namespace: "default"}, configMap)).To(Succeed())
+				Expect(configMap.Data["promiseConfig"]).To(MatchYAML(`
+config:
+  region: eu-west-2
+secretRefs:
+- name: registry
+  secretRef:
+    name: registry-credentials
+    namespace: kratix-platform-system
+`))
+
+				By("not copying the Secret into the namespace of the resource", func() {
+					secrets := &v1.SecretList{}
+					Expect(fakeK8sClient.List(ctx, secrets, client.InNamespace("default"))).To(Succeed())
+					Expect(secrets.Items).To(BeEmpty())
+				})
+
+				By("allowing the pipeline to read the Secret", func() {
+					binding := &rbacv1.RoleBinding{}
+					Expect(fakeK8sClient.Get(ctx, types.NamespacedName{Name: "promise-config-" + promise.GetName() + "-default", Namespace: v1alpha1.KratixSystemNamespace}, binding)).To(Succeed())
+					Expect(binding.RoleRef.Name).To(Equal("promise-config-" + promise.GetName()))
+				})
+			})
+
+			It("errors when a referenced Secret does not exist", func() {
+				reconciler.PromiseConfigSecretRefs[0].SecretRef.Name = "missing"
+				_, err := t.reconcileUntilCompletion(reconciler, resReq)
+				Expect(err).To(MatchError(ContainSubstring("failed to get secret kratix-platform-system/missing for promise config registry")))
+			})
+		})
+
 		When("CanCreateResources is set to false", func() {
 			BeforeEach(func() {
 				canCreate := false
@@ -276,6 +347,16 @@ var _ = Describe("DynamicResourceRequestController", func() {
 				Expect(jobs.Items[1].Spec.Template.Spec.InitContainers[1].Image).To(Equal("configure:v0.1.0"))
 			})
 
+			By("recording the spec change as the trigger of the new run", func() {
+				runs := &v1alpha1.PipelineRunList{}
+				Expect(fakeK8sClient.List(ctx, runs)).To(Succeed())
+				Expect(runs.Items).To(HaveLen(2))
+				Expect([]string{runs.Items[0].Spec.Trigger, runs.Items[1].Spec.Trigger}).To(ConsistOf(
+					v1alpha1.PipelineRunTriggerCreate,
+					v1alpha1.PipelineRunTriggerSpecChange,
+				))
+			})
+
 			result, err := t.reconcileUntilCompletion(reconciler, resReq, &opts{
 				funcs: []func(client.Object) error{
 					autoCompleteJobAndCreateWork(promiseCommonLabels, promise.GetName()+"-"+resReq.GetName()),
@@ -328,6 +409,16 @@ var _ = Describe("DynamicResourceRequestController", func() {
 				}).To(ConsistOf("configure:v0.1.0", "delete:v0.1.0"))
 			})
 
+			By("recording the delete pipeline run", func() {
+				runs := &v1alpha1.PipelineRunList{}
+				Expect(fakeK8sClient.List(ctx, runs)).To(Succeed())
+				Expect(runs.Items).To(ContainElement(SatisfyAll(
+					HaveField("Spec.WorkflowAction", "delete"),
+					HaveField("Spec.Trigger", v1alpha1.PipelineRunTriggerDelete),
+					HaveField("Spec.ResourceName", resReq.GetName()),
+				)))
+			})
+
 			result, err := t.reconcileUntilCompletion(reconciler, resReq, &opts{
 				funcs: []func(client.Object) error{
 					autoCompleteJobAndCreateWork(promiseCommonLabels, promise.GetName()+"-"+resReq.GetName()),
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// WorkDestinationSelectorKeysField indexes Works by the label keys their
+	// resolved destination selectors constrain. WorkloadGroups without
+	// selectors are indexed under AnyDestinationSelectorKey.
+	WorkDestinationSelectorKeysField = "spec.workloadGroups.destinationSelectors.keys"
+	// WorkPromiseField indexes Works by the name of their Promise
+	WorkPromiseField = "spec.promiseName"
+	// WorkPlacementTargetDestinationField indexes WorkPlacements by the name of
+	// the Destination they are scheduled to
+	WorkPlacementTargetDestinationField = "spec.targetDestinationName"
+	// WorkPlacementWorkField indexes WorkPlacements by the name of their Work
+	WorkPlacementWorkField = "metadata.labels." + workLabelKey
+	// WorkPlacementWorkloadGroupField indexes WorkPlacements by the name of
+	// their Work and the ID of their WorkloadGroup, see workloadGroupIndexValue
+	WorkPlacementWorkloadGroupField = "metadata.labels." + workloadGroupIDKey
+
+	AnyDestinationSelectorKey = "*"
+)
+
+// Index is a field index registered with the manager cache
+type Index struct {
+	Object  client.Object
+	Field   string
+	Extract client.IndexerFunc
+}
+
+// Indexes are the field indexes the controllers rely on
+var Indexes = []Index{
+	{
+		Object:  &platformv1alpha1.Work{},
+		Field:   WorkDestinationSelectorKeysField,
+		Extract: workDestinationSelectorKeys,
+	},
+	{
+		Object:  &platformv1alpha1.Work{},
+		Field:   WorkPromiseField,
+		Extract: workPromise,
+	},
+	{
+		Object:  &platformv1alpha1.WorkPlacement{},
+		Field:   WorkPlacementTargetDestinationField,
+		Extract: workPlacementTargetDestination,
+	},
+	{
+		Object:  &platformv1alpha1.WorkPlacement{},
+		Field:   WorkPlacementWorkField,
+		Extract: workPlacementWork,
+	},
+	{
+		Object:  &platformv1alpha1.WorkPlacement{},
+		Field:   WorkPlacementWorkloadGroupField,
+		Extract: workPlacementWorkloadGroup,
+	},
+}
+
+// SetupIndexes registers the Indexes with the field indexer
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, index := range Indexes {
+		if err := indexer.IndexField(ctx, index.Object, index.Field, index.Extract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func workDestinationSelectorKeys(obj client.Object) []string {
+	work := obj.(*platformv1alpha1.Work)
+	keys := map[string]bool{}
+	for _, workloadGroup := range work.Spec.WorkloadGroups {
+		for _, key := range destinationSelectorKeys(resolveDestinationSelectorsForWorkloadGroup(workloadGroup, work)) {
+			keys[key] = true
+		}
+	}
+
+	values := []string{}
+	for key := range keys {
+		values = append(values, key)
+	}
+	return values
+}
+
+func workPlacementTargetDestination(obj client.Object) []string {
+	workPlacement := obj.(*platformv1alpha1.WorkPlacement)
+	return []string{workPlacement.Spec.TargetDestinationName}
+}
+
+func workPromise(obj client.Object) []string {
+	work := obj.(*platformv1alpha1.Work)
+	return []string{work.Spec.PromiseName}
+}
+
+func workPlacementWork(obj client.Object) []string {
+	workName := obj.GetLabels()[workLabelKey]
+	if workName == "" {
+		return nil
+	}
+	return []string{workName}
+}
+
+func workPlacementWorkloadGroup(obj client.Object) []string {
+	workName, workloadGroupID := obj.GetLabels()[workLabelKey], obj.GetLabels()[workloadGroupIDKey]
+	if workName == "" || workloadGroupID == "" {
+		return nil
+	}
+	return []string{workloadGroupIndexValue(workName, workloadGroupID)}
+}
+
+func workloadGroupIndexValue(workName, workloadGroupID string) string {
+	return workName + "/" + workloadGroupID
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2021 Syntasso.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/syntasso/kratix/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	DefaultPipelineRunHistoryLimit  = 10
+	DefaultPipelineRunLogTailLines  = 50
+	pipelineRunWorkflowKindLabel    = "kratix-workflow-kind"
+	pipelineRunJobNameLabel         = "job-name"
+	pipelineRunLogsUnavailableError = "<logs unavailable>"
+)
+
+// PipelineRunReconciler keeps PipelineRuns up to date with the Jobs they
+// record, and prunes old PipelineRuns beyond the configured history limit
+type PipelineRunReconciler struct {
+	Client       client.Client
+	Log          logr.Logger
+	PodLogReader PodLogReader
+	// Number of finished PipelineRuns to keep per workflow
+	HistoryLimit int
+	// Number of log lines to keep per container
+	LogTailLines int64
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . PodLogReader
+type PodLogReader interface {
+	TailLogs(ctx context.Context, namespace, podName, containerName string, lines int64) (string, error)
+}
+
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=pipelineruns,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+
+func (r *PipelineRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Log.WithValues("pipelineRun", req.NamespacedName)
+
+	run := &v1alpha1.PipelineRun{}
+	if err := r.Client.Get(ctx, req.NamespacedName, run); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Error getting PipelineRun")
+		return defaultRequeue, nil
+	}
+
+	if run.IsFinished() {
+		return ctrl.Result{}, r.pruneHistory(ctx, logger, run)
+	}
+
+	job := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: run.Spec.JobName, Namespace: run.GetNamespace()}, job)
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Error getting Job for PipelineRun")
+		return defaultRequeue, nil
+	}
+
+	if errors.IsNotFound(err) {
+		logger.Info("Job for PipelineRun no longer exists")
+		now := metav1.Now()
+		run.Status.Result = v1alpha1.PipelineRunResultUnknown
+		run.Status.CompletionTime = &now
+		return ctrl.Result{}, r.Client.Status().Update(ctx, run)
+	}
+
+	run.Status.StartTime = job.Status.StartTime
+	run.Status.Result = jobResult(job)
+
+	if run.Status.Result == v1alpha1.PipelineRunResultSucceeded || run.Status.Result == v1alpha1.PipelineRunResultFailed {
+		run.Status.CompletionTime = jobCompletionTime(job)
+		containers, err := r.containerStatuses(ctx, logger, job)
+		if err != nil {
+			logger.Error(err, "Error getting pipeline pod")
+			return defaultRequeue, nil
+		}
+		run.Status.Containers = containers
+	}
+
+	logger.Info("Updating PipelineRun status", "result", run.Status.Result)
+	if err := r.Client.Status().Update(ctx, run); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if run.IsFinished() {
+		return ctrl.Result{}, r.pruneHistory(ctx, logger, run)
+	}
+	return ctrl.Result{}, nil
+}
+
+func jobResult(job *batchv1.Job) string {
+	if job.Status.Succeeded > 0 {
+		return v1alpha1.PipelineRunResultSucceeded
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+			return v1alpha1.PipelineRunResultFailed
+		}
+	}
+	if job.Spec.Suspend != nil && *job.Spec.Suspend {
+		return v1alpha1.PipelineRunResultSuspended
+	}
+	return v1alpha1.PipelineRunResultRunning
+}
+
+func jobCompletionTime(job *batchv1.Job) *metav1.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed {
+			return &condition.LastTransitionTime
+		}
+	}
+	now := metav1.Now()
+	return &now
+}
+
+// containerStatuses captures the exit code and tail of the logs of every
+// container in the most recent Pod of the Job, in execution order
+func (r *PipelineRunReconciler) containerStatuses(ctx context.Context, logger logr.Logger, job *batchv1.Job) ([]v1alpha1.PipelineRunContainerStatus, error) {
+	pods := &v1.PodList{}
+	err := r.Client.List(ctx, pods,
+		client.InNamespace(job.GetNamespace()),
+		client.MatchingLabels{pipelineRunJobNameLabel: job.GetName()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pods.Items) == 0 {
+		logger.Info("No pods found for Job, skipping log capture", "job", job.GetName())
+		return nil, nil
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.After(pods.Items[j].CreationTimestamp.Time)
+	})
+	pod := pods.Items[0]
+
+	var containers []v1alpha1.PipelineRunContainerStatus
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		container := v1alpha1.PipelineRunContainerStatus{Name: status.Name}
+		if status.State.Terminated != nil {
+			exitCode := status.State.Terminated.ExitCode
+			container.ExitCode = &exitCode
+		}
+
+		logs, err := r.PodLogReader.TailLogs(ctx, pod.GetNamespace(), pod.GetName(), status.Name, r.logTailLines())
+		if err != nil {
+			logger.Info("Failed to read container logs", "pod", pod.GetName(), "container", status.Name, "error", err)
+			logs = pipelineRunLogsUnavailableError
+		}
+		container.Logs = logs
+
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+// pruneHistory deletes the oldest finished PipelineRuns of the same workflow
+// for the same object until at most HistoryLimit remain
+func (r *PipelineRunReconciler) pruneHistory(ctx context.Context, logger logr.Logger, run *v1alpha1.PipelineRun) error {
+	runs := &v1alpha1.PipelineRunList{}
+	err := r.Client.List(ctx, runs,
+		client.InNamespace(run.GetNamespace()),
+		client.MatchingLabels(run.GetLabels()),
+	)
+	if err != nil {
+		return err
+	}
+
+	var finished []v1alpha1.PipelineRun
+	for _, item := range runs.Items {
+		if item.IsFinished() {
+			finished = append(finished, item)
+		}
+	}
+
+	if len(finished) <= r.historyLimit() {
+		return nil
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].Status.CompletionTime.Before(finished[j].Status.CompletionTime)
+	})
+
+	for _, old := range finished[:len(finished)-r.historyLimit()] {
+		logger.Info("Pruning PipelineRun beyond history limit", "pipelineRun", old.GetName())
+		if err := r.Client.Delete(ctx, &old); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PipelineRunReconciler) historyLimit() int {
+	if r.HistoryLimit <= 0 {
+		return DefaultPipelineRunHistoryLimit
+	}
+	return r.HistoryLimit
+}
+
+func (r *PipelineRunReconciler) logTailLines() int64 {
+	if r.LogTailLines <= 0 {
+		return DefaultPipelineRunLogTailLines
+	}
+	return r.LogTailLines
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.PipelineRun{}).
+		Watches(
+			&batchv1.Job{},
+			handler.EnqueueRequestsFromMapFunc(r.pipelineRunForJob),
+		).
+		Complete(r)
+}
+
+// PipelineRuns share the name and namespace of the Job they record
+func (r *PipelineRunReconciler) pipelineRunForJob(ctx context.Context, obj client.Object) []reconcile.Request {
+	if _, ok := obj.GetLabels()[pipelineRunWorkflowKindLabel]; !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}},
+	}
+}
+
+type clientsetPodLogReader struct {
+	clientset kubernetes.Interface
+}
+
+func NewPodLogReader(clientset kubernetes.Interface) PodLogReader {
+	return &clientsetPodLogReader{clientset: clientset}
+}
+
+func (c *clientsetPodLogReader) TailLogs(ctx context.Context, namespace, podName, containerName string, lines int64) (string, error) {
+	req := c.clientset.CoreV1().Pods(namespace).GetLogs(podName, &v1.PodLogOptions{
+		Container: containerName,
+		TailLines: &lines,
+	})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
@@ -0,0 +1,241 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/controllers"
+	"github.com/syntasso/kratix/controllers/controllersfakes"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("PipelineRunReconciler", func() {
+	var (
+		ctx           context.Context
+		reconciler    *controllers.PipelineRunReconciler
+		fakeLogReader *controllersfakes.FakePodLogReader
+		run           *v1alpha1.PipelineRun
+		job           *batchv1.Job
+		runName       types.NamespacedName
+		runLabels     map[string]string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeLogReader = &controllersfakes.FakePodLogReader{}
+		fakeLogReader.TailLogsStub = func(_ context.Context, _, _, container string, _ int64) (string, error) {
+			return "logs from " + container, nil
+		}
+		reconciler = &controllers.PipelineRunReconciler{
+			Client:       fakeK8sClient,
+			Log:          ctrl.Log.WithName("controllers").WithName("PipelineRun"),
+			PodLogReader: fakeLogReader,
+			HistoryLimit: 2,
+			LogTailLines: 10,
+		}
+
+		runLabels = map[string]string{
+			"kratix-promise-id":    "redis",
+			"kratix-workflow-kind": "pipeline.platform.kratix.io",
+			"kratix-workflow-type": "resource",
+		}
+		runName = types.NamespacedName{Name: "configure-pipeline-redis-abcde", Namespace: "default"}
+
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      runName.Name,
+				Namespace: runName.Namespace,
+				Labels:    runLabels,
+			},
+		}
+		Expect(fakeK8sClient.Create(ctx, job)).To(Succeed())
+
+		run = &v1alpha1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      runName.Name,
+				Namespace: runName.Namespace,
+				Labels:    runLabels,
+			},
+			Spec: v1alpha1.PipelineRunSpec{
+				PromiseName: "redis",
+				JobName:     runName.Name,
+				Trigger:     v1alpha1.PipelineRunTriggerCreate,
+			},
+		}
+		Expect(fakeK8sClient.Create(ctx, run)).To(Succeed())
+	})
+
+	reconcile := func() {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: runName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(fakeK8sClient.Get(ctx, runName, run)).To(Succeed())
+	}
+
+	When("the Job is still running", func() {
+		BeforeEach(func() {
+			startTime := metav1.Now()
+			job.Status.StartTime = &startTime
+			job.Status.Active = 1
+			Expect(fakeK8sClient.Status().Update(ctx, job)).To(Succeed())
+		})
+
+		It("records the run as running without capturing logs", func() {
+			reconcile()
+			Expect(run.Status.Result).To(Equal(v1alpha1.PipelineRunResultRunning))
+			Expect(run.Status.StartTime).NotTo(BeNil())
+			Expect(run.Status.CompletionTime).To(BeNil())
+			Expect(run.Status.Containers).To(BeEmpty())
+			Expect(fakeLogReader.TailLogsCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the Job is suspended", func() {
+		BeforeEach(func() {
+			suspend := true
+			job.Spec.Suspend = &suspend
+			Expect(fakeK8sClient.Update(ctx, job)).To(Succeed())
+		})
+
+		It("records the run as suspended", func() {
+			reconcile()
+			Expect(run.Status.Result).To(Equal(v1alpha1.PipelineRunResultSuspended))
+			Expect(run.IsFinished()).To(BeFalse())
+		})
+	})
+
+	When("the Job has completed", func() {
+		BeforeEach(func() {
+			completionTime := metav1.Now()
+			job.Status.Succeeded = 1
+			job.Status.CompletionTime = &completionTime
+			Expect(fakeK8sClient.Status().Update(ctx, job)).To(Succeed())
+
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      runName.Name + "-pod",
+					Namespace: runName.Namespace,
+					Labels:    map[string]string{"job-name": runName.Name},
+				},
+				Status: v1.PodStatus{
+					InitContainerStatuses: []v1.ContainerStatus{
+						{Name: "reader", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+						{Name: "configure", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+					},
+					ContainerStatuses: []v1.ContainerStatus{
+						{Name: "status-writer", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+					},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, pod)).To(Succeed())
+		})
+
+		It("records the result and the tail of each container logs", func() {
+			reconcile()
+			Expect(run.Status.Result).To(Equal(v1alpha1.PipelineRunResultSucceeded))
+			Expect(run.IsFinished()).To(BeTrue())
+			Expect(run.Status.Containers).To(HaveLen(3))
+			Expect(run.Status.Containers[0].Name).To(Equal("reader"))
+			Expect(run.Status.Containers[1].Name).To(Equal("configure"))
+			Expect(run.Status.Containers[1].Logs).To(Equal("logs from configure"))
+			Expect(*run.Status.Containers[1].ExitCode).To(BeEquivalentTo(0))
+			Expect(run.Status.Containers[2].Name).To(Equal("status-writer"))
+
+			Expect(fakeLogReader.TailLogsCallCount()).To(Equal(3))
+			_, namespace, podName, _, lines := fakeLogReader.TailLogsArgsForCall(0)
+			Expect(namespace).To(Equal("default"))
+			Expect(podName).To(Equal(runName.Name + "-pod"))
+			Expect(lines).To(BeEquivalentTo(10))
+		})
+
+		It("keeps recording the run when logs cannot be read", func() {
+			fakeLogReader.TailLogsStub = nil
+			fakeLogReader.TailLogsReturns("", errors.New("pod gone"))
+			reconcile()
+			Expect(run.Status.Result).To(Equal(v1alpha1.PipelineRunResultSucceeded))
+			Expect(run.Status.Containers[0].Logs).To(Equal("<logs unavailable>"))
+		})
+	})
+
+	When("the Job has failed", func() {
+		BeforeEach(func() {
+			job.Status.Conditions = []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: v1.ConditionTrue, LastTransitionTime: metav1.Now()},
+			}
+			Expect(fakeK8sClient.Status().Update(ctx, job)).To(Succeed())
+		})
+
+		It("records the run as failed", func() {
+			reconcile()
+			Expect(run.Status.Result).To(Equal(v1alpha1.PipelineRunResultFailed))
+			Expect(run.IsFinished()).To(BeTrue())
+		})
+	})
+
+	When("the Job no longer exists", func() {
+		BeforeEach(func() {
+			Expect(fakeK8sClient.Delete(ctx, job)).To(Succeed())
+		})
+
+		It("finishes the run with an unknown result", func() {
+			reconcile()
+			Expect(run.Status.Result).To(Equal(v1alpha1.PipelineRunResultUnknown))
+			Expect(run.IsFinished()).To(BeTrue())
+		})
+	})
+
+	When("there are more finished runs than the history limit", func() {
+		BeforeEach(func() {
+			for i := 0; i < 3; i++ {
+				old := &v1alpha1.PipelineRun{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("old-run-%d", i),
+						Namespace: runName.Namespace,
+						Labels:    runLabels,
+					},
+				}
+				Expect(fakeK8sClient.Create(ctx, old)).To(Succeed())
+				completionTime := metav1.NewTime(time.Now().Add(time.Duration(i-10) * time.Minute))
+				old.Status.Result = v1alpha1.PipelineRunResultSucceeded
+				old.Status.CompletionTime = &completionTime
+				Expect(fakeK8sClient.Status().Update(ctx, old)).To(Succeed())
+			}
+
+			otherWorkflow := &v1alpha1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-workflow-run",
+					Namespace: runName.Namespace,
+					Labels:    map[string]string{"kratix-promise-id": "postgres"},
+				},
+			}
+			Expect(fakeK8sClient.Create(ctx, otherWorkflow)).To(Succeed())
+			completionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+			otherWorkflow.Status.CompletionTime = &completionTime
+			Expect(fakeK8sClient.Status().Update(ctx, otherWorkflow)).To(Succeed())
+
+			job.Status.Succeeded = 1
+			Expect(fakeK8sClient.Status().Update(ctx, job)).To(Succeed())
+		})
+
+		It("deletes the oldest runs of the same workflow", func() {
+			reconcile()
+
+			runs := &v1alpha1.PipelineRunList{}
+			Expect(fakeK8sClient.List(ctx, runs)).To(Succeed())
+			var names []string
+			for _, r := range runs.Items {
+				names = append(names, r.GetName())
+			}
+			Expect(names).To(ConsistOf(runName.Name, "old-run-2", "other-workflow-run"))
+		})
+	})
+})
@@ -413,6 +413,12 @@ func (r *PromiseReconciler) reconcileAllRRs(rrGVK schema.GroupVersionKind) error
 		}
 		newLabels[resourceutil.ManualReconciliationLabel] = "true"
 		rr.SetLabels(newLabels)
+		newAnnotations := rr.GetAnnotations()
+		if newAnnotations == nil {
+			newAnnotations = make(map[string]string)
+		}
+		newAnnotations[pipelineTriggerAnnotation] = v1alpha1.PipelineRunTriggerPromiseUpdate
+		rr.SetAnnotations(newAnnotations)
 		if err := r.Client.Update(context.TODO(), &rr); err != nil {
 			return err
 		}
@@ -436,6 +442,8 @@ func (r *PromiseReconciler) ensureDynamicControllerIsStarted(promise *v1alpha1.P
 
 		dynamicController.PromiseDestinationSelectors = promise.Spec.DestinationSelectors
 		dynamicController.PromiseWorkflowSelectors = work.GetDefaultScheduling("promise-workflow")
+		dynamicController.PromiseConfig = promise.Spec.Config
+		dynamicController.PromiseConfigSecretRefs = promise.Spec.ConfigSecretRefs
 
 		return nil
 	}
@@ -454,6 +462,8 @@ func (r *PromiseReconciler) ensureDynamicControllerIsStarted(promise *v1alpha1.P
 		DeletePipelines:             deletePipelines,
 		PromiseDestinationSelectors: promise.Spec.DestinationSelectors,
 		PromiseWorkflowSelectors:    work.GetDefaultScheduling("promise-workflow"),
+		PromiseConfig:               promise.Spec.Config,
+		PromiseConfigSecretRefs:     promise.Spec.ConfigSecretRefs,
 		Log:                         r.Log.WithName(promise.GetName()),
 		UID:                         string(promise.GetUID())[0:5],
 		Enabled:                     &enabled,
@@ -908,55 +918,97 @@ func generateCRDAndGVK(promise *v1alpha1.Promise, logger logr.Logger) (*apiexten
 	return rrCRD, rrGVK, nil
 }
 
+// setStatusFieldsOnCRD adds the status fields owned by Kratix to the Promise
+// API. A status schema and printer columns declared by the Promise author are
+// kept, so that pipelines can be validated against them; without a declared
+// schema, any status is accepted.
 func setStatusFieldsOnCRD(rrCRD *apiextensionsv1.CustomResourceDefinition) {
 	for i := range rrCRD.Spec.Versions {
 		rrCRD.Spec.Versions[i].Subresources = &apiextensionsv1.CustomResourceSubresources{
 			Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
 		}
 
-		rrCRD.Spec.Versions[i].AdditionalPrinterColumns = []apiextensionsv1.CustomResourceColumnDefinition{
+		printerColumns := []apiextensionsv1.CustomResourceColumnDefinition{
 			{
 				Name:     "status",
 				Type:     "string",
 				JSONPath: ".status.message",
 			},
 		}
+		for _, column := range rrCRD.Spec.Versions[i].AdditionalPrinterColumns {
+			if column.Name != "status" {
+				printerColumns = append(printerColumns, column)
+			}
+		}
+		rrCRD.Spec.Versions[i].AdditionalPrinterColumns = printerColumns
 
-		rrCRD.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["status"] = apiextensionsv1.JSONSchemaProps{
+		statusSchema := apiextensionsv1.JSONSchemaProps{
 			Type:                   "object",
 			XPreserveUnknownFields: &[]bool{true}[0], // pointer to bool
-			Properties: map[string]apiextensionsv1.JSONSchemaProps{
-				"message": {
-					Type: "string",
+		}
+		if declared, ok := rrCRD.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["status"]; ok && len(declared.Properties) > 0 {
+			statusSchema = declared
+			statusSchema.Type = "object"
+		}
+
+		properties := map[string]apiextensionsv1.JSONSchemaProps{}
+		for name, property := range statusSchema.Properties {
+			properties[name] = property
+		}
+		properties["message"] = apiextensionsv1.JSONSchemaProps{
+			Type: "string",
+		}
+		properties["conditions"] = apiextensionsv1.JSONSchemaProps{
+			Type: "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+				Schema: &apiextensionsv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"lastTransitionTime": {
+							Type:   "string",
+							Format: "datetime", //RFC3339
+						},
+						"message": {
+							Type: "string",
+						},
+						"reason": {
+							Type: "string",
+						},
+						"status": {
+							Type: "string",
+						},
+						"type": {
+							Type: "string",
+						},
+					},
 				},
-				"conditions": {
-					Type: "array",
-					Items: &apiextensionsv1.JSONSchemaPropsOrArray{
-						Schema: &apiextensionsv1.JSONSchemaProps{
+			},
+		}
+		properties["outputs"] = apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+				Allows: true,
+				Schema: &apiextensionsv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"value": {
+							Type: "string",
+						},
+						"secretRef": {
 							Type: "object",
 							Properties: map[string]apiextensionsv1.JSONSchemaProps{
-								"lastTransitionTime": {
-									Type:   "string",
-									Format: "datetime", //RFC3339
-								},
-								"message": {
-									Type: "string",
-								},
-								"reason": {
-									Type: "string",
-								},
-								"status": {
-									Type: "string",
-								},
-								"type": {
-									Type: "string",
-								},
+								"name":      {Type: "string"},
+								"namespace": {Type: "string"},
+								"key":       {Type: "string"},
 							},
 						},
 					},
 				},
 			},
 		}
+		statusSchema.Properties = properties
+
+		rrCRD.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["status"] = statusSchema
 	}
 }
 
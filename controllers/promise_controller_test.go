@@ -2,6 +2,7 @@ package controllers_test
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"regexp"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -200,6 +202,49 @@ var _ = Describe("PromiseController", func() {
 						Expect(*reconciler.StartedDynamicControllers["1234abcd"].CanCreateResources).To(BeTrue())
 					})
 				})
+
+				When("the API declares a status schema and printer columns", func() {
+					BeforeEach(func() {
+						crd, err := promise.GetAPIAsCRD()
+						Expect(err).NotTo(HaveOccurred())
+						crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"] = apiextensionsv1.JSONSchemaProps{
+							Type: "object",
+							Properties: map[string]apiextensionsv1.JSONSchemaProps{
+								"host": {Type: "string"},
+							},
+						}
+						crd.Spec.Versions[0].AdditionalPrinterColumns = []apiextensionsv1.CustomResourceColumnDefinition{
+							{Name: "host", Type: "string", JSONPath: ".status.host"},
+							{Name: "status", Type: "string", JSONPath: ".status.host"},
+						}
+						raw, err := json.Marshal(crd)
+						Expect(err).NotTo(HaveOccurred())
+						promise.Spec.API = &runtime.RawExtension{Raw: raw}
+						Expect(fakeK8sClient.Update(ctx, promise)).To(Succeed())
+					})
+
+					It("keeps them alongside the fields owned by Kratix", func() {
+						_, err := t.reconcileUntilCompletion(reconciler, promise, &opts{
+							funcs: []func(client.Object) error{autoMarkCRDAsEstablished},
+						})
+						Expect(err).NotTo(HaveOccurred())
+
+						crd, err := fakeApiExtensionsClient.CustomResourceDefinitions().Get(ctx, expectedCRDName, metav1.GetOptions{})
+						Expect(err).NotTo(HaveOccurred())
+
+						status := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+						Expect(status.XPreserveUnknownFields).To(BeNil())
+						Expect(status.Properties).To(HaveKey("host"))
+						Expect(status.Properties).To(HaveKey("message"))
+						Expect(status.Properties).To(HaveKey("conditions"))
+						Expect(status.Properties).To(HaveKey("outputs"))
+
+						Expect(crd.Spec.Versions[0].AdditionalPrinterColumns).To(Equal([]apiextensionsv1.CustomResourceColumnDefinition{
+							{Name: "status", Type: "string", JSONPath: ".status.message"},
+							{Name: "host", Type: "string", JSONPath: ".status.host"},
+						}))
+					})
+				})
 			})
 			When("the promise has requirements", func() {
 				BeforeEach(func() {
@@ -427,7 +472,27 @@ var _ = Describe("PromiseController", func() {
 						Expect(configMap.Data).To(HaveKey("destinationSelectors"))
 						space := regexp.MustCompile(`\s+`)
 						destinationSelectors := space.ReplaceAllString(configMap.Data["destinationSelectors"], " ")
-						Expect(strings.TrimSpace(destinationSelectors)).To(Equal(`- matchlabels: environment: dev source: promise`))
+						Expect(strings.TrimSpace(destinationSelectors)).To(Equal(`- matchLabels: environment: dev source: promise`))
+					})
+
+					By("allows the pipeline service account to manage ConfigMaps only in the namespace of its Works", func() {
+						role := &rbacv1.Role{}
+						Expect(fakeK8sClient.Get(ctx, promiseResourcesName, role)).To(Succeed(), "Expected Role for pipeline to exist")
+						Expect(role.GetLabels()).To(Equal(promiseCommonLabels))
+						Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{
+							Verbs:     []string{"get", "list", "create", "update", "delete"},
+							APIGroups: []string{""},
+							Resources: []string{"configmaps"},
+						}))
+
+						binding := &rbacv1.RoleBinding{}
+						Expect(fakeK8sClient.Get(ctx, promiseResourcesName, binding)).To(Succeed(), "Expected RoleBinding for pipeline to exist")
+						Expect(binding.RoleRef.Name).To(Equal(promiseResourcesName.Name))
+						Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+							Kind:      "ServiceAccount",
+							Namespace: "kratix-platform-system",
+							Name:      promiseResourcesName.Name,
+						}))
 					})
 
 					promiseResourcesName.Namespace = ""
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sort"
 	"time"
 
@@ -14,6 +15,8 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/syntasso/kratix/api/v1alpha1"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -23,6 +26,9 @@ const (
 	workLabelKey       = kratixPrefix + "work"
 	workloadGroupIDKey = kratixPrefix + "workload-group-id"
 	misscheduledLabel  = kratixPrefix + "misscheduled"
+	drainingLabel      = kratixPrefix + "draining"
+
+	drainingConditionType = "Draining"
 )
 
 type schedulingStatus string
@@ -36,57 +42,53 @@ const (
 type Scheduler struct {
 	Client client.Client
 	Log    logr.Logger
-}
-
-// Reconciles all Works by scheduling each Work's WorkloadGroups to appropriate
-// Destinations.
-// Only reconciles Works that are from a Promise Dependency.
-func (s *Scheduler) ReconcileAllDependencyWorks() error {
-	works := platformv1alpha1.WorkList{}
-	lo := &client.ListOptions{}
-	if err := s.Client.List(context.Background(), &works, lo); err != nil {
-		return err
-	}
-
-	for _, work := range works.Items {
-		if work.IsDependency() {
-			if _, err := s.ReconcileWork(&work); err != nil {
-				s.Log.Error(err, "Failed reconciling Work: ")
-			}
-		}
-	}
-
-	return nil
+	// Destinations, when set and synced, is used to look up Destinations
+	// instead of the Client
+	Destinations *DestinationSnapshot
 }
 
 // Reconciles all WorkloadGroups in a Work by scheduling them to Destinations via
 // Workplacements.
+// Returns the IDs of the WorkloadGroups that are not scheduled to as many
+// Destinations as the Work asks for.
 func (s *Scheduler) ReconcileWork(work *platformv1alpha1.Work) ([]string, error) {
 	unschedulable := []string{}
+	partiallyScheduled := []string{}
 	misscheduled := []string{}
+	workloadGroupStatuses := []platformv1alpha1.WorkloadGroupStatus{}
+	// the policy is resolved once, rather than for each WorkloadGroup
+	reschedule := s.reschedulePolicy(work)
 	for _, wg := range work.Spec.WorkloadGroups {
-		schedulingStatus, err := s.reconcileWorkloadGroup(wg, work)
+		schedulingStatus, placedReplicas, err := s.reconcileWorkloadGroup(wg, work, reschedule)
 		if err != nil {
 			return nil, err
 		}
 
 		if schedulingStatus == unscheduledStatus {
 			unschedulable = append(unschedulable, wg.ID)
+		} else if work.IsResourceRequest() && placedReplicas < work.Spec.Replicas {
+			partiallyScheduled = append(partiallyScheduled, wg.ID)
 		}
 
 		if schedulingStatus == misscheduledStatus {
 			misscheduled = append(misscheduled, wg.ID)
 		}
+
+		workloadGroupStatuses = append(workloadGroupStatuses, platformv1alpha1.WorkloadGroupStatus{
+			ID:             wg.ID,
+			Directory:      wg.Directory,
+			PlacedReplicas: placedReplicas,
+		})
 	}
 
-	if err := s.updateWorkStatus(work, unschedulable, misscheduled); err != nil {
+	if err := s.updateWorkStatus(work, unschedulable, partiallyScheduled, misscheduled, workloadGroupStatuses); err != nil {
 		return nil, err
 	}
 
-	return unschedulable, s.cleanupDanglingWorkplacements(work)
+	return append(unschedulable, partiallyScheduled...), s.cleanupDanglingWorkplacements(work)
 }
 
-func (s *Scheduler) updateWorkStatus(work *platformv1alpha1.Work, unscheduledWorkloadGroupIDs, missscheduledWorkloadGroupIDs []string) error {
+func (s *Scheduler) updateWorkStatus(work *platformv1alpha1.Work, unscheduledWorkloadGroupIDs, partiallyScheduledWorkloadGroupIDs, missscheduledWorkloadGroupIDs []string, workloadGroupStatuses []platformv1alpha1.WorkloadGroupStatus) error {
 	work = work.DeepCopy()
 	conditions := []metav1.Condition{
 		{
@@ -115,6 +117,10 @@ func (s *Scheduler) updateWorkStatus(work *platformv1alpha1.Work, unscheduledWor
 		conditions[0].Status = "False"
 		conditions[0].Message = fmt.Sprintf("No Destinations available work WorkloadGroups: %v", unscheduledWorkloadGroupIDs)
 		conditions[0].Reason = "UnscheduledWorkloadGroups"
+	} else if len(partiallyScheduledWorkloadGroupIDs) > 0 {
+		conditions[0].Status = "False"
+		conditions[0].Message = fmt.Sprintf("Not enough Destinations available to place %d replicas of WorkloadGroups: %v", work.Spec.Replicas, partiallyScheduledWorkloadGroupIDs)
+		conditions[0].Reason = "PartiallyScheduledWorkloadGroups"
 	}
 
 	if len(missscheduledWorkloadGroupIDs) > 0 {
@@ -123,17 +129,28 @@ func (s *Scheduler) updateWorkStatus(work *platformv1alpha1.Work, unscheduledWor
 		conditions[1].Reason = "ScheduledToIncorrectDestinations"
 	}
 
+	placedReplicas := 0
+	for i, wg := range workloadGroupStatuses {
+		if i == 0 || wg.PlacedReplicas < placedReplicas {
+			placedReplicas = wg.PlacedReplicas
+		}
+	}
+
 	if len(work.Status.Conditions) == 2 &&
 		work.Status.Conditions[0].Status == conditions[0].Status &&
 		work.Status.Conditions[0].Message == conditions[0].Message &&
 		work.Status.Conditions[0].Reason == conditions[0].Reason &&
 		work.Status.Conditions[1].Status == conditions[1].Status &&
 		work.Status.Conditions[1].Message == conditions[1].Message &&
-		work.Status.Conditions[1].Reason == conditions[1].Reason {
+		work.Status.Conditions[1].Reason == conditions[1].Reason &&
+		work.Status.PlacedReplicas == placedReplicas &&
+		reflect.DeepEqual(work.Status.WorkloadGroups, workloadGroupStatuses) {
 		return nil
 	}
 
 	work.Status.Conditions = conditions
+	work.Status.PlacedReplicas = placedReplicas
+	work.Status.WorkloadGroups = workloadGroupStatuses
 	return s.Client.Status().Update(context.Background(), work)
 }
 
@@ -167,43 +184,23 @@ func (s *Scheduler) cleanupDanglingWorkplacements(work *platformv1alpha1.Work) e
 	return nil
 }
 
-// Reconciles a WorkloadGroup by scheduling it to a Destination via a Workplacement.
-func (s *Scheduler) reconcileWorkloadGroup(workloadGroup platformv1alpha1.WorkloadGroup, work *platformv1alpha1.Work) (schedulingStatus, error) {
+// Reconciles a WorkloadGroup by scheduling it to Destinations via Workplacements.
+// Returns the number of Destinations the WorkloadGroup is scheduled to.
+func (s *Scheduler) reconcileWorkloadGroup(workloadGroup platformv1alpha1.WorkloadGroup, work *platformv1alpha1.Work, reschedule platformv1alpha1.ReschedulePolicy) (schedulingStatus, int, error) {
 	// TODO why pointer for work?
 
 	existingWorkplacements, err := s.getExistingWorkPlacementsForWorkloadGroup(work.Namespace, work.Name, workloadGroup)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	status := scheduledStatus
 	if work.IsResourceRequest() {
-		// If the Work is for a Resource Request, only one Workplacement will be created per
-		// WorkloadGroup. If this Workplacement already exists, it will be updated.
-		if len(existingWorkplacements) > 0 {
-			var errored int
-			for _, existingWorkplacement := range existingWorkplacements {
-				s.Log.Info("found workplacement for work; will try an update")
-				misscheduled, err := s.updateWorkPlacement(workloadGroup, work, &existingWorkplacement)
-				if err != nil {
-					s.Log.Error(err, "error updating workplacement for work", "workplacement", existingWorkplacement.Name, "work", work.Name, "workloadGroupID", workloadGroup.ID)
-					errored++
-				}
-				if misscheduled {
-					status = misscheduledStatus
-				}
-			}
-
-			if errored > 0 {
-				return "", fmt.Errorf("failed to update %d of %d workplacements for work", errored, len(existingWorkplacements))
-			}
-
-			return status, nil
-		}
+		return s.reconcileResourceRequestWorkloadGroup(workloadGroup, work, existingWorkplacements, reschedule)
 	}
 
+	status := scheduledStatus
 	destinationSelectors := resolveDestinationSelectorsForWorkloadGroup(workloadGroup, work)
-	targetDestinationNames := s.getTargetDestinationNames(destinationSelectors, work)
+	targetDestinationNames := s.getTargetDestinationNames(destinationSelectors)
 	targetDestinationMap := map[string]bool{}
 	for _, dest := range targetDestinationNames {
 		//false == not misscheduled
@@ -211,6 +208,9 @@ func (s *Scheduler) reconcileWorkloadGroup(workloadGroup platformv1alpha1.Worklo
 	}
 
 	for _, existingWorkplacement := range existingWorkplacements {
+		if !existingWorkplacement.GetDeletionTimestamp().IsZero() {
+			continue
+		}
 		dest := existingWorkplacement.Spec.TargetDestinationName
 		_, exists := targetDestinationMap[dest]
 		if !exists {
@@ -221,37 +221,315 @@ func (s *Scheduler) reconcileWorkloadGroup(workloadGroup platformv1alpha1.Worklo
 
 	if len(targetDestinationMap) == 0 {
 		s.Log.Info("no Destinations can be selected for scheduling", "scheduling", destinationSelectors, "workloadGroupDirectory", workloadGroup.Directory, "workloadGroupID", workloadGroup.ID)
-		return unscheduledStatus, nil
+		return unscheduledStatus, 0, nil
 	}
 
 	s.Log.Info("found available target Destinations", "work", work.GetName(), "destinations", targetDestinationNames)
 	misscheduled, err := s.applyWorkplacementsForTargetDestinations(workloadGroup, work, targetDestinationMap)
 	if err != nil {
-		return "", err
+		return "", 0, err
+	}
+
+	if misscheduled && len(targetDestinationNames) > 0 && reschedule == platformv1alpha1.RescheduleAutomatic {
+		removed, err := s.removeRescheduledDependencyWorkplacements(work, workloadGroup, targetDestinationMap)
+		if err != nil {
+			return "", 0, err
+		}
+		misscheduled = !removed
 	}
 
 	if misscheduled {
 		status = misscheduledStatus
 	}
 
-	return status, nil
+	return status, len(targetDestinationNames), nil
 }
 
-func (s *Scheduler) updateWorkPlacement(workloadGroup platformv1alpha1.WorkloadGroup, work *platformv1alpha1.Work, workPlacement *platformv1alpha1.WorkPlacement) (bool, error) {
-	misscheduled := true
+// Reconciles a WorkloadGroup of a Resource Request. Existing Workplacements
+// are updated and stay on their Destination; Workplacements are added or
+// removed until the WorkloadGroup is scheduled to as many Destinations as the
+// Work has replicas.
+func (s *Scheduler) reconcileResourceRequestWorkloadGroup(workloadGroup platformv1alpha1.WorkloadGroup, work *platformv1alpha1.Work, existingWorkplacements []platformv1alpha1.WorkPlacement, reschedulePolicy platformv1alpha1.ReschedulePolicy) (schedulingStatus, int, error) {
 	destinationSelectors := resolveDestinationSelectorsForWorkloadGroup(workloadGroup, work)
-	for _, dest := range s.getTargetDestinationNames(destinationSelectors, work) {
-		if dest == workPlacement.Spec.TargetDestinationName {
-			misscheduled = false
-			break
+	matchingDestinations := s.getDestinationsForWorkloadGroup(destinationSelectors)
+	matchingDestinationNames := map[string]bool{}
+	for _, destination := range matchingDestinations {
+		matchingDestinationNames[destination.Name] = true
+	}
+
+	placed := []platformv1alpha1.WorkPlacement{}
+	for _, existingWorkplacement := range existingWorkplacements {
+		if existingWorkplacement.GetDeletionTimestamp().IsZero() {
+			placed = append(placed, existingWorkplacement)
+		}
+	}
+
+	var errored int
+	for i := range placed {
+		s.Log.Info("found workplacement for work; will try an update")
+		if _, err := s.updateWorkPlacement(work, workloadGroup, &placed[i], matchingDestinationNames); err != nil {
+			s.Log.Error(err, "error updating workplacement for work", "workplacement", placed[i].Name, "work", work.Name, "workloadGroupID", workloadGroup.ID)
+			errored++
+		}
+	}
+
+	if errored > 0 {
+		return "", 0, fmt.Errorf("failed to update %d of %d workplacements for work", errored, len(placed))
+	}
+
+	// Workplacements being drained off their Destination, and with automatic
+	// rescheduling the misscheduled ones, are moved: they do not count as
+	// replicas and are kept until enough replacements are written.
+	reschedule := reschedulePolicy == platformv1alpha1.RescheduleAutomatic
+	replicas, moving := partitionWorkplacements(placed, func(workPlacement platformv1alpha1.WorkPlacement) bool {
+		if isDraining(workPlacement) {
+			return true
+		}
+		return reschedule && !matchingDestinationNames[workPlacement.Spec.TargetDestinationName]
+	})
+
+	if surplus := len(replicas) - work.Spec.Replicas; surplus > 0 {
+		remaining, err := s.removeSurplusWorkplacements(replicas, surplus, matchingDestinationNames)
+		if err != nil {
+			return "", 0, err
+		}
+		replicas = remaining
+	}
+
+	placedReplicas := len(replicas)
+	if missing := work.Spec.Replicas - len(replicas); missing > 0 {
+		inUse := map[string]bool{}
+		for _, workPlacement := range placed {
+			inUse[workPlacement.Spec.TargetDestinationName] = true
+		}
+
+		candidates := []platformv1alpha1.Destination{}
+		usedDestinations := []platformv1alpha1.Destination{}
+		for _, destination := range matchingDestinations {
+			if !inUse[destination.Name] && destination.Schedulable() {
+				candidates = append(candidates, destination)
+			}
+		}
+		for _, workPlacement := range replicas {
+			destination, found, err := s.getDestination(workPlacement.Spec.TargetDestinationName)
+			if err != nil {
+				return "", 0, err
+			}
+			if found {
+				usedDestinations = append(usedDestinations, destination)
+			}
+		}
+
+		targetDestinationNames := selectDestinations(candidates, usedDestinations, missing, work.Spec.SpreadConstraint)
+		if len(targetDestinationNames) > 0 {
+			s.Log.Info("found available target Destinations", "work", work.GetName(), "destinations", targetDestinationNames)
+			targetDestinationMap := map[string]bool{}
+			for _, dest := range targetDestinationNames {
+				//false == not misscheduled
+				targetDestinationMap[dest] = false
+			}
+			if _, err := s.applyWorkplacementsForTargetDestinations(workloadGroup, work, targetDestinationMap); err != nil {
+				return "", 0, err
+			}
+		}
+		placedReplicas += len(targetDestinationNames)
+	}
+
+	remaining := replicas
+	if len(moving) > 0 {
+		// replacements created in this reconcile are not written yet
+		written := 0
+		for _, workPlacement := range replicas {
+			if workloadsWritten(workPlacement) {
+				written++
+			}
+		}
+
+		kept, err := s.removeRescheduledWorkplacements(moving, work.Spec.Replicas-written)
+		if err != nil {
+			return "", 0, err
+		}
+
+		for _, workPlacement := range kept {
+			if !isDraining(workPlacement) {
+				continue
+			}
+			reason, message := "WaitingForReplacement", "Waiting for the workloads to be written to another Destination"
+			if placedReplicas < work.Spec.Replicas {
+				reason, message = "NoEligibleDestination", "No eligible Destination to migrate the workloads to"
+			}
+			if err := s.setDrainingCondition(workPlacement, reason, message); err != nil {
+				return "", 0, err
+			}
+		}
+
+		// kept WorkPlacements only make up the replicas that are still missing
+		if missing := work.Spec.Replicas - placedReplicas; missing > 0 {
+			if len(kept) < missing {
+				missing = len(kept)
+			}
+			placedReplicas += missing
+		}
+		remaining = append(remaining, kept...)
+	}
+
+	status := scheduledStatus
+	for _, workPlacement := range remaining {
+		if !matchingDestinationNames[workPlacement.Spec.TargetDestinationName] {
+			status = misscheduledStatus
+		}
+	}
+
+	if placedReplicas == 0 {
+		s.Log.Info("no Destinations can be selected for scheduling", "scheduling", destinationSelectors, "workloadGroupDirectory", workloadGroup.Directory, "workloadGroupID", workloadGroup.ID)
+		return unscheduledStatus, 0, nil
+	}
+	if placedReplicas < work.Spec.Replicas {
+		s.Log.Info("not enough Destinations can be selected to place all replicas", "replicas", work.Spec.Replicas, "placedReplicas", placedReplicas, "workloadGroupID", workloadGroup.ID)
+	}
+	return status, placedReplicas, nil
+}
+
+// partitionWorkplacements splits Workplacements into the ones that stay on
+// their Destination and the ones to move
+func partitionWorkplacements(workPlacements []platformv1alpha1.WorkPlacement, move func(platformv1alpha1.WorkPlacement) bool) ([]platformv1alpha1.WorkPlacement, []platformv1alpha1.WorkPlacement) {
+	staying := []platformv1alpha1.WorkPlacement{}
+	moving := []platformv1alpha1.WorkPlacement{}
+	for _, workPlacement := range workPlacements {
+		if move(workPlacement) {
+			moving = append(moving, workPlacement)
+		} else {
+			staying = append(staying, workPlacement)
+		}
+	}
+	return staying, moving
+}
+
+func isDraining(workPlacement platformv1alpha1.WorkPlacement) bool {
+	return workPlacement.GetLabels()[drainingLabel] == "true"
+}
+
+func (s *Scheduler) setDrainingCondition(workPlacement platformv1alpha1.WorkPlacement, reason, message string) error {
+	updatedWorkPlacement := &platformv1alpha1.WorkPlacement{}
+	if err := s.Client.Get(context.Background(), client.ObjectKeyFromObject(&workPlacement), updatedWorkPlacement); err != nil {
+		return err
+	}
+
+	existing := meta.FindStatusCondition(updatedWorkPlacement.Status.Conditions, drainingConditionType)
+	if existing != nil && existing.Reason == reason {
+		return nil
+	}
+
+	meta.SetStatusCondition(&updatedWorkPlacement.Status.Conditions, v1.Condition{
+		Type:    drainingConditionType,
+		Status:  v1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	return s.Client.Status().Update(context.Background(), updatedWorkPlacement)
+}
+
+// removeRescheduledWorkplacements deletes Workplacements that are moved,
+// keeping up to keep of them while their replacements are not written yet.
+// Returns the ones kept.
+func (s *Scheduler) removeRescheduledWorkplacements(moving []platformv1alpha1.WorkPlacement, keep int) ([]platformv1alpha1.WorkPlacement, error) {
+	if keep < 0 {
+		keep = 0
+	}
+	if keep >= len(moving) {
+		return moving, nil
+	}
+
+	sort.SliceStable(moving, func(i, j int) bool {
+		return moving[i].Name < moving[j].Name
+	})
+	for i := keep; i < len(moving); i++ {
+		s.Log.Info("deleting workplacement as it has been rescheduled", "workPlacementName", moving[i].Name, "namespace", moving[i].Namespace)
+		if err := s.Client.Delete(context.Background(), &moving[i]); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return moving[:keep], nil
+}
+
+// removeRescheduledDependencyWorkplacements deletes the misscheduled
+// Workplacements of a dependency WorkloadGroup once the workloads are written
+// to every matching Destination. Returns whether they were deleted.
+func (s *Scheduler) removeRescheduledDependencyWorkplacements(work *platformv1alpha1.Work, workloadGroup platformv1alpha1.WorkloadGroup, targetDestinationMap map[string]bool) (bool, error) {
+	workPlacements, err := s.getExistingWorkPlacementsForWorkloadGroup(work.Namespace, work.Name, workloadGroup)
+	if err != nil {
+		return false, err
+	}
+
+	misscheduled := []platformv1alpha1.WorkPlacement{}
+	for _, workPlacement := range workPlacements {
+		if targetDestinationMap[workPlacement.Spec.TargetDestinationName] {
+			misscheduled = append(misscheduled, workPlacement)
+			continue
+		}
+		if !workloadsWritten(workPlacement) {
+			return false, nil
+		}
+	}
+
+	if _, err := s.removeRescheduledWorkplacements(misscheduled, 0); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// workloadsWritten returns whether the current workloads of the Workplacement
+// are written to the State Store
+func workloadsWritten(workPlacement platformv1alpha1.WorkPlacement) bool {
+	condition := meta.FindStatusCondition(workPlacement.Status.Conditions, workloadsWrittenConditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == workPlacement.GetGeneration()
+}
+
+func (s *Scheduler) reschedulePolicy(work *platformv1alpha1.Work) platformv1alpha1.ReschedulePolicy {
+	promise := &platformv1alpha1.Promise{}
+	if err := s.Client.Get(context.Background(), client.ObjectKey{Name: work.Spec.PromiseName}, promise); err != nil {
+		if !errors.IsNotFound(err) {
+			s.Log.Error(err, "error getting Promise for reschedule policy, will not reschedule", "promise", work.Spec.PromiseName)
+		}
+		return platformv1alpha1.RescheduleNever
+	}
+	if promise.Spec.Reschedule == "" {
+		return platformv1alpha1.RescheduleNever
+	}
+	return promise.Spec.Reschedule
+}
+
+// removeSurplusWorkplacements deletes count Workplacements, the ones on
+// Destinations that no longer match first, and returns the remaining ones
+func (s *Scheduler) removeSurplusWorkplacements(workPlacements []platformv1alpha1.WorkPlacement, count int, matchingDestinationNames map[string]bool) ([]platformv1alpha1.WorkPlacement, error) {
+	sort.SliceStable(workPlacements, func(i, j int) bool {
+		iMatches := matchingDestinationNames[workPlacements[i].Spec.TargetDestinationName]
+		jMatches := matchingDestinationNames[workPlacements[j].Spec.TargetDestinationName]
+		if iMatches != jMatches {
+			return !iMatches
+		}
+		return workPlacements[i].Name > workPlacements[j].Name
+	})
+
+	for i := 0; i < count; i++ {
+		s.Log.Info("deleting workplacement as the work has fewer replicas", "workPlacementName", workPlacements[i].Name, "namespace", workPlacements[i].Namespace)
+		if err := s.Client.Delete(context.Background(), &workPlacements[i]); err != nil && !errors.IsNotFound(err) {
+			return nil, err
 		}
 	}
+	return workPlacements[count:], nil
+}
+
+func (s *Scheduler) updateWorkPlacement(work *platformv1alpha1.Work, workloadGroup platformv1alpha1.WorkloadGroup, workPlacement *platformv1alpha1.WorkPlacement, matchingDestinationNames map[string]bool) (bool, error) {
+	misscheduled := !matchingDestinationNames[workPlacement.Spec.TargetDestinationName]
 
 	if misscheduled {
 		s.labelWorkplacementAsMisscheduled(workPlacement)
+	} else {
+		delete(workPlacement.Labels, misscheduledLabel)
 	}
 
-	workPlacement.Spec.Workloads = workloadGroup.Workloads
+	setWorkloadsReference(workPlacement, work, workloadGroup)
 	if err := s.Client.Update(context.Background(), workPlacement); err != nil {
 		s.Log.Error(err, "Error updating WorkPlacement", "workplacement", workPlacement.Name)
 		return false, err
@@ -265,6 +543,16 @@ func (s *Scheduler) updateWorkPlacement(workloadGroup platformv1alpha1.WorkloadG
 	return misscheduled, nil
 }
 
+// setWorkloadsReference points the WorkPlacement at the current workloads of
+// the WorkloadGroup, instead of copying them into every WorkPlacement, and
+// copies the wave of the WorkloadGroup
+func setWorkloadsReference(workPlacement *platformv1alpha1.WorkPlacement, work *platformv1alpha1.Work, workloadGroup platformv1alpha1.WorkloadGroup) {
+	workPlacement.Spec.Workloads = nil
+	workPlacement.Spec.WorkName = work.Name
+	workPlacement.Spec.WorkloadsHash = workloadGroup.WorkloadsHash()
+	workPlacement.Spec.Wave = workloadGroup.Wave
+}
+
 func (s *Scheduler) labelWorkplacementAsMisscheduled(workPlacement *v1alpha1.WorkPlacement) {
 	s.Log.Info("Warning: WorkPlacement scheduled to destination that doesn't fufil scheduling requirements", "workplacement", workPlacement.Name, "namespace", workPlacement.Namespace)
 	newLabels := workPlacement.GetLabels()
@@ -292,34 +580,21 @@ func misscheduledWorkPlacements(listA, listB []v1alpha1.WorkPlacement) []v1alpha
 }
 
 func (s *Scheduler) getExistingWorkPlacementsForWorkloadGroup(namespace, workName string, workloadGroup platformv1alpha1.WorkloadGroup) ([]platformv1alpha1.WorkPlacement, error) {
-	return s.listWorkplacementWithLabels(namespace, map[string]string{
-		workLabelKey:       workName,
-		workloadGroupIDKey: workloadGroup.ID,
+	return s.listWorkplacements(namespace, client.MatchingFields{
+		WorkPlacementWorkloadGroupField: workloadGroupIndexValue(workName, workloadGroup.ID),
 	})
 }
 
 func (s *Scheduler) getExistingWorkPlacementsForWork(namespace, workName string) ([]platformv1alpha1.WorkPlacement, error) {
-	return s.listWorkplacementWithLabels(namespace, map[string]string{
-		workLabelKey: workName,
+	return s.listWorkplacements(namespace, client.MatchingFields{
+		WorkPlacementWorkField: workName,
 	})
 }
 
-func (s *Scheduler) listWorkplacementWithLabels(namespace string, matchLabels map[string]string) ([]platformv1alpha1.WorkPlacement, error) {
+func (s *Scheduler) listWorkplacements(namespace string, fields client.MatchingFields) ([]platformv1alpha1.WorkPlacement, error) {
 	workPlacementList := &platformv1alpha1.WorkPlacementList{}
-	workPlacementListOptions := &client.ListOptions{
-		Namespace: namespace,
-	}
-	workSelectorLabel := labels.FormatLabels(matchLabels)
-	//<none> is valid output from above
-	selector, err := labels.Parse(workSelectorLabel)
-
-	if err != nil {
-		s.Log.Error(err, "error parsing scheduling")
-	}
-	workPlacementListOptions.LabelSelector = selector
-
-	s.Log.Info("Listing Workplacements", "labels", workSelectorLabel)
-	err = s.Client.List(context.Background(), workPlacementList, workPlacementListOptions)
+	s.Log.Info("Listing Workplacements", "fields", fields)
+	err := s.Client.List(context.Background(), workPlacementList, client.InNamespace(namespace), fields)
 	if err != nil {
 		s.Log.Error(err, "Error getting WorkPlacements")
 		return nil, err
@@ -336,7 +611,7 @@ func (s *Scheduler) applyWorkplacementsForTargetDestinations(workloadGroup platf
 		workPlacement.Name = work.Name + "." + targetDestinationName + "-" + shortID(workloadGroup.ID)
 
 		op, err := controllerutil.CreateOrUpdate(context.Background(), s.Client, workPlacement, func() error {
-			workPlacement.Spec.Workloads = workloadGroup.Workloads
+			setWorkloadsReference(workPlacement, work, workloadGroup)
 			workPlacement.Labels = map[string]string{
 				workLabelKey:       work.Name,
 				workloadGroupIDKey: workloadGroup.ID,
@@ -377,82 +652,115 @@ func (s *Scheduler) updateStatus(workPlacement *platformv1alpha1.WorkPlacement,
 		return err
 	}
 
-	updatedWorkPlacement.Status.Conditions = nil
 	if misscheduled {
-		updatedWorkPlacement.Status.Conditions = []v1.Condition{
-			{
-				Message:            "Target destination no longer matches destinationSelectors",
-				Reason:             "DestinationSelectorMismatch",
-				Type:               "Misscheduled",
-				Status:             "True",
-				LastTransitionTime: v1.NewTime(time.Now()),
-			},
-		}
+		meta.SetStatusCondition(&updatedWorkPlacement.Status.Conditions, v1.Condition{
+			Message:            "Target destination no longer matches destinationSelectors",
+			Reason:             "DestinationSelectorMismatch",
+			Type:               "Misscheduled",
+			Status:             "True",
+			LastTransitionTime: v1.NewTime(time.Now()),
+		})
+	} else {
+		meta.RemoveStatusCondition(&updatedWorkPlacement.Status.Conditions, "Misscheduled")
 	}
 
 	return s.Client.Status().Update(context.Background(), updatedWorkPlacement)
 }
 
-// Where Work is a Resource Request return one random Destination name, where Work is a
-// DestinationWorkerResource return all Destination names
-func (s *Scheduler) getTargetDestinationNames(destinationSelectors map[string]string, work *platformv1alpha1.Work) []string {
+// Returns the names of all Destinations matching the selectors
+func (s *Scheduler) getTargetDestinationNames(destinationSelectors metav1.LabelSelector) []string {
 	destinations := s.getDestinationsForWorkloadGroup(destinationSelectors)
 
-	if len(destinations) == 0 {
-		return make([]string, 0)
-	}
-
-	if work.IsResourceRequest() {
-		s.Log.Info("Getting Destination names for Resource Request")
-		var targetDestinationNames = make([]string, 1)
-		rand.Seed(time.Now().UnixNano())
-		randomDestinationIndex := rand.Intn(len(destinations))
-		targetDestinationNames[0] = destinations[randomDestinationIndex].Name
-		s.Log.Info("Adding Destination: " + targetDestinationNames[0])
-		return targetDestinationNames
-	} else if work.IsDependency() {
-		s.Log.Info("Getting Destination names for dependencies")
-		var targetDestinationNames = make([]string, len(destinations))
-		for i := 0; i < len(destinations); i++ {
-			targetDestinationNames[i] = destinations[i].Name
-			s.Log.Info("Adding Destination: " + targetDestinationNames[i])
-		}
-		return targetDestinationNames
-	} else {
-		replicas := work.Spec.Replicas
-		s.Log.Info("Cannot interpret replica count: " + fmt.Sprint(replicas))
-		return make([]string, 0)
+	s.Log.Info("Getting Destination names for dependencies")
+	var targetDestinationNames = make([]string, len(destinations))
+	for i := 0; i < len(destinations); i++ {
+		targetDestinationNames[i] = destinations[i].Name
+		s.Log.Info("Adding Destination: " + targetDestinationNames[i])
 	}
+	return targetDestinationNames
 }
 
-// By default, all destinations are returned. However, if scheduling is provided, only matching destinations will be returned.
-func (s *Scheduler) getDestinationsForWorkloadGroup(destinationSelectors map[string]string) []platformv1alpha1.Destination {
-	destinationList := &platformv1alpha1.DestinationList{}
-	lo := &client.ListOptions{}
+// selectDestinations returns the names of up to count randomly chosen
+// candidates. With a spread constraint, only candidates with the topology
+// label are chosen, and each replica goes to the domain with the fewest
+// replicas, counting the Destinations already in use, that is under its
+// limit.
+func selectDestinations(candidates, inUse []platformv1alpha1.Destination, count int, spread *platformv1alpha1.SpreadConstraint) []string {
+	rand.Seed(time.Now().UnixNano())
+	candidates = append([]platformv1alpha1.Destination{}, candidates...)
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
 
-	if len(destinationSelectors) > 0 {
-		workloadGroupSelectorLabel := labels.FormatLabels(destinationSelectors)
-		//<none> is valid output from above
-		selector, err := labels.Parse(workloadGroupSelectorLabel)
+	selected := []string{}
+	if spread == nil {
+		for i := 0; i < count && i < len(candidates); i++ {
+			selected = append(selected, candidates[i].Name)
+		}
+		return selected
+	}
 
-		if err != nil {
-			s.Log.Error(err, "error parsing scheduling")
+	replicasPerDomain := map[string]int{}
+	for _, destination := range inUse {
+		if domain, ok := destination.GetLabels()[spread.TopologyKey]; ok {
+			replicasPerDomain[domain]++
 		}
-		lo.LabelSelector = selector
 	}
 
-	err := s.Client.List(context.Background(), destinationList, lo)
-	if err != nil {
-		s.Log.Error(err, "Error listing available Destinations")
+	candidatesPerDomain := map[string][]string{}
+	for _, destination := range candidates {
+		if domain, ok := destination.GetLabels()[spread.TopologyKey]; ok {
+			candidatesPerDomain[domain] = append(candidatesPerDomain[domain], destination.Name)
+		}
+	}
+
+	domains := []string{}
+	for domain := range candidatesPerDomain {
+		domains = append(domains, domain)
 	}
+	sort.Strings(domains)
+
+	for len(selected) < count {
+		nextDomain := ""
+		for _, domain := range domains {
+			if len(candidatesPerDomain[domain]) == 0 {
+				continue
+			}
+			if spread.MaxReplicasPerDomain > 0 && replicasPerDomain[domain] >= spread.MaxReplicasPerDomain {
+				continue
+			}
+			if nextDomain == "" || replicasPerDomain[domain] < replicasPerDomain[nextDomain] {
+				nextDomain = domain
+			}
+		}
+		if nextDomain == "" {
+			break
+		}
 
-	if len(destinationSelectors) > 0 {
-		return destinationList.Items
+		selected = append(selected, candidatesPerDomain[nextDomain][0])
+		candidatesPerDomain[nextDomain] = candidatesPerDomain[nextDomain][1:]
+		replicasPerDomain[nextDomain]++
+	}
+
+	return selected
+}
+
+// By default, all destinations are returned. However, if scheduling is provided, only matching destinations will be returned.
+func (s *Scheduler) getDestinationsForWorkloadGroup(destinationSelectors metav1.LabelSelector) []platformv1alpha1.Destination {
+	selected, err := s.destinationsMatching(destinationSelectors)
+	if err != nil {
+		s.Log.Error(err, "error parsing scheduling", "destinationSelectors", destinationSelectors)
+		return nil
 	}
 
 	destinations := []platformv1alpha1.Destination{}
-	for _, destination := range destinationList.Items {
-		if destination.Spec.StrictMatchLabels && len(destination.GetLabels()) > 0 {
+	for _, destination := range selected {
+		// Destinations being deleted only keep their WorkPlacements until the
+		// Destination finalizer resolves them
+		if !destination.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if !destinationMatchesSelectors(destination, destinationSelectors) {
 			continue
 		}
 		destinations = append(destinations, destination)
@@ -460,17 +768,104 @@ func (s *Scheduler) getDestinationsForWorkloadGroup(destinationSelectors map[str
 	return destinations
 }
 
-func resolveDestinationSelectorsForWorkloadGroup(workloadGroup platformv1alpha1.WorkloadGroup, work *platformv1alpha1.Work) map[string]string {
-	sortedWorkloadGroupDestinations := sortWorkloadGroupDestinationsByLowestPriority(workloadGroup.DestinationSelectors)
-	destinationSelectors := map[string]string{}
+// destinationsMatching returns the Destinations selected by the label selector,
+// from the Destination snapshot once it is synced
+func (s *Scheduler) destinationsMatching(destinationSelectors metav1.LabelSelector) ([]platformv1alpha1.Destination, error) {
+	if s.Destinations != nil && s.Destinations.Synced() {
+		return s.Destinations.Select(destinationSelectors)
+	}
 
-	for _, scheduling := range sortedWorkloadGroupDestinations {
-		for key, value := range scheduling.MatchLabels {
-			destinationSelectors[key] = value
+	selector, err := metav1.LabelSelectorAsSelector(&destinationSelectors)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationList := &platformv1alpha1.DestinationList{}
+	if err := s.Client.List(context.Background(), destinationList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		s.Log.Error(err, "Error listing available Destinations")
+	}
+	return destinationList.Items, nil
+}
+
+// getDestination returns the Destination with the given name, from the
+// Destination snapshot once it is synced
+func (s *Scheduler) getDestination(name string) (platformv1alpha1.Destination, bool, error) {
+	if s.Destinations != nil && s.Destinations.Synced() {
+		destination, found := s.Destinations.Get(name)
+		return destination, found, nil
+	}
+
+	destination := platformv1alpha1.Destination{}
+	if err := s.Client.Get(context.Background(), client.ObjectKey{Name: name}, &destination); err != nil {
+		if errors.IsNotFound(err) {
+			return destination, false, nil
+		}
+		return destination, false, err
+	}
+	return destination, true, nil
+}
+
+func hasDestinationSelectors(destinationSelectors metav1.LabelSelector) bool {
+	return len(destinationSelectors.MatchLabels) > 0 || len(destinationSelectors.MatchExpressions) > 0
+}
+
+// destinationMatchesSelectors returns whether the Destination labels are
+// selected by the destination selectors. Without selectors, every Destination
+// matches except the ones with strict label matching and a non-empty label
+// set.
+func destinationMatchesSelectors(destination platformv1alpha1.Destination, destinationSelectors metav1.LabelSelector) bool {
+	if !hasDestinationSelectors(destinationSelectors) {
+		return !destination.Spec.StrictMatchLabels || len(destination.GetLabels()) == 0
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&destinationSelectors)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(destination.GetLabels()))
+}
+
+// destinationSelectorKeys returns the label keys constrained by the
+// destination selectors, and AnyDestinationSelectorKey when no selector
+// requires a label: NotIn and DoesNotExist also select the Destinations
+// without any of the keys.
+func destinationSelectorKeys(destinationSelectors metav1.LabelSelector) []string {
+	if !hasDestinationSelectors(destinationSelectors) {
+		return []string{AnyDestinationSelectorKey}
+	}
+
+	keys := []string{}
+	requiresLabel := false
+	for key := range destinationSelectors.MatchLabels {
+		keys = append(keys, key)
+		requiresLabel = true
+	}
+	for _, expression := range destinationSelectors.MatchExpressions {
+		keys = append(keys, expression.Key)
+		if expression.Operator != metav1.LabelSelectorOpNotIn && expression.Operator != metav1.LabelSelectorOpDoesNotExist {
+			requiresLabel = true
 		}
 	}
+	if !requiresLabel {
+		keys = append(keys, AnyDestinationSelectorKey)
+	}
+	return keys
+}
+
+// resolveDestinationSelectorsForWorkloadGroup merges the selectors of all
+// sources. When sources constrain the same label key, only the constraints of
+// the source with the highest priority apply, see
+// platformv1alpha1.MergeLabelSelectors
+func resolveDestinationSelectorsForWorkloadGroup(workloadGroup platformv1alpha1.WorkloadGroup, work *platformv1alpha1.Work) metav1.LabelSelector {
+	destinationSelectors := append([]platformv1alpha1.WorkloadGroupScheduling{}, workloadGroup.DestinationSelectors...)
+	sortedWorkloadGroupDestinations := sortWorkloadGroupDestinationsByLowestPriority(destinationSelectors)
+
+	selectors := []metav1.LabelSelector{}
+	for i := len(sortedWorkloadGroupDestinations) - 1; i >= 0; i-- {
+		selectors = append(selectors, sortedWorkloadGroupDestinations[i].LabelSelector())
+	}
 
-	return destinationSelectors
+	return platformv1alpha1.MergeLabelSelectors(selectors...)
 }
 
 // Returned in order:
@@ -2,19 +2,23 @@ package controllers_test
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"strconv"
+	"testing"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/syntasso/kratix/api/v1alpha1"
 	. "github.com/syntasso/kratix/api/v1alpha1"
 	"github.com/syntasso/kratix/lib/hash"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	. "github.com/syntasso/kratix/controllers"
 )
@@ -45,65 +49,6 @@ var _ = Describe("Controllers/Scheduler", func() {
 		}
 	})
 
-	Describe("#ReconcileAllDependencyWorks", func() {
-		var devDestination3 Destination
-		var dependencyWorkForDev Work
-
-		BeforeEach(func() {
-			// dependency Work which is scheduled to all Destinations
-			newWork("work-name", DependencyReplicas)
-
-			// dependency Work scheduled only to devDestination
-			dependencyWorkForDev = newWork("dev-work-name", DependencyReplicas, schedulingFor(devDestination))
-
-			// dependency Work scheduled only to prodDestination
-			newWork("prod-work-name", DependencyReplicas, schedulingFor(prodDestination))
-
-			// resource Work scheduled only to devDestination
-			newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(devDestination))
-		})
-
-		When("a new Destination is added which matches a Work's scheduling", func() {
-			BeforeEach(func() {
-				// register new destination which matches both the dependency and resource schedulings
-				devDestination3 = newDestination("dev-3", schedulingFor(devDestination).MatchLabels)
-				Expect(fakeK8sClient.Create(context.Background(), &devDestination3)).To(Succeed())
-
-				scheduler.ReconcileAllDependencyWorks()
-			})
-
-			It("schedules dependency Works with matching labels to the new Destination", func() {
-				// get all the WorkPlacements for the dependency Work
-				lo := &client.ListOptions{}
-				selector, err := labels.Parse(labels.FormatLabels(map[string]string{"kratix.io/work": "dev-work-name"}))
-				Expect(err).NotTo(HaveOccurred())
-				lo.LabelSelector = selector
-				Expect(fakeK8sClient.List(context.Background(), &workPlacements, lo)).To(Succeed())
-
-				// check that the dependency work is scheduled to all destinations, including the new one (dev-3)
-				Expect(workPlacements.Items).To(HaveLen(3))
-
-				Expect(workPlacements.Items[0].Name).To(HavePrefix("dev-work-name.dev-1"))
-				Expect(workPlacements.Items[0].Namespace).To(Equal(KratixSystemNamespace))
-				Expect(workPlacements.Items[0].Spec.TargetDestinationName).To(Equal(devDestination.Name))
-				Expect(workPlacements.Items[0].Spec.Workloads).To(Equal(dependencyWorkForDev.Spec.WorkloadGroups[0].Workloads))
-				Expect(workPlacements.Items[0].Spec.ID).To(Equal(dependencyWorkForDev.Spec.WorkloadGroups[0].ID))
-
-				Expect(workPlacements.Items[1].Name).To(HavePrefix("dev-work-name.dev-2"))
-				Expect(workPlacements.Items[1].Namespace).To(Equal(KratixSystemNamespace))
-				Expect(workPlacements.Items[1].Spec.TargetDestinationName).To(Equal(devDestination2.Name))
-				Expect(workPlacements.Items[1].Spec.Workloads).To(Equal(dependencyWorkForDev.Spec.WorkloadGroups[0].Workloads))
-				Expect(workPlacements.Items[1].Spec.ID).To(Equal(dependencyWorkForDev.Spec.WorkloadGroups[0].ID))
-
-				Expect(workPlacements.Items[2].Name).To(HavePrefix("dev-work-name.dev-3"))
-				Expect(workPlacements.Items[2].Namespace).To(Equal(KratixSystemNamespace))
-				Expect(workPlacements.Items[2].Spec.TargetDestinationName).To(Equal(devDestination3.Name))
-				Expect(workPlacements.Items[2].Spec.Workloads).To(Equal(dependencyWorkForDev.Spec.WorkloadGroups[0].Workloads))
-				Expect(workPlacements.Items[2].Spec.ID).To(Equal(dependencyWorkForDev.Spec.WorkloadGroups[0].ID))
-			})
-		})
-	})
-
 	Describe("#ReconcileWork", func() {
 		Describe("Scheduling Resources (replicas=1)", func() {
 			var resourceWork, resourceWorkWithMultipleGroups Work
@@ -128,7 +73,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(workPlacement.ObjectMeta.Labels["kratix.io/work"]).To(Equal("rr-work-name"))
 					Expect(workPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]).To(Equal(hash.ComputeHash(".")))
 					Expect(workPlacement.Name).To(Equal("rr-work-name." + workPlacement.Spec.TargetDestinationName + "-" + hash.ComputeHash(".")[0:5]))
-					Expect(workPlacement.Spec.Workloads).To(Equal(resourceWork.Spec.WorkloadGroups[0].Workloads))
+					Expect(workPlacement.Spec.WorkloadsHash).To(Equal(resourceWork.Spec.WorkloadGroups[0].WorkloadsHash()))
 					Expect(workPlacement.Spec.ID).To(Equal(resourceWork.Spec.WorkloadGroups[0].ID))
 					Expect(workPlacement.Spec.TargetDestinationName).To(MatchRegexp("prod|dev\\-\\d"))
 					Expect(workPlacement.Finalizers).To(HaveLen(1), "expected one finalizer")
@@ -181,7 +126,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(newWorkPlacement.Namespace).To(Equal(workPlacement.Namespace))
 					Expect(newWorkPlacement.ObjectMeta.Labels["kratix.io/work"]).To(Equal(workPlacement.ObjectMeta.Labels["kratix.io/work"]))
 					Expect(newWorkPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]).To(Equal(workPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]))
-					Expect(newWorkPlacement.Spec.Workloads).To(Equal(workPlacement.Spec.Workloads))
+					Expect(newWorkPlacement.Spec.WorkloadsHash).To(Equal(workPlacement.Spec.WorkloadsHash))
 					Expect(newWorkPlacement.Spec.ID).To(Equal(workPlacement.Spec.ID))
 					Expect(newWorkPlacement.Spec.TargetDestinationName).To(Equal(workPlacement.Spec.TargetDestinationName))
 					Expect(newWorkPlacement.Finalizers).To(Equal(workPlacement.Finalizers))
@@ -201,7 +146,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(workPlacements.Items).To(HaveLen(1))
 
 					workPlacement := workPlacements.Items[0]
-					Expect(workPlacement.Spec.Workloads).To(HaveLen(1))
+					Expect(workPlacement.Spec.WorkloadsHash).To(Equal(resourceWork.Spec.WorkloadGroups[0].WorkloadsHash()))
 
 					previousResourceVersion, err := strconv.Atoi(workPlacement.ResourceVersion)
 
@@ -210,6 +155,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					resourceWork.Spec.WorkloadGroups[0].Workloads = append(resourceWork.Spec.WorkloadGroups[0].Workloads, Workload{
 						Content: "fake: content",
 					})
+					resourceWork.Spec.WorkloadGroups[0].Wave = 2
 
 					_, err = scheduler.ReconcileWork(&resourceWork)
 					Expect(err).ToNot(HaveOccurred())
@@ -217,12 +163,11 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
 					Expect(workPlacements.Items).To(HaveLen(1))
 
-					// expect the new Workload to be added to the existing WorkPlacement
+					// expect the existing WorkPlacement to reference the new Workloads
 					workPlacement = workPlacements.Items[0]
-					Expect(workPlacement.Spec.Workloads).To(HaveLen(2))
-					Expect(workPlacement.Spec.Workloads).To(ContainElement(Workload{
-						Content: "fake: content",
-					}))
+					Expect(workPlacement.Spec.Workloads).To(BeEmpty())
+					Expect(workPlacement.Spec.WorkloadsHash).To(Equal(resourceWork.Spec.WorkloadGroups[0].WorkloadsHash()))
+					Expect(workPlacement.Spec.Wave).To(Equal(2))
 
 					newResourceVersion, err := strconv.Atoi(workPlacement.ResourceVersion)
 					Expect(newResourceVersion).To(BeNumerically(">", previousResourceVersion))
@@ -301,7 +246,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(newWorkPlacement.ObjectMeta.Labels["kratix.io/work"]).To(Equal("rr-work-name"))
 					Expect(newWorkPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]).To(Equal(hash.ComputeHash("foo")))
 					Expect(newWorkPlacement.Name).To(Equal("rr-work-name." + newWorkPlacement.Spec.TargetDestinationName + "-" + hash.ComputeHash("foo")[0:5]))
-					Expect(newWorkPlacement.Spec.Workloads).To(Equal(resourceWork.Spec.WorkloadGroups[1].Workloads))
+					Expect(newWorkPlacement.Spec.WorkloadsHash).To(Equal(resourceWork.Spec.WorkloadGroups[1].WorkloadsHash()))
 					Expect(newWorkPlacement.Spec.ID).To(Equal(resourceWork.Spec.WorkloadGroups[1].ID))
 					Expect(newWorkPlacement.Spec.TargetDestinationName).To(MatchRegexp("prod|dev\\-\\d"))
 					Expect(newWorkPlacement.Finalizers).To(HaveLen(1), "expected one finalizer")
@@ -357,7 +302,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					workPlacement := workPlacements.Items[0]
 					Expect(workPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]).To(Equal(hash.ComputeHash("foo")))
 					Expect(workPlacement.Name).To(Equal("rr-work-name." + workPlacement.Spec.TargetDestinationName + "-" + hash.ComputeHash("foo")[0:5]))
-					Expect(workPlacement.Spec.Workloads).To(Equal(resourceWork.Spec.WorkloadGroups[0].Workloads))
+					Expect(workPlacement.Spec.WorkloadsHash).To(Equal(resourceWork.Spec.WorkloadGroups[0].WorkloadsHash()))
 					Expect(workPlacement.Spec.ID).To(Equal(resourceWork.Spec.WorkloadGroups[0].ID))
 				})
 			})
@@ -386,7 +331,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 						Expect(devOrProdWorkPlacement.ObjectMeta.Labels["kratix.io/work"]).To(Equal("rr-work-name-with-two-groups"))
 						Expect(devOrProdWorkPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]).To(Equal(hash.ComputeHash(".")))
 						Expect(devOrProdWorkPlacement.Name).To(Equal("rr-work-name-with-two-groups." + devOrProdWorkPlacement.Spec.TargetDestinationName + "-" + hash.ComputeHash(".")[0:5]))
-						Expect(devOrProdWorkPlacement.Spec.Workloads).To(Equal(resourceWorkWithMultipleGroups.Spec.WorkloadGroups[0].Workloads))
+						Expect(devOrProdWorkPlacement.Spec.WorkloadsHash).To(Equal(resourceWorkWithMultipleGroups.Spec.WorkloadGroups[0].WorkloadsHash()))
 						Expect(devOrProdWorkPlacement.Spec.TargetDestinationName).To(MatchRegexp("prod|dev\\-\\d"))
 						Expect(devOrProdWorkPlacement.Finalizers).To(HaveLen(1), "expected one finalizer")
 						Expect(devOrProdWorkPlacement.Finalizers[0]).To(Equal("finalizers.workplacement.kratix.io/repo-cleanup"))
@@ -397,7 +342,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 						Expect(pciWorkPlacement.ObjectMeta.Labels["kratix.io/work"]).To(Equal("rr-work-name-with-two-groups"))
 						Expect(pciWorkPlacement.ObjectMeta.Labels["kratix.io/workload-group-id"]).To(Equal(hash.ComputeHash("foo")))
 						Expect(pciWorkPlacement.Name).To(Equal("rr-work-name-with-two-groups." + pciWorkPlacement.Spec.TargetDestinationName + "-" + hash.ComputeHash("foo")[0:5]))
-						Expect(pciWorkPlacement.Spec.Workloads).To(Equal(resourceWorkWithMultipleGroups.Spec.WorkloadGroups[1].Workloads))
+						Expect(pciWorkPlacement.Spec.WorkloadsHash).To(Equal(resourceWorkWithMultipleGroups.Spec.WorkloadGroups[1].WorkloadsHash()))
 						Expect(pciWorkPlacement.Spec.TargetDestinationName).To(Equal("pci"))
 						Expect(pciWorkPlacement.Finalizers).To(HaveLen(1), "expected one finalizer")
 						Expect(pciWorkPlacement.Finalizers[0]).To(Equal("finalizers.workplacement.kratix.io/repo-cleanup"))
@@ -478,7 +423,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 						Expect(pciWorkPlacement.Spec.TargetDestinationName).To(Equal("pci"))
 						Expect(pciWorkPlacement.Finalizers).To(HaveLen(1), "expected one finalizer")
 						Expect(pciWorkPlacement.Finalizers[0]).To(Equal("finalizers.workplacement.kratix.io/repo-cleanup"))
-						Expect(pciWorkPlacement.Spec.Workloads).To(Equal(resourceWorkWithMultipleGroups.Spec.WorkloadGroups[1].Workloads))
+						Expect(pciWorkPlacement.Spec.WorkloadsHash).To(Equal(resourceWorkWithMultipleGroups.Spec.WorkloadGroups[1].WorkloadsHash()))
 						Expect(pciWorkPlacement.Spec.PromiseName).To(Equal("promise"))
 						Expect(pciWorkPlacement.Spec.ResourceName).To(Equal("resource"))
 					})
@@ -575,6 +520,439 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(workPlacement.Spec.TargetDestinationName).To(Equal("prod"))
 				})
 			})
+
+			When("sources combine matchLabels and matchExpressions on the same key", func() {
+				BeforeEach(func() {
+					resourceWork.Spec.WorkloadGroups[0].DestinationSelectors = []WorkloadGroupScheduling{
+						{
+							MatchLabels: map[string]string{"environment": "dev"},
+							Source:      "resource-workflow",
+						},
+						{
+							MatchExpressions: []v1.LabelSelectorRequirement{
+								{Key: "environment", Operator: v1.LabelSelectorOpNotIn, Values: []string{"dev", "staging"}},
+								{Key: "environment", Operator: v1.LabelSelectorOpExists},
+							},
+							Source: "promise",
+						},
+					}
+					_, err := scheduler.ReconcileWork(&resourceWork)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("only applies the constraints of the highest priority source", func() {
+					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+					Expect(workPlacements.Items).To(HaveLen(1))
+					Expect(workPlacements.Items[0].Spec.TargetDestinationName).To(Equal("prod"))
+				})
+			})
+		})
+
+		Describe("Scheduling Resources (replicas>1)", func() {
+			var resourceWork Work
+			var multiScheduling WorkloadGroupScheduling
+
+			BeforeEach(func() {
+				for name, zone := range map[string]string{"zone-a-1": "a", "zone-a-2": "a", "zone-b-1": "b"} {
+					destination := newDestination(name, map[string]string{"tier": "multi", "zone": zone})
+					Expect(fakeK8sClient.Create(context.Background(), &destination)).To(Succeed())
+				}
+				multiScheduling = WorkloadGroupScheduling{MatchLabels: map[string]string{"tier": "multi"}, Source: "promise"}
+			})
+
+			reconcile := func() []string {
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKeyFromObject(&resourceWork), &resourceWork)).To(Succeed())
+				unscheduled, err := scheduler.ReconcileWork(&resourceWork)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKeyFromObject(&resourceWork), &resourceWork)).To(Succeed())
+				return unscheduled
+			}
+
+			placedDestinations := func() []string {
+				Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+				destinations := []string{}
+				for _, workPlacement := range workPlacements.Items {
+					if workPlacement.GetDeletionTimestamp().IsZero() {
+						destinations = append(destinations, workPlacement.Spec.TargetDestinationName)
+					}
+				}
+				return destinations
+			}
+
+			zoneOf := func(destinationName string) string {
+				destination := Destination{}
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKey{Name: destinationName}, &destination)).To(Succeed())
+				return destination.GetLabels()["zone"]
+			}
+
+			setReplicas := func(replicas int, spread *SpreadConstraint) {
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKeyFromObject(&resourceWork), &resourceWork)).To(Succeed())
+				resourceWork.Spec.Replicas = replicas
+				resourceWork.Spec.SpreadConstraint = spread
+				Expect(fakeK8sClient.Update(context.Background(), &resourceWork)).To(Succeed())
+			}
+
+			When("there are enough matching Destinations", func() {
+				BeforeEach(func() {
+					resourceWork = newWork("rr-work-name", 2, multiScheduling)
+					Expect(reconcile()).To(BeEmpty())
+				})
+
+				It("schedules each replica to a different Destination", func() {
+					destinations := placedDestinations()
+					Expect(destinations).To(HaveLen(2))
+					Expect(destinations[0]).NotTo(Equal(destinations[1]))
+					Expect([]string{"zone-a-1", "zone-a-2", "zone-b-1"}).To(ContainElements(destinations))
+				})
+
+				It("shows the placed replicas in the Work status", func() {
+					Expect(resourceWork.Status.PlacedReplicas).To(Equal(2))
+					Expect(resourceWork.Status.WorkloadGroups).To(ConsistOf(WorkloadGroupStatus{
+						ID:             hash.ComputeHash("."),
+						Directory:      ".",
+						PlacedReplicas: 2,
+					}))
+					Expect(resourceWork.Status.Conditions[0].Status).To(Equal(v1.ConditionTrue))
+				})
+
+				It("keeps existing replicas in place when scaling up", func() {
+					existing := placedDestinations()
+					setReplicas(3, nil)
+					Expect(reconcile()).To(BeEmpty())
+
+					Expect(placedDestinations()).To(HaveLen(3))
+					Expect(placedDestinations()).To(ContainElements(existing))
+					Expect(resourceWork.Status.PlacedReplicas).To(Equal(3))
+				})
+
+				It("removes replicas when scaling down", func() {
+					setReplicas(1, nil)
+					Expect(reconcile()).To(BeEmpty())
+
+					Expect(placedDestinations()).To(HaveLen(1))
+					Expect(resourceWork.Status.PlacedReplicas).To(Equal(1))
+				})
+			})
+
+			When("there are not enough matching Destinations", func() {
+				BeforeEach(func() {
+					resourceWork = newWork("rr-work-name", 4, multiScheduling)
+				})
+
+				It("places as many replicas as it can and marks the Work as partially scheduled", func() {
+					Expect(reconcile()).To(ConsistOf(hash.ComputeHash(".")))
+
+					Expect(placedDestinations()).To(ConsistOf("zone-a-1", "zone-a-2", "zone-b-1"))
+					Expect(resourceWork.Status.PlacedReplicas).To(Equal(3))
+					Expect(resourceWork.Status.Conditions[0].Status).To(Equal(v1.ConditionFalse))
+					Expect(resourceWork.Status.Conditions[0].Reason).To(Equal("PartiallyScheduledWorkloadGroups"))
+					Expect(resourceWork.Status.Conditions[0].Message).To(ContainSubstring("place 4 replicas"))
+				})
+			})
+
+			When("the replicas are spread across a Destination label", func() {
+				BeforeEach(func() {
+					resourceWork = newWork("rr-work-name", 1, multiScheduling)
+				})
+
+				It("spreads the replicas evenly across the label values", func() {
+					setReplicas(2, &SpreadConstraint{TopologyKey: "zone"})
+					Expect(reconcile()).To(BeEmpty())
+
+					destinations := placedDestinations()
+					Expect(destinations).To(HaveLen(2))
+					Expect([]string{zoneOf(destinations[0]), zoneOf(destinations[1])}).To(ConsistOf("a", "b"))
+				})
+
+				It("does not exceed the maximum replicas per label value", func() {
+					setReplicas(3, &SpreadConstraint{TopologyKey: "zone", MaxReplicasPerDomain: 1})
+					Expect(reconcile()).To(ConsistOf(hash.ComputeHash(".")))
+
+					destinations := placedDestinations()
+					Expect(destinations).To(HaveLen(2))
+					Expect([]string{zoneOf(destinations[0]), zoneOf(destinations[1])}).To(ConsistOf("a", "b"))
+					Expect(resourceWork.Status.PlacedReplicas).To(Equal(2))
+				})
+
+				It("does not schedule to Destinations without the label", func() {
+					setReplicas(2, &SpreadConstraint{TopologyKey: "region"})
+					Expect(reconcile()).To(ConsistOf(hash.ComputeHash(".")))
+
+					Expect(placedDestinations()).To(BeEmpty())
+					Expect(resourceWork.Status.Conditions[0].Reason).To(Equal("UnscheduledWorkloadGroups"))
+				})
+			})
+		})
+
+		Describe("Rescheduling WorkPlacements", func() {
+			var work Work
+
+			reconcile := func() {
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKeyFromObject(&work), &work)).To(Succeed())
+				_, err := scheduler.ReconcileWork(&work)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKeyFromObject(&work), &work)).To(Succeed())
+			}
+
+			scheduleTo := func(scheduling WorkloadGroupScheduling) {
+				Expect(fakeK8sClient.Get(context.Background(), client.ObjectKeyFromObject(&work), &work)).To(Succeed())
+				work.Spec.WorkloadGroups[0].DestinationSelectors = []WorkloadGroupScheduling{scheduling}
+				Expect(fakeK8sClient.Update(context.Background(), &work)).To(Succeed())
+			}
+
+			activeWorkPlacements := func() map[string]WorkPlacement {
+				Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+				active := map[string]WorkPlacement{}
+				for _, workPlacement := range workPlacements.Items {
+					if workPlacement.GetDeletionTimestamp().IsZero() {
+						active[workPlacement.Spec.TargetDestinationName] = workPlacement
+					}
+				}
+				return active
+			}
+
+			markWritten := func(destinationName string) {
+				workPlacement := activeWorkPlacements()[destinationName]
+				workPlacement.Status.Conditions = append(workPlacement.Status.Conditions, v1.Condition{
+					Type:               "WorkloadsWritten",
+					Status:             v1.ConditionTrue,
+					Reason:             "WorkloadsWrittenToStateStore",
+					ObservedGeneration: workPlacement.GetGeneration(),
+					LastTransitionTime: v1.Now(),
+				})
+				Expect(fakeK8sClient.Status().Update(context.Background(), &workPlacement)).To(Succeed())
+			}
+
+			createPromise := func(policy ReschedulePolicy) {
+				promise := &Promise{
+					ObjectMeta: v1.ObjectMeta{Name: "promise"},
+					Spec:       PromiseSpec{Reschedule: policy},
+				}
+				Expect(fakeK8sClient.Create(context.Background(), promise)).To(Succeed())
+			}
+
+			When("the Promise reschedules automatically", func() {
+				BeforeEach(func() {
+					createPromise(RescheduleAutomatic)
+				})
+
+				It("reads the Promise once for all the WorkloadGroups of the Work", func() {
+					counter := &promiseGetCounter{Client: fakeK8sClient}
+					scheduler.Client = counter
+					work := newWorkWithTwoWorkloadGroups("rr-work-name-with-two-groups", ResourceRequestReplicas, schedulingFor(devDestination), schedulingFor(pciDestination))
+					_, err := scheduler.ReconcileWork(&work)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(counter.gets).To(Equal(1))
+				})
+
+				When("a resource Work no longer matches its Destination", func() {
+					BeforeEach(func() {
+						work = newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(prodDestination))
+						reconcile()
+						markWritten("prod")
+
+						scheduleTo(schedulingFor(pciDestination))
+						reconcile()
+					})
+
+					It("places the workloads on a matching Destination, keeping the misscheduled WorkPlacement until they are written", func() {
+						active := activeWorkPlacements()
+						Expect(active).To(HaveLen(2))
+						Expect(active).To(HaveKey("pci"))
+						Expect(active["prod"].Labels).To(HaveKeyWithValue("kratix.io/misscheduled", "true"))
+						Expect(work.Status.Conditions[1].Status).To(Equal(v1.ConditionTrue))
+					})
+
+					It("deletes the misscheduled WorkPlacement once the workloads are written to the new Destination", func() {
+						markWritten("pci")
+						reconcile()
+
+						active := activeWorkPlacements()
+						Expect(active).To(HaveLen(1))
+						Expect(active).To(HaveKey("pci"))
+						Expect(work.Status.PlacedReplicas).To(Equal(1))
+						Expect(work.Status.Conditions[1].Status).To(Equal(v1.ConditionFalse))
+					})
+				})
+
+				When("no other Destination matches", func() {
+					BeforeEach(func() {
+						work = newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(prodDestination))
+						reconcile()
+
+						scheduleTo(WorkloadGroupScheduling{MatchLabels: map[string]string{"environment": "staging"}, Source: "promise"})
+						reconcile()
+					})
+
+					It("keeps the workloads on the misscheduled Destination", func() {
+						active := activeWorkPlacements()
+						Expect(active).To(HaveLen(1))
+						Expect(active["prod"].Labels).To(HaveKeyWithValue("kratix.io/misscheduled", "true"))
+					})
+				})
+
+				When("a dependency Work no longer matches its Destination", func() {
+					BeforeEach(func() {
+						work = newWork("dependency-work-name", DependencyReplicas, schedulingFor(prodDestination))
+						reconcile()
+						markWritten("prod")
+
+						scheduleTo(schedulingFor(pciDestination))
+						reconcile()
+					})
+
+					It("deletes the misscheduled WorkPlacement once the workloads are written to every matching Destination", func() {
+						Expect(activeWorkPlacements()).To(HaveLen(2))
+
+						markWritten("pci")
+						reconcile()
+
+						active := activeWorkPlacements()
+						Expect(active).To(HaveLen(1))
+						Expect(active).To(HaveKey("pci"))
+					})
+				})
+			})
+
+			When("a Destination is unschedulable", func() {
+				BeforeEach(func() {
+					devDestination.Spec.Unschedulable = true
+					Expect(fakeK8sClient.Update(context.Background(), &devDestination)).To(Succeed())
+				})
+
+				It("does not schedule new resource Works to it", func() {
+					for i := 0; i < 5; i++ {
+						work = newWork("rr-work-name-"+strconv.Itoa(i), ResourceRequestReplicas, schedulingFor(devDestination))
+						reconcile()
+					}
+					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+					Expect(workPlacements.Items).To(HaveLen(5))
+					for _, workPlacement := range workPlacements.Items {
+						Expect(workPlacement.Spec.TargetDestinationName).To(Equal("dev-2"))
+					}
+				})
+
+				It("still schedules dependency Works to it", func() {
+					work = newWork("dependency-work-name", DependencyReplicas, schedulingFor(devDestination))
+					reconcile()
+					Expect(activeWorkPlacements()).To(HaveLen(2))
+				})
+			})
+
+			When("a Destination is being deleted", func() {
+				var deleted, other string
+
+				BeforeEach(func() {
+					work = newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(devDestination))
+					reconcile()
+					for name := range activeWorkPlacements() {
+						deleted = name
+					}
+					other = map[string]string{"dev-1": "dev-2", "dev-2": "dev-1"}[deleted]
+
+					destination := Destination{}
+					Expect(fakeK8sClient.Get(context.Background(), client.ObjectKey{Name: deleted}, &destination)).To(Succeed())
+					destination.Finalizers = []string{"finalizers.destination.kratix.io/workplacement-cleanup"}
+					Expect(fakeK8sClient.Update(context.Background(), &destination)).To(Succeed())
+					Expect(fakeK8sClient.Delete(context.Background(), &destination)).To(Succeed())
+				})
+
+				It("reschedules resource Works to another Destination once their WorkPlacement is removed", func() {
+					workPlacement := activeWorkPlacements()[deleted]
+					workPlacement.Finalizers = nil
+					Expect(fakeK8sClient.Update(context.Background(), &workPlacement)).To(Succeed())
+					Expect(fakeK8sClient.Delete(context.Background(), &workPlacement)).To(Succeed())
+					reconcile()
+
+					active := activeWorkPlacements()
+					Expect(active).To(HaveLen(1))
+					Expect(active).To(HaveKey(other))
+				})
+
+				It("does not schedule dependency Works to it", func() {
+					work = newWork("dependency-work-name", DependencyReplicas, schedulingFor(devDestination))
+					reconcile()
+
+					Expect(fakeK8sClient.List(context.Background(), &workPlacements, client.MatchingLabels{"kratix.io/work": "dependency-work-name"})).To(Succeed())
+					Expect(workPlacements.Items).To(HaveLen(1))
+					Expect(workPlacements.Items[0].Spec.TargetDestinationName).To(Equal(other))
+				})
+			})
+
+			When("a WorkPlacement is being drained off its Destination", func() {
+				drain := func(destinationName string) {
+					destination := Destination{}
+					Expect(fakeK8sClient.Get(context.Background(), client.ObjectKey{Name: destinationName}, &destination)).To(Succeed())
+					destination.Spec.Drain = true
+					Expect(fakeK8sClient.Update(context.Background(), &destination)).To(Succeed())
+
+					workPlacement := activeWorkPlacements()[destinationName]
+					workPlacement.Labels["kratix.io/draining"] = "true"
+					Expect(fakeK8sClient.Update(context.Background(), &workPlacement)).To(Succeed())
+				}
+
+				It("moves it to another eligible Destination once the workloads are written there", func() {
+					work = newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(devDestination))
+					reconcile()
+					var drained, other string
+					for name := range activeWorkPlacements() {
+						drained = name
+					}
+					other = map[string]string{"dev-1": "dev-2", "dev-2": "dev-1"}[drained]
+
+					drain(drained)
+					reconcile()
+
+					active := activeWorkPlacements()
+					Expect(active).To(HaveLen(2))
+					Expect(active).To(HaveKey(other))
+					condition := active[drained].Status.Conditions[len(active[drained].Status.Conditions)-1]
+					Expect(condition.Type).To(Equal("Draining"))
+					Expect(condition.Reason).To(Equal("WaitingForReplacement"))
+					Expect(work.Status.Conditions[1].Status).To(Equal(v1.ConditionFalse))
+					Expect(work.Status.PlacedReplicas).To(Equal(1))
+
+					markWritten(other)
+					reconcile()
+
+					active = activeWorkPlacements()
+					Expect(active).To(HaveLen(1))
+					Expect(active).To(HaveKey(other))
+					Expect(work.Status.PlacedReplicas).To(Equal(1))
+				})
+
+				It("keeps it in place when there is no other eligible Destination", func() {
+					work = newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(prodDestination))
+					reconcile()
+
+					drain("prod")
+					reconcile()
+
+					active := activeWorkPlacements()
+					Expect(active).To(HaveLen(1))
+					condition := active["prod"].Status.Conditions[len(active["prod"].Status.Conditions)-1]
+					Expect(condition.Type).To(Equal("Draining"))
+					Expect(condition.Reason).To(Equal("NoEligibleDestination"))
+					Expect(work.Status.PlacedReplicas).To(Equal(1))
+				})
+			})
+
+			When("the Promise never reschedules", func() {
+				BeforeEach(func() {
+					createPromise(RescheduleNever)
+					work = newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(prodDestination))
+					reconcile()
+
+					scheduleTo(schedulingFor(pciDestination))
+					reconcile()
+				})
+
+				It("keeps the workloads on the misscheduled Destination", func() {
+					active := activeWorkPlacements()
+					Expect(active).To(HaveLen(1))
+					Expect(active["prod"].Labels).To(HaveKeyWithValue("kratix.io/misscheduled", "true"))
+				})
+			})
 		})
 
 		Describe("Scheduling Dependencies (replicas=-1)", func() {
@@ -750,6 +1128,55 @@ var _ = Describe("Controllers/Scheduler", func() {
 				})
 			})
 
+			When("the Work selector uses matchExpressions", func() {
+				BeforeEach(func() {
+					dependencyWork.Spec.WorkloadGroups[0].DestinationSelectors = []v1alpha1.WorkloadGroupScheduling{
+						{
+							MatchExpressions: []v1.LabelSelectorRequirement{
+								{Key: "environment", Operator: v1.LabelSelectorOpIn, Values: []string{"dev", "prod"}},
+								{Key: "environment", Operator: v1.LabelSelectorOpNotIn, Values: []string{"prod"}},
+							},
+							Source: "promise",
+						},
+					}
+					_, err := scheduler.ReconcileWork(&dependencyWork)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("creates WorkPlacements for the Destinations matching all expressions", func() {
+					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+					var destinationNames []string
+					for _, workPlacement := range workPlacements.Items {
+						destinationNames = append(destinationNames, workPlacement.Spec.TargetDestinationName)
+					}
+					Expect(destinationNames).To(ConsistOf("dev-1", "dev-2"))
+				})
+			})
+
+			When("the Work selector requires a label to not exist", func() {
+				BeforeEach(func() {
+					dependencyWork.Spec.WorkloadGroups[0].DestinationSelectors = []v1alpha1.WorkloadGroupScheduling{
+						{
+							MatchExpressions: []v1.LabelSelectorRequirement{
+								{Key: "environment", Operator: v1.LabelSelectorOpDoesNotExist},
+							},
+							Source: "promise",
+						},
+					}
+					_, err := scheduler.ReconcileWork(&dependencyWork)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("schedules to Destinations without the label, including strict ones", func() {
+					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+					var destinationNames []string
+					for _, workPlacement := range workPlacements.Items {
+						destinationNames = append(destinationNames, workPlacement.Spec.TargetDestinationName)
+					}
+					Expect(destinationNames).To(ConsistOf("pci", "strict"))
+				})
+			})
+
 			When("the Work has no selector", func() {
 				BeforeEach(func() {
 					_, err := scheduler.ReconcileWork(&dependencyWork)
@@ -760,9 +1187,9 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
 					Expect(len(workPlacements.Items)).To(Equal(4))
 					for _, workPlacement := range workPlacements.Items {
-						Expect(workPlacement.Spec.Workloads).To(ConsistOf(Workload{
-							Content: "key: value",
-						}))
+						Expect(workPlacement.Spec.WorkName).To(Equal(dependencyWork.Name))
+						Expect(workPlacement.Spec.WorkloadsHash).To(Equal(dependencyWork.Spec.WorkloadGroups[0].WorkloadsHash()))
+						Expect(workPlacement.Spec.Workloads).To(BeEmpty())
 						Expect(workPlacement.Spec.TargetDestinationName).ToNot(Equal(strictDestination.Name))
 					}
 				})
@@ -781,10 +1208,7 @@ var _ = Describe("Controllers/Scheduler", func() {
 					Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
 					Expect(workPlacements.Items).To(HaveLen(4))
 					for _, workPlacement := range workPlacements.Items {
-						Expect(workPlacement.Spec.Workloads).To(ConsistOf(
-							Workload{Content: "key: value"},
-							Workload{Content: "fake: new-content"},
-						))
+						Expect(workPlacement.Spec.WorkloadsHash).To(Equal(dependencyWork.Spec.WorkloadGroups[0].WorkloadsHash()))
 					}
 				})
 			})
@@ -865,15 +1289,45 @@ var _ = Describe("Controllers/Scheduler", func() {
 					// check that all WorkPlacements have been updated, including the two
 					// misscheduled ones
 					for _, workPlacement := range workPlacements.Items {
-						Expect(workPlacement.Spec.Workloads).To(ConsistOf(
-							Workload{Content: "key: value"},
-							Workload{Content: "fake: new-content"},
-						))
+						Expect(workPlacement.Spec.WorkloadsHash).To(Equal(dependencyWork.Spec.WorkloadGroups[0].WorkloadsHash()))
 					}
 				})
 			})
 		})
 	})
+
+	Describe("with a Destination snapshot", func() {
+		BeforeEach(func() {
+			snapshot := NewDestinationSnapshot()
+			for _, destination := range []Destination{devDestination, devDestination2, pciDestination, prodDestination, strictDestination} {
+				snapshot.Set(destination)
+			}
+			scheduler.Destinations = snapshot
+		})
+
+		It("schedules resource Works to a matching Destination", func() {
+			work := newWork("rr-work-name", ResourceRequestReplicas, schedulingFor(devDestination))
+			_, err := scheduler.ReconcileWork(&work)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+			Expect(workPlacements.Items).To(HaveLen(1))
+			Expect(workPlacements.Items[0].Spec.TargetDestinationName).To(Or(Equal("dev-1"), Equal("dev-2")))
+		})
+
+		It("schedules dependency Works without selectors to every non-strict Destination", func() {
+			work := newWork("dependency-work-name", DependencyReplicas)
+			_, err := scheduler.ReconcileWork(&work)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeK8sClient.List(context.Background(), &workPlacements)).To(Succeed())
+			destinationNames := []string{}
+			for _, workPlacement := range workPlacements.Items {
+				destinationNames = append(destinationNames, workPlacement.Spec.TargetDestinationName)
+			}
+			Expect(destinationNames).To(ConsistOf("dev-1", "dev-2", "pci", "prod"))
+		})
+	})
 })
 
 func newDestination(name string, labels map[string]string) Destination {
@@ -979,3 +1433,206 @@ func schedulingFor(destination Destination) WorkloadGroupScheduling {
 		Source:      "promise",
 	}
 }
+
+var _ = Describe("DestinationSnapshot", func() {
+	var snapshot *DestinationSnapshot
+
+	names := func(destinations []Destination) []string {
+		result := []string{}
+		for _, destination := range destinations {
+			result = append(result, destination.Name)
+		}
+		return result
+	}
+
+	selectNames := func(selector v1.LabelSelector) []string {
+		destinations, err := snapshot.Select(selector)
+		Expect(err).ToNot(HaveOccurred())
+		return names(destinations)
+	}
+
+	BeforeEach(func() {
+		snapshot = NewDestinationSnapshot()
+		snapshot.Set(newDestination("dev-1", map[string]string{"environment": "dev", "zone": "a"}))
+		snapshot.Set(newDestination("dev-2", map[string]string{"environment": "dev", "zone": "b"}))
+		snapshot.Set(newDestination("prod", map[string]string{"environment": "prod", "zone": "a"}))
+		snapshot.Set(newDestination("unlabelled", nil))
+	})
+
+	It("selects every Destination for an empty selector", func() {
+		Expect(selectNames(v1.LabelSelector{})).To(Equal([]string{"dev-1", "dev-2", "prod", "unlabelled"}))
+	})
+
+	It("selects the Destinations matching labels and expressions", func() {
+		Expect(selectNames(v1.LabelSelector{MatchLabels: map[string]string{"environment": "dev", "zone": "a"}})).To(Equal([]string{"dev-1"}))
+		Expect(selectNames(v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+			{Key: "environment", Operator: v1.LabelSelectorOpIn, Values: []string{"dev", "prod"}},
+			{Key: "zone", Operator: v1.LabelSelectorOpNotIn, Values: []string{"b"}},
+		}})).To(Equal([]string{"dev-1", "prod"}))
+		Expect(selectNames(v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+			{Key: "zone", Operator: v1.LabelSelectorOpDoesNotExist},
+		}})).To(Equal([]string{"unlabelled"}))
+	})
+
+	It("reindexes relabelled and removed Destinations", func() {
+		snapshot.Set(newDestination("dev-2", map[string]string{"environment": "prod"}))
+		snapshot.Remove("prod")
+
+		Expect(selectNames(v1.LabelSelector{MatchLabels: map[string]string{"environment": "dev"}})).To(Equal([]string{"dev-1"}))
+		Expect(selectNames(v1.LabelSelector{MatchLabels: map[string]string{"environment": "prod"}})).To(Equal([]string{"dev-2"}))
+		_, found := snapshot.Get("prod")
+		Expect(found).To(BeFalse())
+	})
+
+	It("errors for an invalid selector", func() {
+		_, err := snapshot.Select(v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+			{Key: "zone", Operator: "Between"},
+		}})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// promiseGetCounter counts the Promises read through the client
+type promiseGetCounter struct {
+	client.Client
+	gets int
+}
+
+func (c *promiseGetCounter) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*Promise); ok {
+		c.gets++
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+// newBenchmarkScheduler returns a Scheduler over the given number of
+// Destinations, each with a unique "id" label, and as many resource Works
+// already scheduled to them
+func newBenchmarkScheduler(b *testing.B, destinations int, withSnapshot bool) *Scheduler {
+	b.Helper()
+	if err := AddToScheme(scheme.Scheme); err != nil {
+		b.Fatal(err)
+	}
+	clientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithStatusSubresource(&Work{}, &WorkPlacement{}, &Destination{})
+	for _, index := range Indexes {
+		clientBuilder = clientBuilder.WithIndex(index.Object, index.Field, index.Extract)
+	}
+	benchmarkClient := clientBuilder.Build()
+
+	snapshot := NewDestinationSnapshot()
+	scheduler := &Scheduler{
+		Client:       benchmarkClient,
+		Log:          logr.Discard(),
+		Destinations: snapshot,
+	}
+
+	for i := 0; i < destinations; i++ {
+		destination := newDestination("destination-"+strconv.Itoa(i), map[string]string{
+			"id":   strconv.Itoa(i),
+			"zone": "zone-" + strconv.Itoa(i%10),
+		})
+		if err := benchmarkClient.Create(context.Background(), &destination); err != nil {
+			b.Fatal(err)
+		}
+		snapshot.Set(destination)
+	}
+
+	for i := 0; i < destinations; i++ {
+		work := newBenchmarkWork(b, benchmarkClient, "work-"+strconv.Itoa(i), map[string]string{"id": strconv.Itoa(i)})
+		if _, err := scheduler.ReconcileWork(work); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	if !withSnapshot {
+		scheduler.Destinations = nil
+	}
+	return scheduler
+}
+
+func newBenchmarkWork(b *testing.B, c client.Client, name string, matchLabels map[string]string) *Work {
+	b.Helper()
+	work := &Work{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+		Spec: WorkSpec{
+			Replicas: ResourceRequestReplicas,
+			WorkloadCoreFields: WorkloadCoreFields{
+				PromiseName:  "promise",
+				ResourceName: name,
+				WorkloadGroups: []WorkloadGroup{{
+					Workloads:            []Workload{{Content: "key: value"}},
+					Directory:            ".",
+					ID:                   hash.ComputeHash("."),
+					DestinationSelectors: []WorkloadGroupScheduling{{MatchLabels: matchLabels, Source: "promise"}},
+				}},
+			},
+		},
+	}
+	if err := c.Create(context.Background(), work); err != nil {
+		b.Fatal(err)
+	}
+	return work
+}
+
+func BenchmarkSchedulerReconcileWork(b *testing.B) {
+	for _, withSnapshot := range []bool{false, true} {
+		b.Run(fmt.Sprintf("snapshot=%t", withSnapshot), func(b *testing.B) {
+			scheduler := newBenchmarkScheduler(b, 100, withSnapshot)
+			work := &Work{}
+			if err := scheduler.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "work-0"}, work); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := scheduler.ReconcileWork(work); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDestinationLookup(b *testing.B) {
+	if err := AddToScheme(scheme.Scheme); err != nil {
+		b.Fatal(err)
+	}
+	selector := v1.LabelSelector{MatchLabels: map[string]string{"id": "42", "zone": "zone-2"}}
+
+	for _, destinations := range []int{1000, 10000} {
+		benchmarkClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		snapshot := NewDestinationSnapshot()
+		for i := 0; i < destinations; i++ {
+			destination := newDestination("destination-"+strconv.Itoa(i), map[string]string{
+				"id":   strconv.Itoa(i),
+				"zone": "zone-" + strconv.Itoa(i%10),
+			})
+			if err := benchmarkClient.Create(context.Background(), &destination); err != nil {
+				b.Fatal(err)
+			}
+			snapshot.Set(destination)
+		}
+
+		b.Run(fmt.Sprintf("destinations=%d/client", destinations), func(b *testing.B) {
+			labelSelector, err := v1.LabelSelectorAsSelector(&selector)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < b.N; i++ {
+				destinationList := &DestinationList{}
+				if err := benchmarkClient.List(context.Background(), destinationList, &client.ListOptions{LabelSelector: labelSelector}); err != nil || len(destinationList.Items) != 1 {
+					b.Fatal(err, len(destinationList.Items))
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("destinations=%d/snapshot", destinations), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				selected, err := snapshot.Select(selector)
+				if err != nil || len(selected) != 1 {
+					b.Fatal(err, len(selected))
+				}
+			}
+		})
+	}
+}
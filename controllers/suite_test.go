@@ -24,12 +24,14 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/controllers"
 
 	fakeclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -58,6 +60,7 @@ var (
 var _ = BeforeSuite(func(_ SpecContext) {
 	err := platformv1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	Expect(clusterv1.AddToScheme(scheme.Scheme)).To(Succeed())
 	//+kubebuilder:scaffold:scheme
 
 	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
@@ -74,7 +77,7 @@ var _ = BeforeEach(func() {
 	resReq := &unstructured.Unstructured{}
 	Expect(yaml.Unmarshal(yamlFile, resReq)).To(Succeed())
 
-	fakeK8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithStatusSubresource(
+	clientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithStatusSubresource(
 		&platformv1alpha1.PromiseRelease{},
 		&platformv1alpha1.Promise{},
 		&platformv1alpha1.Work{},
@@ -82,9 +85,15 @@ var _ = BeforeEach(func() {
 		&platformv1alpha1.Destination{},
 		&platformv1alpha1.GitStateStore{},
 		&platformv1alpha1.BucketStateStore{},
+		&platformv1alpha1.PipelineRun{},
+		&platformv1alpha1.DestinationTemplate{},
 		//Add redis.marketplace.kratix.io/v1alpha1 so we can update its status
 		resReq,
-	).Build()
+	)
+	for _, index := range controllers.Indexes {
+		clientBuilder = clientBuilder.WithIndex(index.Object, index.Field, index.Extract)
+	}
+	fakeK8sClient = clientBuilder.Build()
 
 	fakeApiExtensionsClient = fakeclientset.NewSimpleClientset().ApiextensionsV1()
 	t = &testReconciler{}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/pipeline"
 	"github.com/syntasso/kratix/lib/resourceutil"
 	"github.com/syntasso/kratix/lib/writers"
 	batchv1 "k8s.io/api/batch/v1"
@@ -28,6 +29,7 @@ const (
 	promiseReleaseNameLabel        = kratixPrefix + "promise-release-name"
 	removeAllWorkflowJobsFinalizer = kratixPrefix + "workflows-cleanup"
 	runDeleteWorkflowsFinalizer    = kratixPrefix + "delete-workflows"
+	pipelineTriggerAnnotation      = kratixPrefix + "pipeline-trigger"
 )
 
 type StateStore interface {
@@ -64,7 +66,7 @@ func ensureConfigurePipelineIsReconciled(j jobOpts) (*ctrl.Result, error) {
 	// No jobs indicates this is the first reconciliation loop of this resource request
 	if len(pipelineJobs) == 0 {
 		j.logger.Info("No jobs found, creating workflow Job")
-		return &fastRequeue, createConfigurePipeline(j)
+		return &fastRequeue, createConfigurePipeline(j, v1alpha1.PipelineRunTriggerCreate)
 	}
 
 	existingPipelineJob, err := resourceutil.PipelineWithDesiredSpecExists(j.logger, j.obj, pipelineJobs)
@@ -94,8 +96,9 @@ func ensureConfigurePipelineIsReconciled(j jobOpts) (*ctrl.Result, error) {
 	}
 
 	if isManualReconciliation(j.obj.GetLabels()) || existingPipelineJob == nil {
-		j.logger.Info("Creating job for workflow", "manualTrigger", isManualReconciliation(j.obj.GetLabels()))
-		return &fastRequeue, createConfigurePipeline(j)
+		trigger := pipelineRunTrigger(j.obj)
+		j.logger.Info("Creating job for workflow", "trigger", trigger)
+		return &fastRequeue, createConfigurePipeline(j, trigger)
 	}
 
 	j.logger.Info("Job already exists and is complete for workflow")
@@ -125,7 +128,19 @@ func deleteConfigMap(j jobOpts) (*ctrl.Result, error) {
 	return nil, nil
 }
 
-func createConfigurePipeline(j jobOpts) error {
+// pipelineRunTrigger returns what caused a new pipeline to be required for obj,
+// given that a pipeline has run for it before
+func pipelineRunTrigger(obj *unstructured.Unstructured) string {
+	if !isManualReconciliation(obj.GetLabels()) {
+		return v1alpha1.PipelineRunTriggerSpecChange
+	}
+	if obj.GetAnnotations()[pipelineTriggerAnnotation] == v1alpha1.PipelineRunTriggerPromiseUpdate {
+		return v1alpha1.PipelineRunTriggerPromiseUpdate
+	}
+	return v1alpha1.PipelineRunTriggerManualReconciliation
+}
+
+func createConfigurePipeline(j jobOpts, trigger string) error {
 	updated, err := setPipelineCompletedConditionStatus(j.opts, j.obj)
 	if err != nil {
 		return err
@@ -139,10 +154,17 @@ func createConfigurePipeline(j jobOpts) error {
 
 	applyResources(j.opts, j.pipelineResources...)
 
+	if err := createPipelineRun(j, trigger); err != nil {
+		j.logger.Error(err, "failed to record pipeline run")
+	}
+
 	if isManualReconciliation(j.obj.GetLabels()) {
 		newLabels := j.obj.GetLabels()
 		delete(newLabels, resourceutil.ManualReconciliationLabel)
 		j.obj.SetLabels(newLabels)
+		newAnnotations := j.obj.GetAnnotations()
+		delete(newAnnotations, pipelineTriggerAnnotation)
+		j.obj.SetAnnotations(newAnnotations)
 		if err := j.client.Update(j.ctx, j.obj); err != nil {
 			return err
 		}
@@ -151,6 +173,36 @@ func createConfigurePipeline(j jobOpts) error {
 	return nil
 }
 
+// createPipelineRun records a run for each pipeline Job that exists. A Job
+// applyResources failed to create is not recorded, as it will never run.
+func createPipelineRun(j jobOpts, trigger string) error {
+	for _, resource := range j.pipelineResources {
+		job, ok := resource.(*batchv1.Job)
+		if !ok {
+			continue
+		}
+
+		if err := j.client.Get(j.ctx, client.ObjectKeyFromObject(job), &batchv1.Job{}); err != nil {
+			if errors.IsNotFound(err) {
+				j.logger.Info("Pipeline Job was not created, not recording its run", "job", job.GetName())
+				continue
+			}
+			return err
+		}
+
+		run, err := pipeline.NewPipelineRun(j.obj, job, trigger)
+		if err != nil {
+			return err
+		}
+
+		j.logger.Info("Recording pipeline run", "pipelineRun", run.GetName(), "trigger", trigger)
+		if err := j.client.Create(j.ctx, run); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 func ensureDeletePipelineIsReconciled(jobOpts jobOpts) (ctrl.Result, error) {
 	jobOpts.logger.Info("labels", "labels", jobOpts.pipelineLabels)
 	existingDeletePipeline, err := getDeletePipeline(jobOpts.opts, jobOpts.obj.GetNamespace(), jobOpts.pipelineLabels)
@@ -164,6 +216,10 @@ func ensureDeletePipelineIsReconciled(jobOpts jobOpts) (ctrl.Result, error) {
 		//TODO retrieve error information from applyResources to return to the caller
 		applyResources(jobOpts.opts, jobOpts.pipelineResources...)
 
+		if err := createPipelineRun(jobOpts, v1alpha1.PipelineRunTriggerDelete); err != nil {
+			jobOpts.logger.Error(err, "failed to record pipeline run")
+		}
+
 		return defaultRequeue, nil
 	}
 
@@ -258,45 +314,68 @@ func fetchObjectAndSecret(o opts, stateStoreRef client.ObjectKey, stateStore Sta
 	return secret, nil
 }
 
-func newWriter(o opts, destination v1alpha1.Destination) (writers.StateStoreWriter, error) {
+// newWriter returns the writer of the State Store of the Destination, and the
+// layout of the Destination directories within it
+func newWriter(o opts, destination v1alpha1.Destination) (writers.StateStoreWriter, writers.Layout, error) {
 	stateStoreRef := client.ObjectKey{
 		Name:      destination.Spec.StateStoreRef.Name,
 		Namespace: destination.Namespace,
 	}
 
 	var writer writers.StateStoreWriter
+	var stateStoreCoreFields v1alpha1.StateStoreCoreFields
 	var err error
 	switch destination.Spec.StateStoreRef.Kind {
 	case "BucketStateStore":
 		stateStore := &v1alpha1.BucketStateStore{}
 		secret, fetchErr := fetchObjectAndSecret(o, stateStoreRef, stateStore)
 		if fetchErr != nil {
-			return nil, fetchErr
+			return nil, writers.Layout{}, fetchErr
 		}
 		var data map[string][]byte = nil
 		if secret != nil {
 			data = secret.Data
 		}
 
+		stateStoreCoreFields = stateStore.Spec.StateStoreCoreFields
 		writer, err = writers.NewS3Writer(o.logger.WithName("writers").WithName("BucketStateStoreWriter"), stateStore.Spec, destination, data)
 	case "GitStateStore":
 		stateStore := &v1alpha1.GitStateStore{}
 		secret, fetchErr := fetchObjectAndSecret(o, stateStoreRef, stateStore)
 		if fetchErr != nil {
-			return nil, fetchErr
+			return nil, writers.Layout{}, fetchErr
 		}
 
+		stateStoreCoreFields = stateStore.Spec.StateStoreCoreFields
 		writer, err = writers.NewGitWriter(o.logger.WithName("writers").WithName("GitStateStoreWriter"), stateStore.Spec, destination, secret.Data)
+	case "KubernetesStateStore":
+		stateStore := &v1alpha1.KubernetesStateStore{}
+		secret, fetchErr := fetchObjectAndSecret(o, stateStoreRef, stateStore)
+		if fetchErr != nil {
+			return nil, writers.Layout{}, fetchErr
+		}
+		if secret == nil {
+			return nil, writers.Layout{}, fmt.Errorf("KubernetesStateStore %s has no secretRef", stateStore.GetName())
+		}
+
+		stateStoreCoreFields = stateStore.Spec.StateStoreCoreFields
+		writer, err = writers.NewKubernetesWriter(o.logger.WithName("writers").WithName("KubernetesStateStoreWriter"), stateStore.Spec, destination, secret.Data)
 	default:
-		return nil, fmt.Errorf("unsupported kind %s", destination.Spec.StateStoreRef.Kind)
+		return nil, writers.Layout{}, fmt.Errorf("unsupported kind %s", destination.Spec.StateStoreRef.Kind)
 	}
 
 	if err != nil {
 		//TODO: should this be a retryable error?
 		o.logger.Error(err, "unable to create StateStoreWriter")
-		return nil, err
+		return nil, writers.Layout{}, err
+	}
+
+	layout, err := writers.NewLayout(stateStoreCoreFields, destination)
+	if err != nil {
+		o.logger.Error(err, "invalid State Store layout")
+		return nil, writers.Layout{}, err
 	}
-	return writer, nil
+	return writer, layout, nil
 }
 
 func getJobsWithLabels(o opts, jobLabels map[string]string, namespace string) ([]batchv1.Job, error) {
@@ -18,12 +18,20 @@ package controllers
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/go-logr/logr"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // WorkReconciler reconciles a Work object
@@ -44,6 +52,8 @@ type WorkScheduler interface {
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=works/finalizers,verbs=update
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=workplacements,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=workplacements/status,verbs=get
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=destinations,verbs=get;list;watch
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=promises,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -83,7 +93,7 @@ func (r *WorkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	}
 
 	if work.IsResourceRequest() && len(unscheduledWorkloadGroupIDs) > 0 {
-		logger.Info("no available Destinations for some of the workload groups, trying again shortly", "workloadGroupIDs", unscheduledWorkloadGroupIDs)
+		logger.Info("not enough available Destinations for some of the workload groups, trying again shortly", "workloadGroupIDs", unscheduledWorkloadGroupIDs)
 		return slowRequeue, nil
 	}
 
@@ -91,10 +101,157 @@ func (r *WorkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 }
 
+// WorksForDestinationChange returns the Works to reconcile when a Destination
+// changes from oldDestination to newDestination; either is nil when the
+// Destination is created or deleted. Those are the Works with a WorkloadGroup
+// selecting the Destination before or after the change, and the Works with
+// WorkPlacements on it.
+func (r *WorkReconciler) WorksForDestinationChange(ctx context.Context, oldDestination, newDestination *platformv1alpha1.Destination) ([]reconcile.Request, error) {
+	keys := changedDestinationSelectorKeys(oldDestination, newDestination)
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	requests := map[types.NamespacedName]bool{}
+	for _, key := range keys {
+		works := &platformv1alpha1.WorkList{}
+		if err := r.Client.List(ctx, works, client.MatchingFields{WorkDestinationSelectorKeysField: key}); err != nil {
+			return nil, err
+		}
+		for i := range works.Items {
+			if workSelectsDestination(&works.Items[i], oldDestination) || workSelectsDestination(&works.Items[i], newDestination) {
+				requests[client.ObjectKeyFromObject(&works.Items[i])] = true
+			}
+		}
+	}
+
+	destinationName := ""
+	if newDestination != nil {
+		destinationName = newDestination.GetName()
+	} else if oldDestination != nil {
+		destinationName = oldDestination.GetName()
+	}
+	workPlacements := &platformv1alpha1.WorkPlacementList{}
+	if err := r.Client.List(ctx, workPlacements, client.MatchingFields{WorkPlacementTargetDestinationField: destinationName}); err != nil {
+		return nil, err
+	}
+	for _, workPlacement := range workPlacements.Items {
+		if workName := workPlacement.GetLabels()[workLabelKey]; workName != "" {
+			requests[types.NamespacedName{Namespace: workPlacement.GetNamespace(), Name: workName}] = true
+		}
+	}
+
+	result := []reconcile.Request{}
+	for request := range requests {
+		result = append(result, reconcile.Request{NamespacedName: request})
+	}
+	return result, nil
+}
+
+// changedDestinationSelectorKeys returns the selector keys under which the
+// affected Works are indexed. Any change to the spec, or to whether the
+// Destination is being deleted, affects its eligibility for every Work
+// selecting it; a label change only affects the Works constraining the
+// changed keys.
+func changedDestinationSelectorKeys(oldDestination, newDestination *platformv1alpha1.Destination) []string {
+	if oldDestination == nil || newDestination == nil ||
+		!reflect.DeepEqual(oldDestination.Spec, newDestination.Spec) ||
+		oldDestination.GetDeletionTimestamp().IsZero() != newDestination.GetDeletionTimestamp().IsZero() {
+		keys := []string{AnyDestinationSelectorKey}
+		for _, destination := range []*platformv1alpha1.Destination{oldDestination, newDestination} {
+			if destination == nil {
+				continue
+			}
+			for key := range destination.GetLabels() {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+
+	oldLabels, newLabels := oldDestination.GetLabels(), newDestination.GetLabels()
+	keys := []string{}
+	for key, value := range oldLabels {
+		if newValue, ok := newLabels[key]; !ok || newValue != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range newLabels {
+		if _, ok := oldLabels[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	if newDestination.Spec.StrictMatchLabels && (len(oldLabels) == 0) != (len(newLabels) == 0) {
+		keys = append(keys, AnyDestinationSelectorKey)
+	}
+	return keys
+}
+
+func workSelectsDestination(work *platformv1alpha1.Work, destination *platformv1alpha1.Destination) bool {
+	if destination == nil {
+		return false
+	}
+	for _, workloadGroup := range work.Spec.WorkloadGroups {
+		if destinationMatchesSelectors(*destination, resolveDestinationSelectorsForWorkloadGroup(workloadGroup, work)) {
+			return true
+		}
+	}
+	return false
+}
+
+// worksForPromise returns the Works of the Promise, so that changes to its
+// scheduling policies apply to them
+func (r *WorkReconciler) worksForPromise(ctx context.Context, obj client.Object) []reconcile.Request {
+	works := &platformv1alpha1.WorkList{}
+	if err := r.Client.List(ctx, works, client.MatchingFields{WorkPromiseField: obj.GetName()}); err != nil {
+		r.Log.Error(err, "Error listing Works for Promise", "promise", obj.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range works.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&works.Items[i])})
+	}
+	return requests
+}
+
+func (r *WorkReconciler) enqueueWorksForDestinationChange(ctx context.Context, queue workqueue.RateLimitingInterface, oldObj, newObj client.Object) {
+	oldDestination, _ := oldObj.(*platformv1alpha1.Destination)
+	newDestination, _ := newObj.(*platformv1alpha1.Destination)
+
+	requests, err := r.WorksForDestinationChange(ctx, oldDestination, newDestination)
+	if err != nil {
+		r.Log.Error(err, "Error finding Works affected by Destination change")
+		return
+	}
+	for _, request := range requests {
+		queue.Add(request)
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *WorkReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&platformv1alpha1.Work{}).
 		Owns(&platformv1alpha1.WorkPlacement{}).
+		Watches(
+			&platformv1alpha1.Destination{},
+			handler.Funcs{
+				CreateFunc: func(ctx context.Context, e event.CreateEvent, queue workqueue.RateLimitingInterface) {
+					r.enqueueWorksForDestinationChange(ctx, queue, nil, e.Object)
+				},
+				UpdateFunc: func(ctx context.Context, e event.UpdateEvent, queue workqueue.RateLimitingInterface) {
+					r.enqueueWorksForDestinationChange(ctx, queue, e.ObjectOld, e.ObjectNew)
+				},
+				DeleteFunc: func(ctx context.Context, e event.DeleteEvent, queue workqueue.RateLimitingInterface) {
+					r.enqueueWorksForDestinationChange(ctx, queue, e.Object, nil)
+				},
+			},
+		).
+		Watches(
+			&platformv1alpha1.Promise{},
+			handler.EnqueueRequestsFromMapFunc(r.worksForPromise),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
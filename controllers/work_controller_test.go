@@ -25,6 +25,7 @@ import (
 	"github.com/syntasso/kratix/controllers"
 	"github.com/syntasso/kratix/controllers/controllersfakes"
 	"github.com/syntasso/kratix/lib/hash"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -154,4 +155,84 @@ var _ = Describe("WorkReconciler", func() {
 			})
 		})
 	})
+
+	Describe("#WorksForDestinationChange", func() {
+		var devDestination, prodDestination platformv1alpha1.Destination
+
+		worksFor := func(oldDestination, newDestination *platformv1alpha1.Destination) []string {
+			requests, err := reconciler.WorksForDestinationChange(ctx, oldDestination, newDestination)
+			Expect(err).NotTo(HaveOccurred())
+			names := []string{}
+			for _, request := range requests {
+				names = append(names, request.Name)
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			devDestination = newDestination("dev", map[string]string{"environment": "dev"})
+			prodDestination = newDestination("prod", map[string]string{"environment": "prod"})
+
+			newWork("dev-dependency", platformv1alpha1.DependencyReplicas, schedulingFor(devDestination))
+			newWork("prod-dependency", platformv1alpha1.DependencyReplicas, schedulingFor(prodDestination))
+			newWork("any-dependency", platformv1alpha1.DependencyReplicas)
+			newWork("dev-resource", platformv1alpha1.ResourceRequestReplicas, schedulingFor(devDestination))
+		})
+
+		It("returns the Works selecting a new Destination", func() {
+			Expect(worksFor(nil, &devDestination)).To(ConsistOf("dev-dependency", "any-dependency", "dev-resource"))
+		})
+
+		It("does not return Works without selectors for a new Destination with strict label matching", func() {
+			devDestination.Spec.StrictMatchLabels = true
+			Expect(worksFor(nil, &devDestination)).To(ConsistOf("dev-dependency", "dev-resource"))
+		})
+
+		It("returns the Works excluding a label for a new Destination without it", func() {
+			newWork("not-eu-dependency", platformv1alpha1.DependencyReplicas, platformv1alpha1.WorkloadGroupScheduling{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "region", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"eu-west"}}},
+				Source:           "promise",
+			})
+			newWork("eu-resource", platformv1alpha1.ResourceRequestReplicas, platformv1alpha1.WorkloadGroupScheduling{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"eu-west"}}},
+				Source:           "promise",
+			})
+
+			staging := newDestination("staging", map[string]string{"environment": "staging"})
+			Expect(worksFor(nil, &staging)).To(ConsistOf("any-dependency", "not-eu-dependency"))
+		})
+
+		It("returns the Works selecting a relabelled Destination before or after the change", func() {
+			relabelled := devDestination.DeepCopy()
+			relabelled.Labels = map[string]string{"environment": "prod"}
+			Expect(worksFor(&devDestination, relabelled)).To(ConsistOf("dev-dependency", "prod-dependency", "dev-resource"))
+		})
+
+		It("returns no Works when the change does not affect scheduling", func() {
+			relabelled := devDestination.DeepCopy()
+			relabelled.Labels["team"] = "platform"
+			Expect(worksFor(&devDestination, relabelled)).To(BeEmpty())
+
+			drained := devDestination.DeepCopy()
+			drained.Status.Drain = &platformv1alpha1.DrainStatus{Phase: platformv1alpha1.DrainPhaseDrained}
+			Expect(worksFor(&devDestination, drained)).To(BeEmpty())
+		})
+
+		It("returns the Works selecting a Destination that becomes schedulable", func() {
+			cordoned := devDestination.DeepCopy()
+			cordoned.Spec.Unschedulable = true
+			Expect(worksFor(cordoned, &devDestination)).To(ConsistOf("dev-dependency", "any-dependency", "dev-resource"))
+		})
+
+		It("returns the Works with WorkPlacements on a deleted Destination", func() {
+			workPlacement := &platformv1alpha1.WorkPlacement{}
+			workPlacement.Name = "prod-dependency.dev"
+			workPlacement.Namespace = platformv1alpha1.KratixSystemNamespace
+			workPlacement.Labels = map[string]string{"kratix.io/work": "prod-dependency"}
+			workPlacement.Spec.TargetDestinationName = "dev"
+			Expect(fakeK8sClient.Create(ctx, workPlacement)).To(Succeed())
+
+			Expect(worksFor(&devDestination, nil)).To(ConsistOf("dev-dependency", "prod-dependency", "any-dependency", "dev-resource"))
+		})
+	})
 })
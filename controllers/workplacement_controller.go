@@ -18,39 +18,62 @@ package controllers
 
 import (
 	"context"
-	"path/filepath"
+	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/syntasso/kratix/api/v1alpha1"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
 	"github.com/syntasso/kratix/lib/resourceutil"
+	"github.com/syntasso/kratix/lib/workloadcontent"
 	"github.com/syntasso/kratix/lib/writers"
 )
 
-const (
-	resourcesDir    = "resources"
-	dependenciesDir = "dependencies"
-)
-
 // WorkPlacementReconciler reconciles a WorkPlacement object
 type WorkPlacementReconciler struct {
 	Client client.Client
-	Log    logr.Logger
+	// APIReader reads the ConfigMaps holding offloaded workload content
+	// without caching every ConfigMap in the cluster. Client is used when
+	// unset.
+	APIReader client.Reader
+	Log       logr.Logger
 }
 
 const repoCleanupWorkPlacementFinalizer = "finalizers.workplacement.kratix.io/repo-cleanup"
 
+// workloadsWrittenConditionType is True once the workloads of the current
+// generation of the WorkPlacement are written to the State Store
+const workloadsWrittenConditionType = "WorkloadsWritten"
+
 var workPlacementFinalizers = []string{repoCleanupWorkPlacementFinalizer}
 
+// DestinationChangedPredicate selects the Destination changes its
+// WorkPlacements are rewritten for: its spec, holding the PathTemplate, and
+// its labels and annotations, which workload templates and PathTemplates may
+// use
+var DestinationChangedPredicate = predicate.Or(
+	predicate.GenerationChangedPredicate{},
+	predicate.LabelChangedPredicate{},
+	predicate.AnnotationChangedPredicate{},
+)
+
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=workplacements,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=workplacements/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=platform.kratix.io,resources=workplacements/finalizers,verbs=update
+//+kubebuilder:rbac:groups=platform.kratix.io,resources=works,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the destination closer to the desired state.
@@ -75,6 +98,9 @@ func (r *WorkPlacementReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 	err = r.Client.Get(context.Background(), destinationName, destination)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.reconcileMissingDestination(ctx, workPlacement, logger)
+		}
 		logger.Error(err, "Error listing available destinations")
 		return ctrl.Result{}, err
 	}
@@ -85,7 +111,7 @@ func (r *WorkPlacementReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		logger: logger,
 	}
 
-	writer, err := newWriter(opts, *destination)
+	writer, layout, err := newWriter(opts, *destination)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return defaultRequeue, nil
@@ -94,29 +120,123 @@ func (r *WorkPlacementReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	if !workPlacement.DeletionTimestamp.IsZero() {
-		return r.deleteWorkPlacement(ctx, writer, workPlacement, logger)
+		return r.deleteWorkPlacement(ctx, writer, layout, workPlacement, *destination, logger)
 	}
 
 	if resourceutil.FinalizersAreMissing(workPlacement, workPlacementFinalizers) {
 		return addFinalizers(opts, workPlacement, workPlacementFinalizers)
 	}
 
-	err = r.writeWorkloadsToStateStore(writer, *workPlacement, logger)
+	pending, err := r.earlierWavePending(ctx, workPlacement)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending != nil {
+		logger.Info("Waiting for the workloads of earlier waves to be written", "wave", workPlacement.Spec.Wave, "pendingWorkPlacement", pending.GetName(), "pendingWave", pending.Spec.Wave)
+		condition := metav1.Condition{
+			Type:               workloadsWrittenConditionType,
+			Status:             metav1.ConditionFalse,
+			Reason:             "WaitingForEarlierWaves",
+			Message:            fmt.Sprintf("Waiting for WorkPlacement %s of wave %d to be written", pending.GetName(), pending.Spec.Wave),
+			ObservedGeneration: workPlacement.GetGeneration(),
+		}
+		if err := r.updateWorkloadsWrittenCondition(ctx, workPlacement, condition); err != nil {
+			logger.Error(err, "Error updating WorkPlacement status")
+		}
+		return defaultRequeue, nil
+	}
+
+	workloads, err := r.getWorkloads(ctx, workPlacement)
+	if err != nil {
+		logger.Info("Workloads not available, will try again in 5 seconds", "reason", err.Error())
+		return defaultRequeue, nil
+	}
+
+	dir, err := r.writeWorkloadsToStateStore(writer, layout, *workPlacement, *destination, workloads, r.renderedTemplateProcessor(ctx, workPlacement), logger)
 	if err != nil {
 		logger.Error(err, "Error writing to repository, will try again in 5 seconds")
+		if statusErr := r.setWorkloadsWrittenCondition(ctx, workPlacement, err); statusErr != nil {
+			logger.Error(statusErr, "Error updating WorkPlacement status")
+		}
+		return defaultRequeue, err
+	}
+
+	if workPlacement.Status.Path != dir {
+		workPlacement.Status.Path = dir
+		if err := r.Client.Status().Update(ctx, workPlacement); err != nil {
+			return defaultRequeue, err
+		}
+	}
+
+	if err := r.setWorkloadsWrittenCondition(ctx, workPlacement, nil); err != nil {
 		return defaultRequeue, err
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *WorkPlacementReconciler) deleteWorkPlacement(ctx context.Context, writer writers.StateStoreWriter, workPlacement *platformv1alpha1.WorkPlacement, logger logr.Logger) (ctrl.Result, error) {
+func (r *WorkPlacementReconciler) setWorkloadsWrittenCondition(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement, writeErr error) error {
+	condition := metav1.Condition{
+		Type:               workloadsWrittenConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             "WorkloadsWrittenToStateStore",
+		Message:            "Workloads written to the State Store",
+		ObservedGeneration: workPlacement.GetGeneration(),
+	}
+	if writeErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "StateStoreWriteFailed"
+		condition.Message = writeErr.Error()
+	}
+	return r.updateWorkloadsWrittenCondition(ctx, workPlacement, condition)
+}
+
+func (r *WorkPlacementReconciler) updateWorkloadsWrittenCondition(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement, condition metav1.Condition) error {
+	existing := meta.FindStatusCondition(workPlacement.Status.Conditions, workloadsWrittenConditionType)
+	if existing != nil && existing.Status == condition.Status && existing.ObservedGeneration == condition.ObservedGeneration && existing.Message == condition.Message {
+		return nil
+	}
+
+	meta.SetStatusCondition(&workPlacement.Status.Conditions, condition)
+	return r.Client.Status().Update(ctx, workPlacement)
+}
+
+// reconcileMissingDestination handles WorkPlacements whose Destination no
+// longer exists, e.g. because it was deleted before it had a finalizer. There
+// is no State Store to clean up, so deletion goes ahead; otherwise the
+// WorkPlacement waits for the Destination to come back or for the Scheduler
+// to replace it.
+func (r *WorkPlacementReconciler) reconcileMissingDestination(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement, logger logr.Logger) (ctrl.Result, error) {
+	if workPlacement.DeletionTimestamp.IsZero() {
+		logger.Info("Destination not found, will try again in 15 seconds", "destination", workPlacement.Spec.TargetDestinationName)
+		return slowRequeue, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(workPlacement, repoCleanupWorkPlacementFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Destination not found, skipping repository cleanup", "destination", workPlacement.Spec.TargetDestinationName)
+	controllerutil.RemoveFinalizer(workPlacement, repoCleanupWorkPlacementFinalizer)
+	return ctrl.Result{}, r.Client.Update(ctx, workPlacement)
+}
+
+func (r *WorkPlacementReconciler) deleteWorkPlacement(ctx context.Context, writer writers.StateStoreWriter, layout writers.Layout, workPlacement *platformv1alpha1.WorkPlacement, destination platformv1alpha1.Destination, logger logr.Logger) (ctrl.Result, error) {
 	if !controllerutil.ContainsFinalizer(workPlacement, repoCleanupWorkPlacementFinalizer) {
 		return ctrl.Result{}, nil
 	}
 
-	logger.Info("cleaning up files on repository", "repository", workPlacement.Name)
-	err := r.removeWorkFromRepository(writer, *workPlacement, logger)
+	dir, err := writtenDir(*workPlacement, destination)
+	if err == nil && dir == "" {
+		dir, err = getDir(layout, *workPlacement)
+	}
+	if err != nil {
+		logger.Error(err, "error getting the directory of the workloads")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("cleaning up files on repository", "repository", workPlacement.Name, "dir", dir)
+	err = r.removeWorkFromRepository(writer, dir, logger)
 	if err != nil {
 		logger.Error(err, "error removing work from repository, will try again in 5 seconds")
 		return defaultRequeue, err
@@ -130,19 +250,88 @@ func (r *WorkPlacementReconciler) deleteWorkPlacement(ctx context.Context, write
 	return fastRequeue, nil
 }
 
-func (r *WorkPlacementReconciler) writeWorkloadsToStateStore(writer writers.StateStoreWriter, workPlacement v1alpha1.WorkPlacement, logger logr.Logger) error {
-	err := writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, getDir(workPlacement), workPlacement.Spec.Workloads...)
+// writeWorkloadsToStateStore writes the workloads to the directory the
+// layout decides, and returns it. When the layout changed since the workloads
+// were last written, they are moved: the directory they were written to is
+// removed once they are written to the new one.
+func (r *WorkPlacementReconciler) writeWorkloadsToStateStore(writer writers.StateStoreWriter, layout writers.Layout, workPlacement v1alpha1.WorkPlacement, destination v1alpha1.Destination, workloads []v1alpha1.Workload, processTemplate func(*v1alpha1.Workload) error, logger logr.Logger) (string, error) {
+	dir, err := getDir(layout, workPlacement)
+	if err != nil {
+		logger.Error(err, "Error getting the directory of the workloads")
+		return "", err
+	}
+
+	workloads, err = workloadcontent.Render(workloads, destination, processTemplate)
+	if err != nil {
+		logger.Error(err, "Error rendering workload templates")
+		return "", err
+	}
+
+	err = writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, dir, workloads...)
 	if err != nil {
 		logger.Error(err, "Error writing resources to repository")
-		return err
+		return "", err
 	}
 
-	return nil
+	previousDir, err := writtenDir(workPlacement, destination)
+	if err != nil || previousDir == "" || previousDir == dir {
+		return dir, err
+	}
+	// removing a directory would remove the workloads just written within it
+	if strings.HasPrefix(dir, previousDir+"/") || strings.HasPrefix(previousDir, dir+"/") {
+		logger.Info("Not removing the previous directory of the workloads, as it overlaps the new one", "previousDir", previousDir, "dir", dir)
+		return dir, nil
+	}
+
+	logger.Info("Moved workloads to a new directory, removing the previous one", "previousDir", previousDir, "dir", dir)
+	if err := r.removeWorkFromRepository(writer, previousDir, logger); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// renderedTemplateProcessor returns the checks the work-creator makes on the
+// pipeline output, for the templates it could not check before they were
+// rendered: resource workloads must meet the WorkloadPolicy of the Promise,
+// and are stamped when the Promise sets StampWorkloads. The Promise and Work
+// are only read once a template is rendered.
+func (r *WorkPlacementReconciler) renderedTemplateProcessor(ctx context.Context, workPlacement *v1alpha1.WorkPlacement) func(*v1alpha1.Workload) error {
+	var promise *v1alpha1.Promise
+	var pipelineRunName string
+	return func(workload *v1alpha1.Workload) error {
+		if promise == nil {
+			p := &v1alpha1.Promise{}
+			if err := r.Client.Get(ctx, client.ObjectKey{Name: workPlacement.Spec.PromiseName}, p); err != nil {
+				return fmt.Errorf("failed to get Promise %s to check template %s: %w", workPlacement.Spec.PromiseName, workload.Filepath, err)
+			}
+			if p.Spec.StampWorkloads && workPlacement.Spec.WorkName != "" {
+				work := &v1alpha1.Work{}
+				key := client.ObjectKey{Namespace: workPlacement.GetNamespace(), Name: workPlacement.Spec.WorkName}
+				if err := r.Client.Get(ctx, key, work); err != nil {
+					return fmt.Errorf("failed to get Work %s to stamp template %s: %w", workPlacement.Spec.WorkName, workload.Filepath, err)
+				}
+				pipelineRunName = work.GetAnnotations()[workloadcontent.StampPipelineRunKey]
+			}
+			promise = p
+		}
+
+		if workPlacement.Spec.ResourceName != "" && workloadcontent.IsManifest(*workload) {
+			if problems := workloadcontent.CheckPolicy(*workload, promise.Spec.WorkloadPolicy); len(problems) > 0 {
+				return fmt.Errorf("invalid workloads in template %s: %s", workload.Filepath, strings.Join(problems, "; "))
+			}
+		}
+
+		if !promise.Spec.StampWorkloads {
+			return nil
+		}
+		stamp := workloadcontent.NewOwnershipStamp(workPlacement.Spec.PromiseName, workPlacement.Spec.ResourceName, workPlacement.GetNamespace(), pipelineRunName)
+		return stamp.Apply(workload)
+	}
 }
 
-func (r *WorkPlacementReconciler) removeWorkFromRepository(writer writers.StateStoreWriter, workPlacement v1alpha1.WorkPlacement, logger logr.Logger) error {
+func (r *WorkPlacementReconciler) removeWorkFromRepository(writer writers.StateStoreWriter, dir string, logger logr.Logger) error {
 	//MinIO needs a trailing slash to delete a directory
-	dir := getDir(workPlacement) + "/"
+	dir = dir + "/"
 	if err := writer.RemoveObject(dir); err != nil {
 		logger.Error(err, "Error removing workloads from repository", "dir", dir)
 		return err
@@ -150,24 +339,110 @@ func (r *WorkPlacementReconciler) removeWorkFromRepository(writer writers.StateS
 	return nil
 }
 
-func getDir(workPlacement v1alpha1.WorkPlacement) string {
-	if workPlacement.Spec.ResourceName == "" {
-		//dependencies/<promise-name>/<dir-sha>/
-		return filepath.Join(dependenciesDir, workPlacement.Spec.PromiseName, shortID(workPlacement.Spec.ID))
-	} else {
-		//resources/<rr-namespace>/<promise-name>/<rr-name>/<dir-sha>/
-		return filepath.Join(resourcesDir, workPlacement.GetNamespace(), workPlacement.Spec.PromiseName, workPlacement.Spec.ResourceName, shortID(workPlacement.Spec.ID))
+func getDir(layout writers.Layout, workPlacement v1alpha1.WorkPlacement) (string, error) {
+	data := writers.PathData{
+		Type:        writers.DependenciesType,
+		PromiseName: workPlacement.Spec.PromiseName,
+		ID:          shortID(workPlacement.Spec.ID),
+	}
+	if workPlacement.Spec.ResourceName != "" {
+		data.Type = writers.ResourcesType
+		data.ResourceNamespace = workPlacement.GetNamespace()
+		data.ResourceName = workPlacement.Spec.ResourceName
+	}
+	return layout.Dir(data)
+}
+
+// writtenDir returns the directory the workloads of the WorkPlacement were
+// last written to, or an empty string when they were never written.
+// WorkPlacements written before the directory was recorded in their status
+// were written with the default layout.
+func writtenDir(workPlacement v1alpha1.WorkPlacement, destination v1alpha1.Destination) (string, error) {
+	if workPlacement.Status.Path != "" {
+		return workPlacement.Status.Path, nil
 	}
+	if meta.FindStatusCondition(workPlacement.Status.Conditions, workloadsWrittenConditionType) == nil {
+		return "", nil
+	}
+	return getDir(writers.DefaultLayout(destination), workPlacement)
 }
 
-func (r *WorkPlacementReconciler) getWork(workName, workNamespace string, logger logr.Logger) *platformv1alpha1.Work {
+// getWorkloads returns the workloads the WorkPlacement references in its
+// Work. It errors until the Work holds the version of the workloads named by
+// the WorkloadsHash, as the Scheduler updates the hash after the Work changes.
+func (r *WorkPlacementReconciler) getWorkloads(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement) ([]platformv1alpha1.Workload, error) {
+	if workPlacement.Spec.WorkName == "" {
+		return workloadcontent.Decode(ctx, r.apiReader(), workPlacement.GetNamespace(), workPlacement.Spec.Workloads)
+	}
+
 	work := &platformv1alpha1.Work{}
 	namespaceName := types.NamespacedName{
-		Namespace: workNamespace,
-		Name:      workName,
+		Namespace: workPlacement.GetNamespace(),
+		Name:      workPlacement.Spec.WorkName,
+	}
+	if err := r.Client.Get(ctx, namespaceName, work); err != nil {
+		return nil, err
+	}
+
+	for _, workloadGroup := range work.Spec.WorkloadGroups {
+		if workloadGroup.ID != workPlacement.Spec.ID {
+			continue
+		}
+		if hash := workloadGroup.WorkloadsHash(); hash != workPlacement.Spec.WorkloadsHash {
+			return nil, fmt.Errorf("workloads of WorkloadGroup %s in Work %s have hash %s, expected %s", workloadGroup.ID, work.GetName(), hash, workPlacement.Spec.WorkloadsHash)
+		}
+		return workloadcontent.Decode(ctx, r.apiReader(), work.GetNamespace(), workloadGroup.Workloads)
+	}
+	return nil, fmt.Errorf("WorkloadGroup %s not found in Work %s", workPlacement.Spec.ID, work.GetName())
+}
+
+// earlierWavePending returns a WorkPlacement on the same Destination whose
+// workloads must be written first but are not yet, or nil when there is none.
+// Those are the WorkPlacements of the same Work in a lower wave and, for
+// resource requests, the WorkPlacements of the Promise dependencies up to the
+// same wave, as the dependencies usually install what the resources use.
+func (r *WorkPlacementReconciler) earlierWavePending(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement) (*platformv1alpha1.WorkPlacement, error) {
+	if workName := workPlacement.GetLabels()[workLabelKey]; workName != "" && workPlacement.Spec.Wave > 0 {
+		pending, err := r.unwrittenWorkPlacement(ctx, workPlacement, workPlacement.GetNamespace(), workName, workPlacement.Spec.Wave-1)
+		if err != nil || pending != nil {
+			return pending, err
+		}
 	}
-	r.Client.Get(context.Background(), namespaceName, work)
-	return work
+
+	if workPlacement.Spec.ResourceName == "" || workPlacement.Spec.PromiseName == "" {
+		return nil, nil
+	}
+	return r.unwrittenWorkPlacement(ctx, workPlacement, platformv1alpha1.KratixSystemNamespace, workPlacement.Spec.PromiseName, workPlacement.Spec.Wave)
+}
+
+// unwrittenWorkPlacement returns a WorkPlacement of the Work, on the
+// Destination of the workPlacement and in a wave up to maxWave, whose
+// workloads are not written
+func (r *WorkPlacementReconciler) unwrittenWorkPlacement(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement, namespace, workName string, maxWave int) (*platformv1alpha1.WorkPlacement, error) {
+	workPlacements := &platformv1alpha1.WorkPlacementList{}
+	if err := r.Client.List(ctx, workPlacements, client.InNamespace(namespace), client.MatchingFields{WorkPlacementWorkField: workName}); err != nil {
+		return nil, err
+	}
+
+	for i := range workPlacements.Items {
+		other := &workPlacements.Items[i]
+		if other.Spec.TargetDestinationName != workPlacement.Spec.TargetDestinationName ||
+			other.Spec.Wave > maxWave ||
+			!other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if !workloadsWritten(*other) {
+			return other, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *WorkPlacementReconciler) apiReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
 }
 
 func (r *WorkPlacementReconciler) addFinalizer(ctx context.Context, workPlacement *platformv1alpha1.WorkPlacement, logger logr.Logger) (ctrl.Result, error) {
@@ -179,9 +454,90 @@ func (r *WorkPlacementReconciler) addFinalizer(ctx context.Context, workPlacemen
 	return ctrl.Result{}, nil
 }
 
+// workPlacementsForWork returns the WorkPlacements of the Work, so that they
+// pick up new workloads as soon as the Work holds them
+func (r *WorkPlacementReconciler) workPlacementsForWork(ctx context.Context, obj client.Object) []reconcile.Request {
+	workPlacements := &platformv1alpha1.WorkPlacementList{}
+	if err := r.Client.List(ctx, workPlacements, client.InNamespace(obj.GetNamespace()), client.MatchingFields{WorkPlacementWorkField: obj.GetName()}); err != nil {
+		r.Log.Error(err, "Error listing WorkPlacements for Work", "work", obj.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range workPlacements.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&workPlacements.Items[i])})
+	}
+	return requests
+}
+
+// workPlacementsForDestination returns the WorkPlacements scheduled to the
+// Destination, so that they move or re-render their workloads as soon as it
+// changes
+func (r *WorkPlacementReconciler) workPlacementsForDestination(ctx context.Context, obj client.Object) []reconcile.Request {
+	workPlacements := &platformv1alpha1.WorkPlacementList{}
+	if err := r.Client.List(ctx, workPlacements, client.MatchingFields{WorkPlacementTargetDestinationField: obj.GetName()}); err != nil {
+		r.Log.Error(err, "Error listing WorkPlacements for Destination", "destination", obj.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range workPlacements.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&workPlacements.Items[i])})
+	}
+	return requests
+}
+
+// workPlacementsForStateStore returns the map function enqueueing the
+// WorkPlacements of the Destinations using a State Store of the kind, so
+// that they move their workloads as soon as its PathTemplate changes
+func (r *WorkPlacementReconciler) workPlacementsForStateStore(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		destinations := &platformv1alpha1.DestinationList{}
+		if err := r.Client.List(ctx, destinations); err != nil {
+			r.Log.Error(err, "Error listing Destinations for State Store", "kind", kind, "stateStore", obj.GetName())
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for i := range destinations.Items {
+			stateStoreRef := destinations.Items[i].Spec.StateStoreRef
+			if stateStoreRef == nil || stateStoreRef.Kind != kind || stateStoreRef.Name != obj.GetName() {
+				continue
+			}
+			requests = append(requests, r.workPlacementsForDestination(ctx, &destinations.Items[i])...)
+		}
+		return requests
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *WorkPlacementReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&platformv1alpha1.WorkPlacement{}).
+		Watches(
+			&platformv1alpha1.Work{},
+			handler.EnqueueRequestsFromMapFunc(r.workPlacementsForWork),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&platformv1alpha1.Destination{},
+			handler.EnqueueRequestsFromMapFunc(r.workPlacementsForDestination),
+			builder.WithPredicates(DestinationChangedPredicate),
+		).
+		Watches(
+			&platformv1alpha1.GitStateStore{},
+			handler.EnqueueRequestsFromMapFunc(r.workPlacementsForStateStore("GitStateStore")),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&platformv1alpha1.BucketStateStore{},
+			handler.EnqueueRequestsFromMapFunc(r.workPlacementsForStateStore("BucketStateStore")),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&platformv1alpha1.KubernetesStateStore{},
+			handler.EnqueueRequestsFromMapFunc(r.workPlacementsForStateStore("KubernetesStateStore")),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
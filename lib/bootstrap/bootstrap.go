@@ -0,0 +1,252 @@
+// Package bootstrap generates the manifests that point the GitOps agent of a
+// Destination at the directories Kratix writes its workloads to.
+package bootstrap
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/writers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+type Format string
+
+const (
+	FormatFlux   Format = "flux"
+	FormatArgoCD Format = "argocd"
+
+	DefaultFluxNamespace   = "flux-system"
+	DefaultArgoCDNamespace = "argocd"
+)
+
+// Options customise the generated manifests
+type Options struct {
+	// Namespace of the generated objects. Defaults to the namespace the
+	// GitOps agent is usually installed in.
+	Namespace string
+	// SecretName is the Secret, on the Destination, holding the credentials
+	// the GitOps agent reads the State Store with. The Secret is not
+	// generated, as its keys differ from those of the State Store Secret.
+	SecretName string
+}
+
+// Manifests returns the manifests bootstrapping the GitOps agent of the
+// Destination, reading from the State Store it references. The State Store
+// must be a GitStateStore or, for Flux only, a BucketStateStore.
+func Manifests(format Format, destination platformv1alpha1.Destination, stateStore client.Object, opts Options) ([]*unstructured.Unstructured, error) {
+	switch format {
+	case FormatFlux:
+		if opts.Namespace == "" {
+			opts.Namespace = DefaultFluxNamespace
+		}
+		return fluxManifests(destination, stateStore, opts)
+	case FormatArgoCD:
+		if opts.Namespace == "" {
+			opts.Namespace = DefaultArgoCDNamespace
+		}
+		return argoCDManifests(destination, stateStore, opts)
+	default:
+		return nil, fmt.Errorf("unsupported format %s, must be one of %s, %s", format, FormatFlux, FormatArgoCD)
+	}
+}
+
+// ToYAML joins the manifests into a multi-document YAML
+func ToYAML(manifests []*unstructured.Unstructured) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, manifest := range manifests {
+		document, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(document)
+	}
+	return buf.Bytes(), nil
+}
+
+func fluxManifests(destination platformv1alpha1.Destination, stateStore client.Object, opts Options) ([]*unstructured.Unstructured, error) {
+	name := "kratix-" + destination.GetName()
+
+	var source *unstructured.Unstructured
+	var dirs typeDirs
+	var err error
+	switch store := stateStore.(type) {
+	case *platformv1alpha1.GitStateStore:
+		dirs, err = destinationTypeDirs(store.Spec.StateStoreCoreFields, destination)
+		spec := map[string]interface{}{
+			"interval": "5s",
+			"url":      store.Spec.URL,
+			"ref": map[string]interface{}{
+				"branch": branch(store),
+			},
+		}
+		addSecretRef(spec, opts)
+		source = newObject("source.toolkit.fluxcd.io/v1", "GitRepository", name, opts.Namespace, spec)
+	case *platformv1alpha1.BucketStateStore:
+		dirs, err = destinationTypeDirs(store.Spec.StateStoreCoreFields, destination)
+		provider := "generic"
+		if store.Spec.AuthMethod == "IAM" {
+			provider = "aws"
+		}
+		spec := map[string]interface{}{
+			"interval":   "5s",
+			"provider":   provider,
+			"bucketName": store.Spec.BucketName,
+			"endpoint":   store.Spec.Endpoint,
+			"insecure":   store.Spec.Insecure,
+		}
+		addSecretRef(spec, opts)
+		source = newObject("source.toolkit.fluxcd.io/v1beta2", "Bucket", name, opts.Namespace, spec)
+	default:
+		return nil, unsupportedStateStore(stateStore)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sourceRef := map[string]interface{}{
+		"kind": source.GetKind(),
+		"name": source.GetName(),
+	}
+	dependencies := newObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", name+"-"+writers.DependenciesType, opts.Namespace, map[string]interface{}{
+		"interval":  "8s",
+		"prune":     true,
+		"sourceRef": sourceRef,
+		"path":      "./" + dirs.dependencies,
+	})
+	resources := newObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", name+"-"+writers.ResourcesType, opts.Namespace, map[string]interface{}{
+		"interval": "3s",
+		"prune":    true,
+		"dependsOn": []interface{}{
+			map[string]interface{}{"name": dependencies.GetName()},
+		},
+		"sourceRef": sourceRef,
+		"path":      "./" + dirs.resources,
+	})
+
+	return []*unstructured.Unstructured{source, dependencies, resources}, nil
+}
+
+func argoCDManifests(destination platformv1alpha1.Destination, stateStore client.Object, opts Options) ([]*unstructured.Unstructured, error) {
+	store, ok := stateStore.(*platformv1alpha1.GitStateStore)
+	if !ok {
+		return nil, unsupportedStateStore(stateStore)
+	}
+	dirs, err := destinationTypeDirs(store.Spec.StateStoreCoreFields, destination)
+	if err != nil {
+		return nil, err
+	}
+	name := "kratix-" + destination.GetName()
+
+	// Argo CD has no dependsOn, so the sync waves of the Applications apply
+	// the dependencies first when both are synced by an app of apps
+	application := func(workloadType, dir, wave string) *unstructured.Unstructured {
+		app := newObject("argoproj.io/v1alpha1", "Application", name+"-"+workloadType, opts.Namespace, map[string]interface{}{
+			"project": "default",
+			"source": map[string]interface{}{
+				"repoURL":        store.Spec.URL,
+				"targetRevision": branch(store),
+				"path":           dir,
+				"directory": map[string]interface{}{
+					"recurse": true,
+				},
+			},
+			"destination": map[string]interface{}{
+				"server": "https://kubernetes.default.svc",
+			},
+			"syncPolicy": map[string]interface{}{
+				"automated": map[string]interface{}{
+					"prune":    true,
+					"selfHeal": true,
+				},
+			},
+		})
+		app.SetAnnotations(map[string]string{"argocd.argoproj.io/sync-wave": wave})
+		return app
+	}
+
+	manifests := []*unstructured.Unstructured{
+		application(writers.DependenciesType, dirs.dependencies, "-1"),
+		application(writers.ResourcesType, dirs.resources, "0"),
+	}
+
+	if opts.SecretName != "" {
+		// Argo CD reads repository credentials from labelled Secrets, so the
+		// existing Secret is only labelled and pointed at the repository
+		repository := newObject("v1", "Secret", opts.SecretName, opts.Namespace, nil)
+		repository.SetLabels(map[string]string{"argocd.argoproj.io/secret-type": "repository"})
+		repository.Object["stringData"] = map[string]interface{}{
+			"type": "git",
+			"url":  store.Spec.URL,
+		}
+		manifests = append([]*unstructured.Unstructured{repository}, manifests...)
+	}
+	return manifests, nil
+}
+
+type typeDirs struct {
+	dependencies string
+	resources    string
+}
+
+// destinationTypeDirs returns the directories, from the root of the State
+// Store, Kratix writes the dependencies and resources of the Destination to
+func destinationTypeDirs(stateStore platformv1alpha1.StateStoreCoreFields, destination platformv1alpha1.Destination) (typeDirs, error) {
+	layout, err := writers.NewLayout(stateStore, destination)
+	if err != nil {
+		return typeDirs{}, err
+	}
+	dependencies, err := layout.TypeDir(writers.DependenciesType)
+	if err != nil {
+		return typeDirs{}, err
+	}
+	resources, err := layout.TypeDir(writers.ResourcesType)
+	if err != nil {
+		return typeDirs{}, err
+	}
+	return typeDirs{
+		dependencies: filepath.Join(stateStore.Path, dependencies),
+		resources:    filepath.Join(stateStore.Path, resources),
+	}, nil
+}
+
+func newObject(apiVersion, kind, name, namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetName(name)
+	object.SetNamespace(namespace)
+	if spec != nil {
+		object.Object["spec"] = spec
+	}
+	return object
+}
+
+func addSecretRef(spec map[string]interface{}, opts Options) {
+	if opts.SecretName != "" {
+		spec["secretRef"] = map[string]interface{}{"name": opts.SecretName}
+	}
+}
+
+func branch(store *platformv1alpha1.GitStateStore) string {
+	if store.Spec.Branch == "" {
+		return "main"
+	}
+	return store.Spec.Branch
+}
+
+func unsupportedStateStore(stateStore client.Object) error {
+	switch stateStore.(type) {
+	case *platformv1alpha1.KubernetesStateStore:
+		return fmt.Errorf("KubernetesStateStore %s applies the workloads directly, no GitOps agent to bootstrap", stateStore.GetName())
+	case *platformv1alpha1.BucketStateStore:
+		return fmt.Errorf("Argo CD cannot read from BucketStateStore %s", stateStore.GetName())
+	default:
+		return fmt.Errorf("unsupported State Store %T", stateStore)
+	}
+}
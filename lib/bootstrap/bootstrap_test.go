@@ -0,0 +1,147 @@
+package bootstrap_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/bootstrap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Manifests", func() {
+	var (
+		destination platformv1alpha1.Destination
+		gitStore    *platformv1alpha1.GitStateStore
+		bucketStore *platformv1alpha1.BucketStateStore
+	)
+
+	field := func(object *unstructured.Unstructured, fields ...string) interface{} {
+		value, _, err := unstructured.NestedFieldNoCopy(object.Object, fields...)
+		Expect(err).NotTo(HaveOccurred())
+		return value
+	}
+
+	BeforeEach(func() {
+		destination = platformv1alpha1.Destination{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+			Spec: platformv1alpha1.DestinationSpec{
+				StateStoreCoreFields: platformv1alpha1.StateStoreCoreFields{Path: "dev"},
+			},
+		}
+		gitStore = &platformv1alpha1.GitStateStore{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: platformv1alpha1.GitStateStoreSpec{
+				URL:                  "https://git.example.com/kratix",
+				Branch:               "kratix",
+				StateStoreCoreFields: platformv1alpha1.StateStoreCoreFields{Path: "clusters"},
+			},
+		}
+		bucketStore = &platformv1alpha1.BucketStateStore{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: platformv1alpha1.BucketStateStoreSpec{
+				BucketName: "kratix",
+				Endpoint:   "minio.example.com",
+				Insecure:   true,
+			},
+		}
+	})
+
+	Describe("flux", func() {
+		It("reads the dependencies and resources of the Destination from a GitStateStore", func() {
+			manifests, err := bootstrap.Manifests(bootstrap.FormatFlux, destination, gitStore, bootstrap.Options{SecretName: "git-credentials"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifests).To(HaveLen(3))
+
+			source, dependencies, resources := manifests[0], manifests[1], manifests[2]
+			Expect(source.GetKind()).To(Equal("GitRepository"))
+			Expect(source.GetName()).To(Equal("kratix-worker-1"))
+			Expect(source.GetNamespace()).To(Equal("flux-system"))
+			Expect(field(source, "spec", "url")).To(Equal("https://git.example.com/kratix"))
+			Expect(field(source, "spec", "ref", "branch")).To(Equal("kratix"))
+			Expect(field(source, "spec", "secretRef", "name")).To(Equal("git-credentials"))
+
+			Expect(dependencies.GetKind()).To(Equal("Kustomization"))
+			Expect(dependencies.GetName()).To(Equal("kratix-worker-1-dependencies"))
+			Expect(field(dependencies, "spec", "path")).To(Equal("./clusters/dev/worker-1/dependencies"))
+			Expect(field(dependencies, "spec", "sourceRef")).To(Equal(map[string]interface{}{"kind": "GitRepository", "name": "kratix-worker-1"}))
+
+			Expect(resources.GetName()).To(Equal("kratix-worker-1-resources"))
+			Expect(field(resources, "spec", "path")).To(Equal("./clusters/dev/worker-1/resources"))
+			Expect(field(resources, "spec", "dependsOn")).To(Equal([]interface{}{map[string]interface{}{"name": "kratix-worker-1-dependencies"}}))
+		})
+
+		It("reads from a BucketStateStore", func() {
+			manifests, err := bootstrap.Manifests(bootstrap.FormatFlux, destination, bucketStore, bootstrap.Options{Namespace: "gitops"})
+			Expect(err).NotTo(HaveOccurred())
+
+			source := manifests[0]
+			Expect(source.GetKind()).To(Equal("Bucket"))
+			Expect(source.GetNamespace()).To(Equal("gitops"))
+			Expect(field(source, "spec", "bucketName")).To(Equal("kratix"))
+			Expect(field(source, "spec", "endpoint")).To(Equal("minio.example.com"))
+			Expect(field(source, "spec", "provider")).To(Equal("generic"))
+			Expect(field(source, "spec", "secretRef")).To(BeNil())
+			Expect(field(manifests[1], "spec", "path")).To(Equal("./dev/worker-1/dependencies"))
+		})
+	})
+
+	Describe("argocd", func() {
+		It("generates an Application for the dependencies and resources of the Destination", func() {
+			manifests, err := bootstrap.Manifests(bootstrap.FormatArgoCD, destination, gitStore, bootstrap.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifests).To(HaveLen(2))
+
+			dependencies, resources := manifests[0], manifests[1]
+			Expect(dependencies.GetKind()).To(Equal("Application"))
+			Expect(dependencies.GetNamespace()).To(Equal("argocd"))
+			Expect(dependencies.GetAnnotations()).To(HaveKeyWithValue("argocd.argoproj.io/sync-wave", "-1"))
+			Expect(field(dependencies, "spec", "source", "repoURL")).To(Equal("https://git.example.com/kratix"))
+			Expect(field(dependencies, "spec", "source", "targetRevision")).To(Equal("kratix"))
+			Expect(field(dependencies, "spec", "source", "path")).To(Equal("clusters/dev/worker-1/dependencies"))
+			Expect(field(resources, "spec", "source", "path")).To(Equal("clusters/dev/worker-1/resources"))
+		})
+
+		It("follows the PathTemplate of the State Store", func() {
+			gitStore.Spec.PathTemplate = "{{ .Destination.Name }}/{{ .Type }}/{{ .PromiseName }}/{{ .ResourceNamespace }}-{{ .ResourceName }}-{{ .ID }}"
+			manifests, err := bootstrap.Manifests(bootstrap.FormatArgoCD, destination, gitStore, bootstrap.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(field(manifests[0], "spec", "source", "path")).To(Equal("clusters/worker-1/dependencies"))
+			Expect(field(manifests[1], "spec", "source", "path")).To(Equal("clusters/worker-1/resources"))
+		})
+
+		It("labels the Secret as the credentials of the repository", func() {
+			manifests, err := bootstrap.Manifests(bootstrap.FormatArgoCD, destination, gitStore, bootstrap.Options{SecretName: "git-credentials"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifests).To(HaveLen(3))
+			Expect(manifests[0].GetKind()).To(Equal("Secret"))
+			Expect(manifests[0].GetLabels()).To(HaveKeyWithValue("argocd.argoproj.io/secret-type", "repository"))
+		})
+
+		It("errors for a BucketStateStore", func() {
+			_, err := bootstrap.Manifests(bootstrap.FormatArgoCD, destination, bucketStore, bootstrap.Options{})
+			Expect(err).To(MatchError("Argo CD cannot read from BucketStateStore default"))
+		})
+	})
+
+	It("errors for a KubernetesStateStore", func() {
+		store := &platformv1alpha1.KubernetesStateStore{ObjectMeta: metav1.ObjectMeta{Name: "direct"}}
+		_, err := bootstrap.Manifests(bootstrap.FormatFlux, destination, store, bootstrap.Options{})
+		Expect(err).To(MatchError(ContainSubstring("applies the workloads directly")))
+	})
+
+	It("errors for an unknown format", func() {
+		_, err := bootstrap.Manifests("helm", destination, gitStore, bootstrap.Options{})
+		Expect(err).To(MatchError(ContainSubstring("unsupported format helm")))
+	})
+
+	It("joins the manifests into a multi-document YAML", func() {
+		manifests, err := bootstrap.Manifests(bootstrap.FormatFlux, destination, gitStore, bootstrap.Options{})
+		Expect(err).NotTo(HaveOccurred())
+
+		output, err := bootstrap.ToYAML(manifests)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output)).To(HavePrefix("---\napiVersion: source.toolkit.fluxcd.io/v1\nkind: GitRepository\n"))
+		Expect(string(output)).To(ContainSubstring("---\napiVersion: kustomize.toolkit.fluxcd.io/v1\nkind: Kustomization\n"))
+	})
+})
@@ -18,6 +18,7 @@ func NewPipelineArgs(promiseIdentifier, resourceRequestIdentifier, namespace str
 		"role":                    pipelineID,
 		"role-binding":            pipelineID,
 		"config-map":              "destination-selectors-" + promiseIdentifier,
+		"promise-config":          "promise-config-" + promiseIdentifier,
 		"resource-request-id":     resourceRequestIdentifier,
 		"namespace":               namespace,
 	}
@@ -47,6 +48,16 @@ func (p PipelineArgs) ConfigMapName() string {
 	return p.names["config-map"]
 }
 
+func (p PipelineArgs) PromiseConfigRoleName() string {
+	return p.names["promise-config"]
+}
+
+// PromiseConfigRoleBindingName is unique to the namespace of the pipeline, as
+// pipelines of every namespace are bound to the Role
+func (p PipelineArgs) PromiseConfigRoleBindingName() string {
+	return p.names["promise-config"] + "-" + p.Namespace()
+}
+
 func (p PipelineArgs) ServiceAccountName() string {
 	return p.names["service-account"]
 }
@@ -11,8 +11,10 @@ import (
 	"github.com/syntasso/kratix/lib/hash"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -20,20 +22,45 @@ import (
 
 func NewConfigureResource(
 	rr *unstructured.Unstructured,
-	crdPlural string,
+	crd *apiextensionsv1.CustomResourceDefinition,
 	pipelines []platformv1alpha1.Pipeline,
 	resourceRequestIdentifier,
 	promiseIdentifier string,
 	promiseDestinationSelectors []platformv1alpha1.PromiseScheduling,
 	promiseWorkflowSelectors *platformv1alpha1.WorkloadGroupScheduling,
+	promise *platformv1alpha1.Promise,
+	promiseConfig *PromiseConfigSource,
 	logger logr.Logger,
 ) ([]client.Object, error) {
 
+	var placement *platformv1alpha1.Placement
+	var workloadPolicy *platformv1alpha1.WorkloadPolicy
+	var stampWorkloads bool
+	if promise != nil {
+		placement = promise.Spec.ResourcePlacement
+		workloadPolicy = promise.Spec.WorkloadPolicy
+		stampWorkloads = promise.Spec.StampWorkloads
+	}
+
 	pipelineResources := NewPipelineArgs(promiseIdentifier, resourceRequestIdentifier, rr.GetNamespace())
-	destinationSelectorsConfigMap, err := destinationSelectorsConfigMap(pipelineResources, promiseDestinationSelectors, promiseWorkflowSelectors)
+	destinationSelectorsConfigMap, err := destinationSelectorsConfigMap(pipelineResources, promiseDestinationSelectors, promiseWorkflowSelectors, NewStatusContract(crd), placement, workloadPolicy, stampWorkloads, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if promise != nil {
+		promiseYAML, err := promiseToYAML(promise)
+		if err != nil {
+			return nil, err
+		}
+		destinationSelectorsConfigMap.Data[promiseConfigMapKey] = promiseYAML
+	}
+
+	promiseConfigYAML, err := promiseConfigSourceToYAML(promiseConfig)
 	if err != nil {
 		return nil, err
 	}
+	destinationSelectorsConfigMap.Data[promiseConfigSourceMapKey] = promiseConfigYAML
 
 	pipeline, err := ConfigurePipeline(rr, pipelines, pipelineResources, promiseIdentifier, false, logger)
 	if err != nil {
@@ -42,11 +69,12 @@ func NewConfigureResource(
 
 	resources := []client.Object{
 		serviceAccount(pipelineResources),
-		role(rr, crdPlural, pipelineResources),
+		role(rr, crd.Spec.Names.Plural, pipelineResources),
 		roleBinding((pipelineResources)),
 		destinationSelectorsConfigMap,
-		pipeline,
 	}
+	resources = append(resources, promiseConfigRoles(pipelineResources, promiseConfig)...)
+	resources = append(resources, pipeline)
 
 	return resources, nil
 }
@@ -59,8 +87,13 @@ func NewConfigurePromise(
 	logger logr.Logger,
 ) ([]client.Object, error) {
 
+	promise := &platformv1alpha1.Promise{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructedPromise.Object, promise); err != nil {
+		return nil, err
+	}
+
 	pipelineResources := NewPipelineArgs(promiseIdentifier, "", v1alpha1.KratixSystemNamespace)
-	destinationSelectorsConfigMap, err := destinationSelectorsConfigMap(pipelineResources, promiseDestinationSelectors, nil)
+	destinationSelectorsConfigMap, err := destinationSelectorsConfigMap(pipelineResources, promiseDestinationSelectors, nil, nil, nil, nil, promise.Spec.StampWorkloads, promise.Spec.DependencyGroupScheduling())
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +107,8 @@ func NewConfigurePromise(
 		serviceAccount(pipelineResources),
 		clusterRole(pipelineResources),
 		clusterRoleBinding(pipelineResources),
+		role(unstructedPromise, v1alpha1.PromisePlural, pipelineResources),
+		roleBinding(pipelineResources),
 		destinationSelectorsConfigMap,
 		pipeline,
 	}
@@ -83,8 +118,11 @@ func NewConfigurePromise(
 
 func ConfigurePipeline(obj *unstructured.Unstructured, pipelines []platformv1alpha1.Pipeline, pipelineArgs PipelineArgs, promiseName string, promiseWorkflow bool, logger logr.Logger) (*batchv1.Job, error) {
 	volumes := metadataAndSchedulingVolumes(pipelineArgs.ConfigMapName())
+	if !promiseWorkflow {
+		volumes = append(volumes, promiseConfigVolume(pipelineArgs))
+	}
 
-	initContainers, pipelineVolumes := configurePipelineInitContainers(obj, pipelines, promiseName, promiseWorkflow, logger)
+	initContainers, pipelineVolumes := configurePipelineInitContainers(obj, pipelines, promiseName, pipelineArgs.ConfigurePipelineName(), promiseWorkflow, logger)
 	volumes = append(volumes, pipelineVolumes...)
 
 	objHash, err := hash.ComputeHashForResource(obj)
@@ -110,17 +148,23 @@ func ConfigurePipeline(obj *unstructured.Unstructured, pipelines []platformv1alp
 						{
 							Name:    "status-writer",
 							Image:   os.Getenv("WC_IMG"),
-							Command: []string{"sh", "-c", "update-status"},
+							Command: []string{workCreatorBinary, "update-status"},
 							Env: []v1.EnvVar{
 								{Name: "OBJECT_KIND", Value: strings.ToLower(obj.GetKind())},
 								{Name: "OBJECT_GROUP", Value: obj.GroupVersionKind().Group},
 								{Name: "OBJECT_NAME", Value: obj.GetName()},
 								{Name: "OBJECT_NAMESPACE", Value: pipelineArgs.Namespace()},
 							},
-							VolumeMounts: []v1.VolumeMount{{
-								MountPath: "/work-creator-files/metadata",
-								Name:      "shared-metadata",
-							}},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									MountPath: "/work-creator-files/metadata",
+									Name:      "shared-metadata",
+								},
+								{
+									MountPath: "/work-creator-files/kratix-system",
+									Name:      "status-contract", // this volumemount is a configmap
+								},
+							},
 						},
 					},
 					InitContainers: initContainers,
@@ -137,7 +181,7 @@ func ConfigurePipeline(obj *unstructured.Unstructured, pipelines []platformv1alp
 	return job, nil
 }
 
-func configurePipelineInitContainers(obj *unstructured.Unstructured, pipelines []platformv1alpha1.Pipeline, promiseName string, promiseWorkflow bool, logger logr.Logger) ([]v1.Container, []v1.Volume) {
+func configurePipelineInitContainers(obj *unstructured.Unstructured, pipelines []platformv1alpha1.Pipeline, promiseName, pipelineRunName string, promiseWorkflow bool, logger logr.Logger) ([]v1.Container, []v1.Volume) {
 	volumes, volumeMounts := pipelineVolumes()
 
 	kratixWorkflowType := platformv1alpha1.KratixWorkflowTypeResource
@@ -146,6 +190,16 @@ func configurePipelineInitContainers(obj *unstructured.Unstructured, pipelines [
 	}
 
 	readerContainer := readerContainer(obj, kratixWorkflowType, "shared-input")
+	if !promiseWorkflow {
+		// the reader copies the promise config and the Promise into
+		// /kratix/input, and uses the Promise name to find the previous Work
+		readerContainer.VolumeMounts = append(readerContainer.VolumeMounts, v1.VolumeMount{
+			MountPath: PromiseConfigDirectory,
+			Name:      "promise-config",
+			ReadOnly:  true,
+		})
+		readerContainer.Env = append(readerContainer.Env, v1.EnvVar{Name: kratixPromiseEnvVar, Value: promiseName})
+	}
 	containers := []v1.Container{
 		readerContainer,
 	}
@@ -185,16 +239,19 @@ func configurePipelineInitContainers(obj *unstructured.Unstructured, pipelines [
 		}
 	}
 
-	workCreatorCommand := fmt.Sprintf("./work-creator -input-directory /work-creator-files -promise-name %s -namespace %q", promiseName, obj.GetNamespace())
+	workCreatorArgs := []string{"-input-directory", "/work-creator-files", "-promise-name", promiseName, "-namespace", obj.GetNamespace(), "-pipeline-run-name", pipelineRunName}
 	if promiseWorkflow {
-		workCreatorCommand += fmt.Sprintf(" -workflow-type %s", platformv1alpha1.KratixWorkflowTypePromise)
+		workCreatorArgs = append(workCreatorArgs, "-workflow-type", platformv1alpha1.KratixWorkflowTypePromise)
 	} else {
-		workCreatorCommand += fmt.Sprintf(" -resource-name %s -workflow-type %s", obj.GetName(), platformv1alpha1.KratixWorkflowTypeResource)
+		workCreatorArgs = append(workCreatorArgs, "-resource-name", obj.GetName(), "-workflow-type", platformv1alpha1.KratixWorkflowTypeResource)
 	}
 	writer := v1.Container{
 		Name:    "work-writer",
 		Image:   os.Getenv("WC_IMG"),
-		Command: []string{"sh", "-c", workCreatorCommand},
+		Command: []string{workCreatorBinary},
+		Args:    workCreatorArgs,
+		// the work-writer reports invalid workloads in the status of the object
+		Env: objectEnvVars(obj),
 		VolumeMounts: []v1.VolumeMount{
 			{
 				MountPath: "/work-creator-files/input",
@@ -223,15 +280,49 @@ func metadataAndSchedulingVolumes(configMapName string) []v1.Volume {
 		},
 		{
 			Name: "promise-scheduling",
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: configMapName,
+					},
+					Items: []v1.KeyToPath{
+						{
+							Key:  "destinationSelectors",
+							Path: "promise-scheduling",
+						},
+						{
+							Key:  placementConfigMapKey,
+							Path: PromisePlacementFile,
+						},
+						{
+							Key:  workloadPolicyConfigMapKey,
+							Path: PromiseWorkloadPolicyFile,
+						},
+						{
+							Key:  stampWorkloadsConfigMapKey,
+							Path: PromiseStampWorkloadsFile,
+						},
+						{
+							Key:  dependencyGroupsConfigMapKey,
+							Path: PromiseDependencyGroupsFile,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "status-contract",
 			VolumeSource: v1.VolumeSource{
 				ConfigMap: &v1.ConfigMapVolumeSource{
 					LocalObjectReference: v1.LocalObjectReference{
 						Name: configMapName,
 					},
 					Items: []v1.KeyToPath{{
-						Key:  "destinationSelectors",
-						Path: "promise-scheduling",
+						Key:  statusContractConfigMapKey,
+						Path: StatusContractFile,
 					}},
+					// Promise workflows have no status contract
+					Optional: &[]bool{true}[0],
 				},
 			},
 		},
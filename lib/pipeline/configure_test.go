@@ -6,8 +6,13 @@ import (
 	. "github.com/onsi/gomega"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
 	"github.com/syntasso/kratix/lib/pipeline"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("Configure Pipeline", func() {
@@ -58,6 +63,318 @@ var _ = Describe("Configure Pipeline", func() {
 		})
 	})
 
+	Describe("Kratix containers", func() {
+		It("run the work-creator binary directly", func() {
+			job, err := pipeline.ConfigurePipeline(rr, pipelines, pipelineResources, "test-promise", false, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			initContainers := job.Spec.Template.Spec.InitContainers
+			Expect(initContainers[0].Name).To(Equal("reader"))
+			Expect(initContainers[0].Command).To(Equal([]string{"/bin/work-creator", "reader"}))
+
+			writer := initContainers[len(initContainers)-1]
+			Expect(writer.Name).To(Equal("work-writer"))
+			Expect(writer.Command).To(Equal([]string{"/bin/work-creator"}))
+			Expect(writer.Args).To(Equal([]string{
+				"-input-directory", "/work-creator-files",
+				"-promise-name", "test-promise",
+				"-namespace", "test-namespace",
+				"-pipeline-run-name", job.GetName(),
+				"-resource-name", "test-pod",
+				"-workflow-type", "resource",
+			}))
+			Expect(writer.Env).To(ConsistOf(
+				corev1.EnvVar{Name: "OBJECT_KIND", Value: "pod"},
+				corev1.EnvVar{Name: "OBJECT_GROUP", Value: ""},
+				corev1.EnvVar{Name: "OBJECT_NAME", Value: "test-pod"},
+				corev1.EnvVar{Name: "OBJECT_NAMESPACE", Value: "test-namespace"},
+			))
+
+			Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal("status-writer"))
+			Expect(job.Spec.Template.Spec.Containers[0].Command).To(Equal([]string{"/bin/work-creator", "update-status"}))
+		})
+	})
+
+	Describe("Promise config", func() {
+		It("is mounted into the reader of resource workflows", func() {
+			job, err := pipeline.ConfigurePipeline(rr, pipelines, pipelineResources, "test-promise", false, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "promise-config",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "destination-selectors-test-promise"},
+						Items: []corev1.KeyToPath{
+							{Key: "promiseConfig", Path: "promise-config.yaml"},
+							{Key: "promise", Path: "promise.yaml"},
+						},
+					},
+				},
+			}))
+			reader := job.Spec.Template.Spec.InitContainers[0]
+			Expect(reader.VolumeMounts).To(ContainElement(
+				corev1.VolumeMount{Name: "promise-config", MountPath: "/kratix/promise-config", ReadOnly: true},
+			))
+			Expect(reader.Env).To(ContainElement(corev1.EnvVar{Name: "KRATIX_PROMISE_NAME", Value: "test-promise"}))
+		})
+
+		It("is not mounted for promise workflows", func() {
+			job, err := pipeline.ConfigurePipeline(rr, pipelines, pipelineResources, "test-promise", true, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, volume := range job.Spec.Template.Spec.Volumes {
+				Expect(volume.Name).NotTo(Equal("promise-config"))
+			}
+		})
+
+		It("is rendered, with the Promise, alongside the pipeline", func() {
+			crd := &apiextensionsv1.CustomResourceDefinition{
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "pods"},
+				},
+			}
+			promise := &platformv1alpha1.Promise{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:          "test-promise",
+					ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+				},
+				Spec: platformv1alpha1.PromiseSpec{Config: map[string]string{"region": "eu-west-2"}},
+			}
+			resources, err := pipeline.NewConfigureResource(rr, crd, pipelines, "test-resource-request", "test-promise", nil, nil, promise,
+				&pipeline.PromiseConfigSource{
+					Config: map[string]string{"region": "eu-west-2"},
+					SecretRefs: []platformv1alpha1.PromiseConfigSecretRef{
+						{Name: "registry", SecretRef: corev1.SecretReference{Name: "registry-credentials", Namespace: "kratix-platform-system"}},
+					},
+				}, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			var configMap *corev1.ConfigMap
+			var roles []*rbacv1.Role
+			var roleBindings []*rbacv1.RoleBinding
+			for _, resource := range resources {
+				switch r := resource.(type) {
+				case *corev1.Secret:
+					Fail("the Secret data must not be copied into the namespace of the resource")
+				case *corev1.ConfigMap:
+					configMap = r
+				case *rbacv1.Role:
+					roles = append(roles, r)
+				case *rbacv1.RoleBinding:
+					roleBindings = append(roleBindings, r)
+				}
+			}
+
+			Expect(configMap).NotTo(BeNil())
+			Expect(configMap.Data["promiseConfig"]).To(MatchYAML(`
+config:
+  region: eu-west-2
+secretRefs:
+- name: registry
+  secretRef:
+    name: registry-credentials
+    namespace: kratix-platform-system
+`))
+
+			By("allowing the pipeline to read only the referenced Secrets", func() {
+				Expect(roles).To(HaveLen(2))
+				Expect(roles[1].GetName()).To(Equal("promise-config-test-promise"))
+				Expect(roles[1].GetNamespace()).To(Equal("kratix-platform-system"))
+				Expect(roles[1].Rules).To(ConsistOf(rbacv1.PolicyRule{
+					APIGroups:     []string{""},
+					Resources:     []string{"secrets"},
+					ResourceNames: []string{"registry-credentials"},
+					Verbs:         []string{"get"},
+				}))
+
+				Expect(roleBindings).To(HaveLen(2))
+				Expect(roleBindings[1].GetName()).To(Equal("promise-config-test-promise-test-namespace"))
+				Expect(roleBindings[1].GetNamespace()).To(Equal("kratix-platform-system"))
+				Expect(roleBindings[1].RoleRef.Name).To(Equal("promise-config-test-promise"))
+				Expect(roleBindings[1].Subjects).To(ConsistOf(rbacv1.Subject{
+					Kind:      "ServiceAccount",
+					Namespace: "test-namespace",
+					Name:      "test-promise-resource-pipeline",
+				}))
+			})
+
+			Expect(configMap).NotTo(BeNil())
+			Expect(configMap.Data["promise"]).To(MatchYAML(`
+apiVersion: platform.kratix.io/v1alpha1
+kind: Promise
+metadata:
+  name: test-promise
+  creationTimestamp: null
+spec:
+  config:
+    region: eu-west-2
+  workflows:
+    promise: {}
+    resource: {}
+status: {}
+`))
+		})
+	})
+
+	Describe("Promise placement", func() {
+		var crd *apiextensionsv1.CustomResourceDefinition
+
+		BeforeEach(func() {
+			crd = &apiextensionsv1.CustomResourceDefinition{
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "pods"},
+				},
+			}
+		})
+
+		configMapAndJob := func(resources []client.Object) (*corev1.ConfigMap, *batchv1.Job) {
+			var configMap *corev1.ConfigMap
+			var job *batchv1.Job
+			for _, resource := range resources {
+				switch r := resource.(type) {
+				case *corev1.ConfigMap:
+					configMap = r
+				case *batchv1.Job:
+					job = r
+				}
+			}
+			return configMap, job
+		}
+
+		It("is made available to the work-writer", func() {
+			promise := &platformv1alpha1.Promise{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-promise"},
+				Spec: platformv1alpha1.PromiseSpec{
+					ResourcePlacement: &platformv1alpha1.Placement{
+						Replicas:         3,
+						SpreadConstraint: &platformv1alpha1.SpreadConstraint{TopologyKey: "zone"},
+					},
+				},
+			}
+			resources, err := pipeline.NewConfigureResource(rr, crd, pipelines, "test-resource-request", "test-promise", nil, nil, promise, nil, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap, job := configMapAndJob(resources)
+			Expect(configMap.Data["placement"]).To(MatchYAML(`
+replicas: 3
+spreadConstraint:
+  topologyKey: zone
+`))
+
+			var schedulingVolume *corev1.Volume
+			for i, volume := range job.Spec.Template.Spec.Volumes {
+				if volume.Name == "promise-scheduling" {
+					schedulingVolume = &job.Spec.Template.Spec.Volumes[i]
+				}
+			}
+			Expect(schedulingVolume).NotTo(BeNil())
+			Expect(schedulingVolume.ConfigMap.Items).To(ContainElement(corev1.KeyToPath{Key: "placement", Path: pipeline.PromisePlacementFile}))
+		})
+
+		It("is always set, as the work-writer mounts it", func() {
+			resources, err := pipeline.NewConfigureResource(rr, crd, pipelines, "test-resource-request", "test-promise", nil, nil, nil, nil, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap, _ := configMapAndJob(resources)
+			Expect(configMap.Data).To(HaveKeyWithValue("placement", "{}\n"))
+		})
+
+		It("makes the workload policy of the Promise available to the work-writer", func() {
+			promise := &platformv1alpha1.Promise{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-promise"},
+				Spec: platformv1alpha1.PromiseSpec{
+					WorkloadPolicy: &platformv1alpha1.WorkloadPolicy{
+						AllowedKinds:      []platformv1alpha1.AllowedWorkloadKind{{Group: "apps", Kind: "Deployment"}},
+						AllowedNamespaces: []string{"team-a"},
+					},
+				},
+			}
+			resources, err := pipeline.NewConfigureResource(rr, crd, pipelines, "test-resource-request", "test-promise", nil, nil, promise, nil, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap, job := configMapAndJob(resources)
+			Expect(configMap.Data["workloadPolicy"]).To(MatchYAML(`
+allowedKinds:
+- group: apps
+  kind: Deployment
+allowedNamespaces:
+- team-a
+`))
+
+			var schedulingVolume *corev1.Volume
+			for i, volume := range job.Spec.Template.Spec.Volumes {
+				if volume.Name == "promise-scheduling" {
+					schedulingVolume = &job.Spec.Template.Spec.Volumes[i]
+				}
+			}
+			Expect(schedulingVolume).NotTo(BeNil())
+			Expect(schedulingVolume.ConfigMap.Items).To(ContainElement(corev1.KeyToPath{Key: "workloadPolicy", Path: pipeline.PromiseWorkloadPolicyFile}))
+		})
+
+		It("tells the work-writer whether the Promise stamps workloads", func() {
+			resources, err := pipeline.NewConfigureResource(rr, crd, pipelines, "test-resource-request", "test-promise", nil, nil, nil, nil, logger)
+			Expect(err).NotTo(HaveOccurred())
+			configMap, _ := configMapAndJob(resources)
+			Expect(configMap.Data).To(HaveKeyWithValue("stampWorkloads", "false"))
+
+			promise := &platformv1alpha1.Promise{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-promise"},
+				Spec:       platformv1alpha1.PromiseSpec{StampWorkloads: true},
+			}
+			resources, err = pipeline.NewConfigureResource(rr, crd, pipelines, "test-resource-request", "test-promise", nil, nil, promise, nil, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap, job := configMapAndJob(resources)
+			Expect(configMap.Data).To(HaveKeyWithValue("stampWorkloads", "true"))
+
+			var schedulingVolume *corev1.Volume
+			for i, volume := range job.Spec.Template.Spec.Volumes {
+				if volume.Name == "promise-scheduling" {
+					schedulingVolume = &job.Spec.Template.Spec.Volumes[i]
+				}
+			}
+			Expect(schedulingVolume).NotTo(BeNil())
+			Expect(schedulingVolume.ConfigMap.Items).To(ContainElement(corev1.KeyToPath{Key: "stampWorkloads", Path: pipeline.PromiseStampWorkloadsFile}))
+		})
+
+		It("makes the dependency groups of the Promise available to the work-writer of Promise workflows", func() {
+			promise := &platformv1alpha1.Promise{
+				TypeMeta:   metav1.TypeMeta{Kind: "Promise", APIVersion: "platform.kratix.io/v1alpha1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-promise"},
+				Spec: platformv1alpha1.PromiseSpec{
+					DependencyGroups: []platformv1alpha1.DependencyGroup{
+						{
+							Directory:            "operator/",
+							DestinationSelectors: []platformv1alpha1.PromiseScheduling{{MatchLabels: map[string]string{"tier": "data"}}},
+						},
+					},
+				},
+			}
+			unstructuredPromise, err := promise.ToUnstructured()
+			Expect(err).NotTo(HaveOccurred())
+
+			resources, err := pipeline.NewConfigurePromise(unstructuredPromise, pipelines, "test-promise", nil, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap, job := configMapAndJob(resources)
+			Expect(configMap.Data["dependencyGroups"]).To(MatchYAML(`
+- directory: operator
+  matchLabels:
+    tier: data
+`))
+
+			var schedulingVolume *corev1.Volume
+			for i, volume := range job.Spec.Template.Spec.Volumes {
+				if volume.Name == "promise-scheduling" {
+					schedulingVolume = &job.Spec.Template.Spec.Volumes[i]
+				}
+			}
+			Expect(schedulingVolume).NotTo(BeNil())
+			Expect(schedulingVolume.ConfigMap.Items).To(ContainElement(corev1.KeyToPath{Key: "dependencyGroups", Path: pipeline.PromiseDependencyGroupsFile}))
+		})
+	})
+
 	Describe("optional workflow configs", func() {
 		It("can include args and commands", func() {
 			pipelines[0].Spec.Containers = append(pipelines[0].Spec.Containers, platformv1alpha1.Container{
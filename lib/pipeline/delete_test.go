@@ -94,6 +94,11 @@ var _ = Describe("Delete Pipeline", func() {
 							Resources: []string{"works"},
 							Verbs:     []string{"get", "update", "create", "patch"},
 						},
+						{
+							APIGroups: []string{""},
+							Resources: []string{"configmaps"},
+							Verbs:     []string{"get", "list", "create", "update", "delete"},
+						},
 					},
 				}
 				Expect(role).To(Equal(expectedRole))
@@ -207,8 +212,7 @@ var _ = Describe("Delete Pipeline", func() {
 											}),
 										),
 										"Command": ConsistOf(
-											Equal("sh"),
-											Equal("-c"),
+											Equal("/bin/work-creator"),
 											Equal("reader"),
 										),
 									}),
@@ -283,6 +287,11 @@ var _ = Describe("Delete Pipeline", func() {
 							Resources: []string{"works"},
 							Verbs:     []string{"get", "update", "create", "patch"},
 						},
+						{
+							APIGroups: []string{""},
+							Resources: []string{"configmaps"},
+							Verbs:     []string{"get", "list", "create", "update", "delete"},
+						},
 					},
 				}
 				Expect(role).To(Equal(expectedRole))
@@ -397,8 +406,7 @@ var _ = Describe("Delete Pipeline", func() {
 											}),
 										),
 										"Command": ConsistOf(
-											Equal("sh"),
-											Equal("-c"),
+											Equal("/bin/work-creator"),
 											Equal("reader"),
 										),
 									}),
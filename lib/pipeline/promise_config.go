@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// PromiseConfigFile is the name of the file holding the Promise config,
+	// both in the promise-config volume, as a PromiseConfigSource, and in
+	// /kratix/input, as a PromiseConfig
+	PromiseConfigFile = "promise-config.yaml"
+	// PromiseFile is the name of the file holding the Promise, both in the
+	// promise-config volume and in /kratix/input
+	PromiseFile = "promise.yaml"
+	// PromiseConfigDirectory is where the promise-config volume is mounted in
+	// the reader container
+	PromiseConfigDirectory = "/kratix/promise-config"
+
+	promiseConfigMapKey       = "promise"
+	promiseConfigSourceMapKey = "promiseConfig"
+)
+
+// PromiseConfig is the Promise-level configuration made available to every
+// resource configure pipeline
+type PromiseConfig struct {
+	// Config as declared on the Promise
+	Config map[string]string `json:"config,omitempty"`
+	// Data of the Secrets referenced by the Promise, keyed by the name given
+	// to each reference
+	Secrets map[string]map[string]string `json:"secrets,omitempty"`
+}
+
+// PromiseConfigSource is the Promise config as provided to the reader, which
+// reads the referenced Secrets into the PromiseConfig. The Secret data is
+// never copied into the namespace of the resource.
+type PromiseConfigSource struct {
+	// Config as declared on the Promise
+	Config map[string]string `json:"config,omitempty"`
+	// SecretRefs as declared on the Promise, each with its namespace set
+	SecretRefs []platformv1alpha1.PromiseConfigSecretRef `json:"secretRefs,omitempty"`
+}
+
+// promiseConfigVolume projects the promise config and the Promise into a
+// single volume for the reader
+func promiseConfigVolume(args PipelineArgs) v1.Volume {
+	return v1.Volume{
+		Name: "promise-config",
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: args.ConfigMapName()},
+				Items: []v1.KeyToPath{
+					{Key: promiseConfigSourceMapKey, Path: PromiseConfigFile},
+					{Key: promiseConfigMapKey, Path: PromiseFile},
+				},
+			},
+		},
+	}
+}
+
+func promiseConfigSourceToYAML(promiseConfig *PromiseConfigSource) (string, error) {
+	if promiseConfig == nil {
+		promiseConfig = &PromiseConfigSource{}
+	}
+
+	promiseConfigYAML, err := k8syaml.Marshal(promiseConfig)
+	if err != nil {
+		return "", errors.Wrap(err, "error marshalling promise config to yaml")
+	}
+	return string(promiseConfigYAML), nil
+}
+
+// promiseConfigRoles allow the pipeline to read, from the namespace of each
+// Secret referenced by the Promise config, only the referenced Secrets
+func promiseConfigRoles(args PipelineArgs, promiseConfig *PromiseConfigSource) []client.Object {
+	if promiseConfig == nil {
+		return nil
+	}
+
+	secretNames := map[string][]string{}
+	for _, ref := range promiseConfig.SecretRefs {
+		namespace := ref.SecretRef.Namespace
+		if !slices.Contains(secretNames[namespace], ref.SecretRef.Name) {
+			secretNames[namespace] = append(secretNames[namespace], ref.SecretRef.Name)
+		}
+	}
+
+	namespaces := []string{}
+	for namespace := range secretNames {
+		namespaces = append(namespaces, namespace)
+	}
+	slices.Sort(namespaces)
+
+	objects := []client.Object{}
+	for _, namespace := range namespaces {
+		names := secretNames[namespace]
+		slices.Sort(names)
+		objects = append(objects,
+			&rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      args.PromiseConfigRoleName(),
+					Namespace: namespace,
+					Labels:    args.Labels(),
+				},
+				Rules: []rbacv1.PolicyRule{
+					{
+						APIGroups:     []string{""},
+						Resources:     []string{"secrets"},
+						ResourceNames: names,
+						Verbs:         []string{"get"},
+					},
+				},
+			},
+			&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      args.PromiseConfigRoleBindingName(),
+					Namespace: namespace,
+					Labels:    args.Labels(),
+				},
+				RoleRef: rbacv1.RoleRef{
+					Kind:     "Role",
+					APIGroup: "rbac.authorization.k8s.io",
+					Name:     args.PromiseConfigRoleName(),
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Namespace: args.Namespace(),
+						Name:      args.ServiceAccountName(),
+					},
+				},
+			},
+		)
+	}
+	return objects
+}
+
+// promiseToYAML renders the Promise as made available to resource pipelines,
+// without the fields managed by the API server
+func promiseToYAML(promise *platformv1alpha1.Promise) (string, error) {
+	promise = promise.DeepCopy()
+	promise.SetGroupVersionKind(platformv1alpha1.GroupVersion.WithKind("Promise"))
+	promise.SetManagedFields(nil)
+
+	promiseYAML, err := k8syaml.Marshal(promise)
+	if err != nil {
+		return "", errors.Wrap(err, "error marshalling promise to yaml")
+	}
+	return string(promiseYAML), nil
+}
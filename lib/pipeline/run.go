@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"github.com/syntasso/kratix/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// NewPipelineRun builds the PipelineRun recording the execution of the given
+// pipeline Job. The PipelineRun shares the name and namespace of the Job, and
+// is owned by obj so that it outlives the Job but not the object it ran for.
+func NewPipelineRun(obj *unstructured.Unstructured, job *batchv1.Job, trigger string) (*v1alpha1.PipelineRun, error) {
+	runLabels := map[string]string{}
+	for k, v := range job.GetLabels() {
+		if k == KratixResourceHashLabel {
+			continue
+		}
+		runLabels[k] = v
+	}
+
+	resourceName := ""
+	if runLabels["kratix-workflow-type"] == resourceType {
+		resourceName = obj.GetName()
+	}
+
+	run := &v1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.GetName(),
+			Namespace: job.GetNamespace(),
+			Labels:    runLabels,
+		},
+		Spec: v1alpha1.PipelineRunSpec{
+			PromiseName:    runLabels["kratix-promise-id"],
+			ResourceName:   resourceName,
+			WorkflowType:   runLabels["kratix-workflow-type"],
+			WorkflowAction: runLabels["kratix-workflow-action"],
+			Trigger:        trigger,
+			JobName:        job.GetName(),
+			ResourceHash:   job.GetLabels()[KratixResourceHashLabel],
+		},
+	}
+
+	if err := controllerutil.SetOwnerReference(obj, run, scheme.Scheme); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
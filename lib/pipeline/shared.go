@@ -2,22 +2,47 @@ package pipeline
 
 import (
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/syntasso/kratix/api/v1alpha1"
-	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	k8syaml "sigs.k8s.io/yaml"
 )
 
 const (
 	kratixActionEnvVar  = "KRATIX_WORKFLOW_ACTION"
 	kratixTypeEnvVar    = "KRATIX_WORKFLOW_TYPE"
 	kratixPromiseEnvVar = "KRATIX_PROMISE_NAME"
+	workCreatorBinary   = "/bin/work-creator"
+
+	placementConfigMapKey = "placement"
+	// PromisePlacementFile is the name of the file, within the kratix-system
+	// directory of the work-writer, holding the Placement set on the Promise
+	PromisePlacementFile = "promise-placement"
+
+	workloadPolicyConfigMapKey = "workloadPolicy"
+	// PromiseWorkloadPolicyFile is the name of the file, within the
+	// kratix-system directory of the work-writer, holding the WorkloadPolicy
+	// set on the Promise
+	PromiseWorkloadPolicyFile = "promise-workload-policy"
+
+	stampWorkloadsConfigMapKey = "stampWorkloads"
+	// PromiseStampWorkloadsFile is the name of the file, within the
+	// kratix-system directory of the work-writer, holding whether the Promise
+	// sets StampWorkloads
+	PromiseStampWorkloadsFile = "promise-stamp-workloads"
+
+	dependencyGroupsConfigMapKey = "dependencyGroups"
+	// PromiseDependencyGroupsFile is the name of the file, within the
+	// kratix-system directory of the work-writer, holding the directories and
+	// destination selectors of the DependencyGroups of the Promise
+	PromiseDependencyGroupsFile = "promise-dependency-groups"
 )
 
 func pipelineVolumes() ([]v1.Volume, []v1.VolumeMount) {
@@ -62,10 +87,22 @@ func role(obj *unstructured.Unstructured, objPluralName string, args PipelineArg
 				Resources: []string{"works"},
 				Verbs:     []string{"get", "update", "create", "patch"},
 			},
+			workloadContentPolicyRule(),
 		},
 	}
 }
 
+// workloadContentPolicyRule allows the work-creator to manage the ConfigMaps
+// holding workload content too large to store in the Work. It is only granted
+// through a Role, in the namespace of the Work.
+func workloadContentPolicyRule() rbacv1.PolicyRule {
+	return rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list", "create", "update", "delete"},
+	}
+}
+
 func roleBinding(args PipelineArgs) *rbacv1.RoleBinding {
 	return &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -125,59 +162,102 @@ func clusterRoleBinding(args PipelineArgs) *rbacv1.ClusterRoleBinding {
 	}
 }
 
-func destinationSelectorsConfigMap(resources PipelineArgs, destinationSelectors []v1alpha1.PromiseScheduling, promiseWorkflowSelectors *v1alpha1.WorkloadGroupScheduling) (*v1.ConfigMap, error) {
+func destinationSelectorsConfigMap(resources PipelineArgs, destinationSelectors []v1alpha1.PromiseScheduling, promiseWorkflowSelectors *v1alpha1.WorkloadGroupScheduling, statusContract *StatusContract, placement *v1alpha1.Placement, workloadPolicy *v1alpha1.WorkloadPolicy, stampWorkloads bool, dependencyGroups []v1alpha1.WorkflowDestinationSelectors) (*v1.ConfigMap, error) {
 	workloadGroupScheduling := []v1alpha1.WorkloadGroupScheduling{}
 	for _, scheduling := range destinationSelectors {
-		workloadGroupScheduling = append(workloadGroupScheduling, v1alpha1.WorkloadGroupScheduling{
-			MatchLabels: scheduling.MatchLabels,
-			Source:      "promise",
-		})
+		workloadGroupScheduling = append(workloadGroupScheduling, v1alpha1.NewWorkloadGroupScheduling(scheduling.LabelSelector(), "promise"))
 	}
 
 	if promiseWorkflowSelectors != nil {
 		workloadGroupScheduling = append(workloadGroupScheduling, *promiseWorkflowSelectors)
 	}
 
-	schedulingYAML, err := yaml.Marshal(workloadGroupScheduling)
+	schedulingYAML, err := k8syaml.Marshal(workloadGroupScheduling)
 	if err != nil {
 		return nil, errors.Wrap(err, "error marshalling destinationSelectors to yaml")
 	}
 
+	// the key is always set, as the work-writer mounts it
+	if placement == nil {
+		placement = &v1alpha1.Placement{}
+	}
+	placementYAML, err := k8syaml.Marshal(placement)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling placement to yaml")
+	}
+
+	if workloadPolicy == nil {
+		workloadPolicy = &v1alpha1.WorkloadPolicy{}
+	}
+	workloadPolicyYAML, err := k8syaml.Marshal(workloadPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling workload policy to yaml")
+	}
+
+	if dependencyGroups == nil {
+		dependencyGroups = []v1alpha1.WorkflowDestinationSelectors{}
+	}
+	dependencyGroupsYAML, err := k8syaml.Marshal(dependencyGroups)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling dependency groups to yaml")
+	}
+
+	data := map[string]string{
+		"destinationSelectors":       string(schedulingYAML),
+		placementConfigMapKey:        string(placementYAML),
+		workloadPolicyConfigMapKey:   string(workloadPolicyYAML),
+		stampWorkloadsConfigMapKey:   strconv.FormatBool(stampWorkloads),
+		dependencyGroupsConfigMapKey: string(dependencyGroupsYAML),
+	}
+
+	if statusContract != nil {
+		statusContractYAML, err := k8syaml.Marshal(statusContract)
+		if err != nil {
+			return nil, errors.Wrap(err, "error marshalling status contract to yaml")
+		}
+		data[statusContractConfigMapKey] = string(statusContractYAML)
+	}
+
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      resources.ConfigMapName(),
 			Namespace: resources.Namespace(),
 			Labels:    resources.Labels(),
 		},
-		Data: map[string]string{
-			"destinationSelectors": string(schedulingYAML),
-		},
+		Data: data,
 	}, nil
 }
 
-func readerContainer(obj *unstructured.Unstructured, kratixWorkflowType, volumeName string) v1.Container {
+// objectEnvVars identify the object the pipeline runs for to the work-creator
+// subcommands
+func objectEnvVars(obj *unstructured.Unstructured) []v1.EnvVar {
 	namespace := obj.GetNamespace()
 	if namespace == "" {
 		// if namespace is empty it means its a unnamespaced resource, so providing
-		// any value is valid for kubectl
+		// any value is valid for the reader
 		namespace = v1alpha1.KratixSystemNamespace
 	}
 
+	return []v1.EnvVar{
+		{Name: "OBJECT_KIND", Value: strings.ToLower(obj.GetKind())},
+		{Name: "OBJECT_GROUP", Value: obj.GroupVersionKind().Group},
+		{Name: "OBJECT_NAME", Value: obj.GetName()},
+		{Name: "OBJECT_NAMESPACE", Value: namespace},
+	}
+}
+
+func readerContainer(obj *unstructured.Unstructured, kratixWorkflowType, volumeName string) v1.Container {
 	readerContainer := v1.Container{
 		Name:  "reader",
 		Image: os.Getenv("WC_IMG"),
-		Env: []v1.EnvVar{
-			{Name: "OBJECT_KIND", Value: strings.ToLower(obj.GetKind())},
-			{Name: "OBJECT_GROUP", Value: obj.GroupVersionKind().Group},
-			{Name: "OBJECT_NAME", Value: obj.GetName()},
-			{Name: "OBJECT_NAMESPACE", Value: namespace},
-			{Name: "KRATIX_WORKFLOW_TYPE", Value: kratixWorkflowType},
-		},
+		Env: append(objectEnvVars(obj),
+			v1.EnvVar{Name: "KRATIX_WORKFLOW_TYPE", Value: kratixWorkflowType},
+		),
 		VolumeMounts: []v1.VolumeMount{
 			{MountPath: "/kratix/input", Name: "shared-input"},
 			{MountPath: "/kratix/output", Name: "shared-output"},
 		},
-		Command: []string{"sh", "-c", "reader"},
+		Command: []string{workCreatorBinary, "reader"},
 	}
 	return readerContainer
 }
@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	statusContractConfigMapKey = "statusContract"
+	// StatusContractFile is the name of the file, within the kratix-system
+	// directory of the status-writer, holding the StatusContract
+	StatusContractFile = "status-contract"
+)
+
+// StatusContract describes the status a resource pipeline is allowed to write
+// back to the resource. It is derived from the Promise API and handed to the
+// status-writer alongside the pipeline output.
+type StatusContract struct {
+	// Schema of the resource .status, as declared on the stored version of the
+	// Promise API
+	Schema *apiextensionsv1.JSONSchemaProps `json:"schema,omitempty"`
+	// Printer columns declared on the stored version of the Promise API
+	PrinterColumns []apiextensionsv1.CustomResourceColumnDefinition `json:"printerColumns,omitempty"`
+}
+
+func NewStatusContract(crd *apiextensionsv1.CustomResourceDefinition) *StatusContract {
+	if crd == nil || len(crd.Spec.Versions) == 0 {
+		return nil
+	}
+
+	storedVersion := crd.Spec.Versions[0]
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			storedVersion = version
+			break
+		}
+	}
+
+	contract := &StatusContract{
+		PrinterColumns: storedVersion.AdditionalPrinterColumns,
+	}
+	if storedVersion.Schema != nil && storedVersion.Schema.OpenAPIV3Schema != nil {
+		if status, ok := storedVersion.Schema.OpenAPIV3Schema.Properties["status"]; ok {
+			contract.Schema = &status
+		}
+	}
+	return contract
+}
@@ -55,6 +55,17 @@ func MarkPipelineAsCompleted(logger logr.Logger, obj *unstructured.Unstructured)
 	logger.Info("set conditions", "condition", PipelineCompletedCondition, "value", v1.ConditionTrue)
 }
 
+func MarkPipelineAsFailed(logger logr.Logger, obj *unstructured.Unstructured, reason, message string) {
+	SetCondition(obj, &clusterv1.Condition{
+		Type:               PipelineCompletedCondition,
+		Status:             v1.ConditionFalse,
+		Message:            message,
+		Reason:             reason,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	logger.Info("set conditions", "condition", PipelineCompletedCondition, "value", v1.ConditionFalse, "reason", reason)
+}
+
 func PipelineWithDesiredSpecExists(logger logr.Logger, obj *unstructured.Unstructured, jobs []batchv1.Job) (*batchv1.Job, error) {
 	if len(jobs) == 0 {
 		return nil, nil
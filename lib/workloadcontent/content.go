@@ -0,0 +1,301 @@
+package workloadcontent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// CompressionThreshold is the size of the content above which workloads
+	// are compressed
+	CompressionThreshold = 16 * 1024
+	// InlineLimit is the total size of the content kept in a Work. Above it,
+	// the largest workloads are moved to ConfigMaps until the rest fits.
+	InlineLimit = 512 * 1024
+	// ChunkSize is the size of the content stored in each ConfigMap
+	ChunkSize = 768 * 1024
+
+	workLabelKey            = "kratix.io/work"
+	workloadContentLabelKey = "kratix.io/workload-content"
+	chunkKey                = "content"
+)
+
+// NewWorkload returns the workload for a file of the pipeline output. Content
+// that is not valid UTF-8 is base64 encoded, as the Work can only hold text,
+// and the mode is kept when it is not the DefaultWorkloadFileMode.
+func NewWorkload(filepath string, content []byte, mode os.FileMode) platformv1alpha1.Workload {
+	workload := platformv1alpha1.Workload{
+		Filepath: filepath,
+		Content:  string(content),
+	}
+	if !utf8.Valid(content) {
+		workload.Content = base64.StdEncoding.EncodeToString(content)
+		workload.Encoding = platformv1alpha1.WorkloadEncodingBase64
+	}
+	if mode.Perm() != platformv1alpha1.DefaultWorkloadFileMode {
+		perm := int32(mode.Perm())
+		workload.Mode = &perm
+	}
+	return workload
+}
+
+// Encode compresses the workloads of the Work larger than the
+// CompressionThreshold and, while the content in the Work exceeds the
+// InlineLimit, moves the largest workloads to chunked ConfigMaps in the
+// namespace of the Work. Cleanup must be called once the Work is stored.
+func Encode(ctx context.Context, c client.Client, work *platformv1alpha1.Work) error {
+	inline := 0
+	for i := range work.Spec.WorkloadGroups {
+		for j := range work.Spec.WorkloadGroups[i].Workloads {
+			workload := &work.Spec.WorkloadGroups[i].Workloads[j]
+			if len(workload.Content) > CompressionThreshold {
+				if err := compress(workload); err != nil {
+					return fmt.Errorf("failed to compress workload %s: %w", workload.Filepath, err)
+				}
+			}
+			inline += len(workload.Content)
+		}
+	}
+
+	for inline > InlineLimit {
+		workload := largestInlineWorkload(work)
+		if workload == nil {
+			break
+		}
+		size := len(workload.Content)
+		if err := offload(ctx, c, work, workload); err != nil {
+			return fmt.Errorf("failed to offload workload %s: %w", workload.Filepath, err)
+		}
+		inline -= size
+	}
+	return nil
+}
+
+// Cleanup owns the ConfigMaps the Work references by the Work, so they are
+// garbage collected with it, and deletes those it no longer references
+func Cleanup(ctx context.Context, c client.Client, work *platformv1alpha1.Work) error {
+	referenced := map[string]bool{}
+	for _, workloadGroup := range work.Spec.WorkloadGroups {
+		for _, workload := range workloadGroup.Workloads {
+			if workload.ContentFrom == nil {
+				continue
+			}
+			for _, name := range workload.ContentFrom.ConfigMaps {
+				referenced[name] = true
+			}
+		}
+	}
+
+	configMaps := &v1.ConfigMapList{}
+	if err := c.List(ctx, configMaps, client.InNamespace(work.GetNamespace()), client.MatchingLabels(contentLabels(work))); err != nil {
+		return err
+	}
+
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if !referenced[configMap.GetName()] {
+			if err := c.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+
+		if ownedBy(configMap, work) {
+			continue
+		}
+		if err := controllerutil.SetOwnerReference(work, configMap, c.Scheme()); err != nil {
+			return err
+		}
+		if err := c.Update(ctx, configMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode returns the workloads with their plain content, reading the content
+// of offloaded workloads from the ConfigMaps in the namespace
+func Decode(ctx context.Context, c client.Reader, namespace string, workloads []platformv1alpha1.Workload) ([]platformv1alpha1.Workload, error) {
+	decoded := []platformv1alpha1.Workload{}
+	for _, workload := range workloads {
+		content, err := decode(ctx, c, namespace, workload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode workload %s: %w", workload.Filepath, err)
+		}
+		workload.Content = content
+		workload.Encoding = ""
+		workload.ContentFrom = nil
+		workload.Digest = ""
+		decoded = append(decoded, workload)
+	}
+	return decoded, nil
+}
+
+func decode(ctx context.Context, c client.Reader, namespace string, workload platformv1alpha1.Workload) (string, error) {
+	content := workload.Content
+	if workload.ContentFrom != nil {
+		var builder strings.Builder
+		for _, name := range workload.ContentFrom.ConfigMaps {
+			configMap := &v1.ConfigMap{}
+			if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, configMap); err != nil {
+				return "", fmt.Errorf("failed to get ConfigMap %s: %w", name, err)
+			}
+			builder.WriteString(configMap.Data[chunkKey])
+		}
+		content = builder.String()
+	}
+
+	content, err := decodeContent(content, workload.Encoding)
+	if err != nil {
+		return "", err
+	}
+
+	if workload.Digest != "" && digest(content) != workload.Digest {
+		return "", fmt.Errorf("content does not match digest %s", workload.Digest)
+	}
+	return content, nil
+}
+
+func decodeContent(content string, encoding platformv1alpha1.WorkloadEncoding) (string, error) {
+	switch encoding {
+	case "":
+		return content, nil
+	case platformv1alpha1.WorkloadEncodingBase64:
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return "", err
+		}
+		return string(decoded), nil
+	case platformv1alpha1.WorkloadEncodingGzipBase64:
+		compressed, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return "", err
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return "", err
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			return "", err
+		}
+		return string(decompressed), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+func compress(workload *platformv1alpha1.Workload) error {
+	if workload.Encoding == platformv1alpha1.WorkloadEncodingGzipBase64 || workload.ContentFrom != nil {
+		return nil
+	}
+
+	content, err := decodeContent(workload.Content, workload.Encoding)
+	if err != nil {
+		return err
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	workload.Digest = digest(content)
+	workload.Content = base64.StdEncoding.EncodeToString(compressed.Bytes())
+	workload.Encoding = platformv1alpha1.WorkloadEncodingGzipBase64
+	return nil
+}
+
+func largestInlineWorkload(work *platformv1alpha1.Work) *platformv1alpha1.Workload {
+	var largest *platformv1alpha1.Workload
+	for i := range work.Spec.WorkloadGroups {
+		for j := range work.Spec.WorkloadGroups[i].Workloads {
+			workload := &work.Spec.WorkloadGroups[i].Workloads[j]
+			if workload.ContentFrom != nil || workload.Content == "" {
+				continue
+			}
+			if largest == nil || len(workload.Content) > len(largest.Content) {
+				largest = workload
+			}
+		}
+	}
+	return largest
+}
+
+// offload stores the content of the workload in ConfigMaps named after its
+// digest, so the same content is stored once however often the pipeline runs.
+// The content is compressed first, so chunks never split a multi-byte
+// character.
+func offload(ctx context.Context, c client.Client, work *platformv1alpha1.Work, workload *platformv1alpha1.Workload) error {
+	if err := compress(workload); err != nil {
+		return err
+	}
+
+	names := []string{}
+	content := workload.Content
+	for i := 0; len(content) > 0; i++ {
+		size := ChunkSize
+		if len(content) < size {
+			size = len(content)
+		}
+
+		configMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s-%d", work.GetName(), workload.Digest[:12], i),
+				Namespace: work.GetNamespace(),
+				Labels:    contentLabels(work),
+			},
+			Data: map[string]string{chunkKey: content[:size]},
+		}
+		if err := c.Create(ctx, configMap); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+
+		names = append(names, configMap.GetName())
+		content = content[size:]
+	}
+
+	workload.Content = ""
+	workload.ContentFrom = &platformv1alpha1.WorkloadContentSource{ConfigMaps: names}
+	return nil
+}
+
+func ownedBy(configMap *v1.ConfigMap, work *platformv1alpha1.Work) bool {
+	for _, ownerReference := range configMap.GetOwnerReferences() {
+		if ownerReference.Kind == "Work" && ownerReference.Name == work.GetName() && ownerReference.UID == work.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+func contentLabels(work *platformv1alpha1.Work) map[string]string {
+	return map[string]string{
+		workLabelKey:            work.GetName(),
+		workloadContentLabelKey: "true",
+	}
+}
+
+func digest(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
@@ -0,0 +1,185 @@
+package workloadcontent_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/workloadcontent"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Workload content", func() {
+	var (
+		ctx       context.Context
+		k8sClient client.Client
+		work      *platformv1alpha1.Work
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(platformv1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		work = &platformv1alpha1.Work{
+			ObjectMeta: metav1.ObjectMeta{Name: "promise-resource", Namespace: "default"},
+		}
+	})
+
+	withWorkloads := func(workloads ...platformv1alpha1.Workload) {
+		work.Spec.WorkloadGroups = []platformv1alpha1.WorkloadGroup{{ID: "5058f1af8388633f609cadb75a75dc9d", Workloads: workloads}}
+	}
+
+	decode := func() []platformv1alpha1.Workload {
+		workloads, err := workloadcontent.Decode(ctx, k8sClient, work.GetNamespace(), work.Spec.WorkloadGroups[0].Workloads)
+		Expect(err).NotTo(HaveOccurred())
+		return workloads
+	}
+
+	contentConfigMaps := func() []v1.ConfigMap {
+		configMaps := &v1.ConfigMapList{}
+		Expect(k8sClient.List(ctx, configMaps, client.InNamespace(work.GetNamespace()))).To(Succeed())
+		return configMaps.Items
+	}
+
+	When("the workloads are small", func() {
+		It("leaves the content as it is", func() {
+			workload := platformv1alpha1.Workload{Filepath: "small.yaml", Content: "kind: ConfigMap"}
+			withWorkloads(workload)
+
+			Expect(workloadcontent.Encode(ctx, k8sClient, work)).To(Succeed())
+
+			Expect(work.Spec.WorkloadGroups[0].Workloads).To(ConsistOf(workload))
+			Expect(decode()).To(ConsistOf(workload))
+			Expect(contentConfigMaps()).To(BeEmpty())
+		})
+	})
+
+	When("a workload is above the compression threshold", func() {
+		It("compresses the content in the Work", func() {
+			content := strings.Repeat("kind: ConfigMap\n", workloadcontent.CompressionThreshold)
+			withWorkloads(platformv1alpha1.Workload{Filepath: "large.yaml", Content: content})
+
+			Expect(workloadcontent.Encode(ctx, k8sClient, work)).To(Succeed())
+
+			encoded := work.Spec.WorkloadGroups[0].Workloads[0]
+			Expect(encoded.Encoding).To(Equal(platformv1alpha1.WorkloadEncodingGzipBase64))
+			Expect(encoded.Digest).NotTo(BeEmpty())
+			Expect(len(encoded.Content)).To(BeNumerically("<", len(content)/10))
+			Expect(encoded.ContentFrom).To(BeNil())
+
+			Expect(decode()).To(ConsistOf(platformv1alpha1.Workload{Filepath: "large.yaml", Content: content}))
+			Expect(contentConfigMaps()).To(BeEmpty())
+		})
+	})
+
+	Describe("NewWorkload", func() {
+		It("keeps text content and the default mode as they are", func() {
+			Expect(workloadcontent.NewWorkload("a.yaml", []byte("kind: ConfigMap"), 0644)).To(Equal(
+				platformv1alpha1.Workload{Filepath: "a.yaml", Content: "kind: ConfigMap"},
+			))
+		})
+
+		It("base64 encodes binary content and keeps other modes", func() {
+			mode := int32(0755)
+			Expect(workloadcontent.NewWorkload("bin", []byte{0xff, 0x00}, 0755)).To(Equal(
+				platformv1alpha1.Workload{Filepath: "bin", Content: "/wA=", Encoding: platformv1alpha1.WorkloadEncodingBase64, Mode: &mode},
+			))
+		})
+	})
+
+	When("a binary workload is above the compression threshold", func() {
+		It("compresses the decoded content", func() {
+			content := strings.Repeat("\xff\x00", workloadcontent.CompressionThreshold)
+			withWorkloads(workloadcontent.NewWorkload("bundle", []byte(content), 0644))
+
+			Expect(workloadcontent.Encode(ctx, k8sClient, work)).To(Succeed())
+
+			Expect(work.Spec.WorkloadGroups[0].Workloads[0].Encoding).To(Equal(platformv1alpha1.WorkloadEncodingGzipBase64))
+			Expect(decode()).To(ConsistOf(platformv1alpha1.Workload{Filepath: "bundle", Content: content}))
+		})
+	})
+
+	When("the workloads exceed the inline limit", func() {
+		var content string
+
+		BeforeEach(func() {
+			content = incompressibleContent(2 * workloadcontent.ChunkSize)
+			withWorkloads(
+				platformv1alpha1.Workload{Filepath: "small.yaml", Content: "kind: ConfigMap"},
+				platformv1alpha1.Workload{Filepath: "chart.yaml", Content: content},
+			)
+
+			Expect(workloadcontent.Encode(ctx, k8sClient, work)).To(Succeed())
+		})
+
+		It("offloads the largest workloads to chunked ConfigMaps", func() {
+			workloads := work.Spec.WorkloadGroups[0].Workloads
+			Expect(workloads[0].Content).To(Equal("kind: ConfigMap"))
+			Expect(workloads[1].Content).To(BeEmpty())
+			Expect(workloads[1].ContentFrom).NotTo(BeNil())
+			Expect(len(workloads[1].ContentFrom.ConfigMaps)).To(BeNumerically(">", 1))
+			Expect(contentConfigMaps()).To(HaveLen(len(workloads[1].ContentFrom.ConfigMaps)))
+
+			Expect(decode()).To(ConsistOf(
+				platformv1alpha1.Workload{Filepath: "small.yaml", Content: "kind: ConfigMap"},
+				platformv1alpha1.Workload{Filepath: "chart.yaml", Content: content},
+			))
+		})
+
+		It("errors when the ConfigMaps do not match the digest", func() {
+			configMap := &v1.ConfigMap{}
+			name := work.Spec.WorkloadGroups[0].Workloads[1].ContentFrom.ConfigMaps[1]
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, configMap)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+
+			_, err := workloadcontent.Decode(ctx, k8sClient, work.GetNamespace(), work.Spec.WorkloadGroups[0].Workloads)
+			Expect(err).To(MatchError(ContainSubstring("failed to decode workload chart.yaml")))
+		})
+
+		Describe("Cleanup", func() {
+			BeforeEach(func() {
+				Expect(k8sClient.Create(ctx, work)).To(Succeed())
+			})
+
+			It("makes the Work own the ConfigMaps", func() {
+				Expect(workloadcontent.Cleanup(ctx, k8sClient, work)).To(Succeed())
+
+				for _, configMap := range contentConfigMaps() {
+					Expect(configMap.GetOwnerReferences()).To(ConsistOf(
+						HaveField("Name", "promise-resource"),
+					))
+				}
+			})
+
+			It("deletes the ConfigMaps the Work no longer references", func() {
+				Expect(k8sClient.Create(ctx, &v1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"},
+				})).To(Succeed())
+
+				work.Spec.WorkloadGroups[0].Workloads = work.Spec.WorkloadGroups[0].Workloads[:1]
+				Expect(workloadcontent.Cleanup(ctx, k8sClient, work)).To(Succeed())
+
+				Expect(contentConfigMaps()).To(ConsistOf(HaveField("ObjectMeta.Name", "unrelated")))
+			})
+		})
+	})
+})
+
+func incompressibleContent(size int) string {
+	random := make([]byte, size)
+	_, err := rand.Read(random)
+	Expect(err).NotTo(HaveOccurred())
+	return base64.StdEncoding.EncodeToString(random)[:size]
+}
@@ -0,0 +1,194 @@
+package workloadcontent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	StampPromiseNameKey       = "kratix.io/promise-name"
+	StampResourceNameKey      = "kratix.io/resource-name"
+	StampResourceNamespaceKey = "kratix.io/resource-namespace"
+	StampPipelineRunKey       = "kratix.io/pipeline-run"
+)
+
+// IsManifest returns whether the workload is a decoded YAML or JSON file,
+// which is checked against the WorkloadPolicy and stamped. Templates are not
+// manifests until they are rendered.
+func IsManifest(workload platformv1alpha1.Workload) bool {
+	if workload.Encoding != "" {
+		return false
+	}
+	switch filepath.Ext(workload.Filepath) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// CheckPolicy parses every YAML document in the workload and returns the
+// problems found checking its manifests against the WorkloadPolicy.
+// Documents that are not manifests, such as Helm values or kustomization
+// files, are only allowed when there is no policy.
+func CheckPolicy(workload platformv1alpha1.Workload, policy *platformv1alpha1.WorkloadPolicy) []string {
+	// kustomization files may omit their apiVersion and kind
+	isKustomization := strings.HasPrefix(filepath.Base(workload.Filepath), "kustomization.")
+
+	var problems []string
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(workload.Content), 2048)
+	for document := 1; ; document++ {
+		var content interface{}
+		err := decoder.Decode(&content)
+		if err == io.EOF {
+			return problems
+		}
+		if err != nil {
+			return append(problems, fmt.Sprintf("document %d: malformed YAML: %s", document, err))
+		}
+		if content == nil {
+			continue
+		}
+
+		object, _ := content.(map[string]interface{})
+		manifest := &unstructured.Unstructured{Object: object}
+		if object == nil || manifest.GetAPIVersion() == "" || manifest.GetKind() == "" {
+			if policy != nil && !isKustomization {
+				problems = append(problems, fmt.Sprintf("document %d: apiVersion and kind are required", document))
+			}
+			continue
+		}
+
+		gvk := manifest.GroupVersionKind()
+		namespace := manifest.GetNamespace()
+		if gvk.Group == "" && gvk.Kind == "Namespace" {
+			namespace = manifest.GetName()
+		}
+		if err := policy.Check(gvk, namespace); err != nil {
+			problems = append(problems, fmt.Sprintf("document %d: %s", document, err))
+		}
+	}
+}
+
+// OwnershipStamp holds the labels and annotations added to every manifest
+// when the Promise sets StampWorkloads
+type OwnershipStamp struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+func NewOwnershipStamp(promiseName, resourceName, resourceNamespace, pipelineRunName string) OwnershipStamp {
+	stamp := OwnershipStamp{
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		stamp.Annotations[key] = value
+		// names longer than 63 characters are not valid label values, so are
+		// kept in the annotations only
+		if len(validation.IsValidLabelValue(value)) == 0 {
+			stamp.Labels[key] = value
+		}
+	}
+
+	add(StampPromiseNameKey, promiseName)
+	add(StampResourceNameKey, resourceName)
+	if resourceName != "" {
+		stamp.Annotations[StampResourceNamespaceKey] = resourceNamespace
+	}
+	if pipelineRunName != "" {
+		stamp.Annotations[StampPipelineRunKey] = pipelineRunName
+	}
+	return stamp
+}
+
+// Apply adds the labels and annotations of the stamp to every manifest in
+// the workload. Workloads that are not manifests and documents without a
+// kind, such as kustomization files, are left as is.
+func (s OwnershipStamp) Apply(workload *platformv1alpha1.Workload) error {
+	if !IsManifest(*workload) {
+		return nil
+	}
+	content, err := s.stampManifests(workload.Content, filepath.Ext(workload.Filepath) == ".json")
+	if err != nil {
+		return fmt.Errorf("failed to stamp %s: %w", workload.Filepath, err)
+	}
+	workload.Content = content
+	return nil
+}
+
+func (s OwnershipStamp) stampManifests(content string, asJSON bool) (string, error) {
+	var documents [][]byte
+	stamped := false
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(content), 2048)
+	for {
+		var content interface{}
+		err := decoder.Decode(&content)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if content == nil {
+			continue
+		}
+
+		// documents that are not objects, such as lists, are kept as they are
+		if object, ok := content.(map[string]interface{}); ok {
+			manifest := &unstructured.Unstructured{Object: object}
+			if manifest.GetKind() != "" {
+				manifest.SetLabels(merge(manifest.GetLabels(), s.Labels))
+				manifest.SetAnnotations(merge(manifest.GetAnnotations(), s.Annotations))
+				stamped = true
+			}
+		}
+
+		var document []byte
+		if asJSON {
+			document, err = json.MarshalIndent(content, "", "  ")
+			document = append(document, '\n')
+		} else {
+			document, err = sigsyaml.Marshal(content)
+		}
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, document)
+	}
+
+	// files without any manifest keep their formatting and comments
+	if !stamped {
+		return content, nil
+	}
+	if asJSON {
+		return string(bytes.Join(documents, nil)), nil
+	}
+	return string(bytes.Join(documents, []byte("---\n"))), nil
+}
+
+func merge(existing, stamped map[string]string) map[string]string {
+	if len(stamped) == 0 {
+		return existing
+	}
+	merged := map[string]string{}
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for key, value := range stamped {
+		merged[key] = value
+	}
+	return merged
+}
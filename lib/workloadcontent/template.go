@@ -0,0 +1,87 @@
+package workloadcontent
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+)
+
+// TemplateSuffix marks the workloads rendered for each Destination they are
+// written to. The suffix is removed from the Filepath of the rendered
+// workload, so deployment.yaml.kratix.tmpl is written as deployment.yaml.
+const TemplateSuffix = ".kratix.tmpl"
+
+// TemplateData is the data available to workload templates
+type TemplateData struct {
+	Destination TemplateDestination
+}
+
+// TemplateDestination describes the Destination a template is rendered for
+type TemplateDestination struct {
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Render returns the workloads with the templates, marked by the
+// TemplateSuffix, rendered for the Destination. The workloads must be
+// decoded. Keys missing from the data are errors, so a typo in a template is
+// not written to the State Store as an empty value. When process is not
+// nil, it is called on every rendered template, so the checks the pipeline
+// output goes through can be made once the templates are manifests.
+func Render(workloads []platformv1alpha1.Workload, destination platformv1alpha1.Destination, process func(*platformv1alpha1.Workload) error) ([]platformv1alpha1.Workload, error) {
+	data := TemplateData{
+		Destination: TemplateDestination{
+			Name:        destination.GetName(),
+			Labels:      destination.GetLabels(),
+			Annotations: destination.GetAnnotations(),
+		},
+	}
+
+	filepaths := map[string]bool{}
+	for _, workload := range workloads {
+		filepaths[workload.Filepath] = true
+	}
+
+	rendered := []platformv1alpha1.Workload{}
+	for _, workload := range workloads {
+		if !strings.HasSuffix(workload.Filepath, TemplateSuffix) {
+			rendered = append(rendered, workload)
+			continue
+		}
+
+		filepath := strings.TrimSuffix(workload.Filepath, TemplateSuffix)
+		if filepaths[filepath] {
+			return nil, fmt.Errorf("template %s renders to %s, which is also in the workloads", workload.Filepath, filepath)
+		}
+
+		content, err := decodeContent(workload.Content, workload.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode template %s: %w", workload.Filepath, err)
+		}
+
+		tmpl, err := template.New(workload.Filepath).Option("missingkey=error").Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", workload.Filepath, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render template %s for Destination %s: %w", workload.Filepath, destination.GetName(), err)
+		}
+
+		workload.Filepath = filepath
+		workload.Content = buf.String()
+		workload.Encoding = ""
+		if process != nil {
+			if err := process(&workload); err != nil {
+				return nil, err
+			}
+		}
+		rendered = append(rendered, workload)
+	}
+	return rendered, nil
+}
@@ -0,0 +1,116 @@
+package workloadcontent_test
+
+import (
+	"encoding/base64"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/workloadcontent"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Render", func() {
+	var destination platformv1alpha1.Destination
+
+	BeforeEach(func() {
+		destination = platformv1alpha1.Destination{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "worker-1",
+				Labels:      map[string]string{"region": "eu-west-2"},
+				Annotations: map[string]string{"example.com/ingress-domain": "worker-1.example.com"},
+			},
+		}
+	})
+
+	It("renders the templates for the Destination and leaves other workloads as they are", func() {
+		workloads := []platformv1alpha1.Workload{
+			{
+				Filepath: "ingress.yaml" + workloadcontent.TemplateSuffix,
+				Content:  `host: app.{{ index .Destination.Annotations "example.com/ingress-domain" }}` + "\nregion: {{ .Destination.Labels.region }}\ncluster: {{ .Destination.Name }}\n",
+			},
+			{Filepath: "plain.yaml", Content: "cluster: {{ .Destination.Name }}\n"},
+		}
+
+		rendered, err := workloadcontent.Render(workloads, destination, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal([]platformv1alpha1.Workload{
+			{Filepath: "ingress.yaml", Content: "host: app.worker-1.example.com\nregion: eu-west-2\ncluster: worker-1\n"},
+			{Filepath: "plain.yaml", Content: "cluster: {{ .Destination.Name }}\n"},
+		}))
+		Expect(workloads[0].Filepath).To(HaveSuffix(workloadcontent.TemplateSuffix))
+	})
+
+	It("renders base64 encoded templates", func() {
+		workloads := []platformv1alpha1.Workload{{
+			Filepath: "name.txt" + workloadcontent.TemplateSuffix,
+			Content:  base64.StdEncoding.EncodeToString([]byte("{{ .Destination.Name }}")),
+			Encoding: platformv1alpha1.WorkloadEncodingBase64,
+		}}
+
+		rendered, err := workloadcontent.Render(workloads, destination, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered).To(Equal([]platformv1alpha1.Workload{{Filepath: "name.txt", Content: "worker-1"}}))
+	})
+
+	It("processes the rendered templates only", func() {
+		workloads := []platformv1alpha1.Workload{
+			{Filepath: "app.yaml" + workloadcontent.TemplateSuffix, Content: "cluster: {{ .Destination.Name }}\n"},
+			{Filepath: "plain.yaml", Content: "a: b\n"},
+		}
+
+		processed := []string{}
+		rendered, err := workloadcontent.Render(workloads, destination, func(workload *platformv1alpha1.Workload) error {
+			processed = append(processed, workload.Content)
+			workload.Content += "stamped: true\n"
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(processed).To(Equal([]string{"cluster: worker-1\n"}))
+		Expect(rendered[0].Content).To(Equal("cluster: worker-1\nstamped: true\n"))
+		Expect(rendered[1].Content).To(Equal("a: b\n"))
+	})
+
+	It("errors when processing a rendered template fails", func() {
+		workloads := []platformv1alpha1.Workload{{
+			Filepath: "app.yaml" + workloadcontent.TemplateSuffix,
+			Content:  "cluster: {{ .Destination.Name }}",
+		}}
+
+		_, err := workloadcontent.Render(workloads, destination, func(*platformv1alpha1.Workload) error {
+			return errors.New("not allowed")
+		})
+		Expect(err).To(MatchError("not allowed"))
+	})
+
+	It("errors when a template uses a key the Destination does not have", func() {
+		workloads := []platformv1alpha1.Workload{{
+			Filepath: "region.yaml" + workloadcontent.TemplateSuffix,
+			Content:  "region: {{ .Destination.Labels.zone }}",
+		}}
+
+		_, err := workloadcontent.Render(workloads, destination, nil)
+		Expect(err).To(MatchError(ContainSubstring("failed to render template region.yaml.kratix.tmpl for Destination worker-1")))
+	})
+
+	It("errors when a template is invalid", func() {
+		workloads := []platformv1alpha1.Workload{{
+			Filepath: "broken.yaml" + workloadcontent.TemplateSuffix,
+			Content:  "{{ .Destination.Name",
+		}}
+
+		_, err := workloadcontent.Render(workloads, destination, nil)
+		Expect(err).To(MatchError(ContainSubstring("failed to parse template broken.yaml.kratix.tmpl")))
+	})
+
+	It("errors when a template renders to the path of another workload", func() {
+		workloads := []platformv1alpha1.Workload{
+			{Filepath: "app.yaml", Content: "a: b"},
+			{Filepath: "app.yaml" + workloadcontent.TemplateSuffix, Content: "a: {{ .Destination.Name }}"},
+		}
+
+		_, err := workloadcontent.Render(workloads, destination, nil)
+		Expect(err).To(MatchError("template app.yaml.kratix.tmpl renders to app.yaml, which is also in the workloads"))
+	})
+})
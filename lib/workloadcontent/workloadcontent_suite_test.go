@@ -0,0 +1,13 @@
+package workloadcontent_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWorkloadcontent(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Workloadcontent Suite")
+}
@@ -64,7 +64,7 @@ func NewGitWriter(logger logr.Logger, stateStoreSpec platformv1alpha1.GitStateSt
 			Email: "kratix@syntasso.io",
 		},
 		Log:  logger,
-		path: filepath.Join(stateStoreSpec.Path, destination.Spec.Path, destination.Namespace, destination.Name),
+		path: stateStoreSpec.Path,
 	}, nil
 }
 
@@ -144,11 +144,17 @@ func (g *GitWriter) WriteDirWithObjects(deleteExistingContentsInDir bool, subDir
 			return err
 		}
 
-		if err := os.WriteFile(absoluteFilePath, []byte(item.Content), 0644); err != nil {
+		if err := os.WriteFile(absoluteFilePath, []byte(item.Content), item.FileMode()); err != nil {
 			logger.Error(err, "could not write to file")
 			return err
 		}
 
+		// WriteFile only sets the mode of new files
+		if err := os.Chmod(absoluteFilePath, item.FileMode()); err != nil {
+			logger.Error(err, "could not set file mode")
+			return err
+		}
+
 		if _, err := worktree.Add(worktreeFilePath); err != nil {
 			logger.Error(err, "could not add file to worktree")
 			return err
@@ -0,0 +1,441 @@
+package writers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KubernetesFieldManager is the field manager of the objects Kratix
+	// applies to the cluster of a KubernetesStateStore
+	KubernetesFieldManager = "kratix"
+	// DefaultInventoryNamespace is the namespace of the inventory ConfigMaps
+	// when the KubernetesStateStore does not set one
+	DefaultInventoryNamespace = "kratix-inventory"
+
+	// the canary Namespace is removed with the canary files, so cannot hold
+	// the inventories
+	canaryNamespace = "kratix-worker-system"
+
+	inventoryLabelKey       = "kratix.io/inventory"
+	inventoryPathAnnotation = "kratix.io/inventory-path"
+	inventoryDataKey        = "inventory"
+	defaultObjectNamespace  = "default"
+)
+
+// KubernetesWriter applies the workloads directly to a cluster. The objects
+// applied from each directory are recorded in an inventory ConfigMap on the
+// cluster, so those no longer in the workloads are pruned.
+type KubernetesWriter struct {
+	Log    logr.Logger
+	Client client.Client
+	// InventoryNamespace is the namespace of the inventory ConfigMaps
+	InventoryNamespace string
+	path               string
+}
+
+// inventoryObject identifies an object applied to the cluster
+type inventoryObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// inventory maps the files of a directory to the objects applied from them
+type inventory map[string][]inventoryObject
+
+func NewKubernetesWriter(logger logr.Logger, stateStoreSpec platformv1alpha1.KubernetesStateStoreSpec, destination platformv1alpha1.Destination, creds map[string][]byte) (StateStoreWriter, error) {
+	inventoryNamespace := stateStoreSpec.InventoryNamespace
+	if inventoryNamespace == "" {
+		inventoryNamespace = DefaultInventoryNamespace
+	}
+	if inventoryNamespace == canaryNamespace {
+		return nil, fmt.Errorf("invalid inventoryNamespace %s: Kratix removes it with the canary files", inventoryNamespace)
+	}
+
+	kubeconfig, ok := creds[platformv1alpha1.KubernetesStateStoreKubeconfigKey]
+	if !ok {
+		return nil, fmt.Errorf("missing key %s", platformv1alpha1.KubernetesStateStoreKubeconfigKey)
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+
+	c, err := client.New(config, client.Options{})
+	if err != nil {
+		logger.Error(err, "Error initialising Kubernetes client")
+		return nil, err
+	}
+
+	return &KubernetesWriter{
+		Log:                logger,
+		Client:             c,
+		InventoryNamespace: inventoryNamespace,
+		path:               stateStoreSpec.Path,
+	}, nil
+}
+
+func (k *KubernetesWriter) WriteDirWithObjects(deleteExistingContentsInDir bool, dir string, toWrite ...platformv1alpha1.Workload) error {
+	ctx := context.Background()
+	dirPath := filepath.Join(k.path, dir)
+	logger := k.Log.WithValues("path", dirPath)
+
+	previous, err := k.getInventory(ctx, dirPath)
+	if err != nil {
+		return err
+	}
+
+	current := inventory{}
+	if !deleteExistingContentsInDir {
+		for file, objects := range previous {
+			current[file] = objects
+		}
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, workload := range toWrite {
+		manifests, err := manifestsOf(workload)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", workload.Filepath, err)
+		}
+		if manifests == nil {
+			logger.Info("Not a Kubernetes manifest, will not apply", "file", workload.Filepath)
+		}
+
+		current[workload.Filepath] = []inventoryObject{}
+		for _, manifest := range manifests {
+			if err := k.defaultNamespace(manifest); err != nil {
+				return fmt.Errorf("failed to apply %s: %w", workload.Filepath, err)
+			}
+			current[workload.Filepath] = append(current[workload.Filepath], inventoryObjectOf(manifest))
+			objects = append(objects, manifest)
+		}
+	}
+
+	// CRDs and Namespaces first, so the objects relying on them can be applied
+	sort.SliceStable(objects, func(i, j int) bool {
+		return applyPriority(objects[i]) < applyPriority(objects[j])
+	})
+	for _, object := range objects {
+		logger.Info("Applying object", "kind", object.GetKind(), "namespace", object.GetNamespace(), "name", object.GetName())
+		if err := k.Client.Patch(ctx, object, client.Apply, client.FieldOwner(KubernetesFieldManager), client.ForceOwnership); err != nil {
+			logger.Error(err, "Error applying object", "kind", object.GetKind(), "namespace", object.GetNamespace(), "name", object.GetName())
+			return err
+		}
+	}
+
+	claimed, err := k.claimedObjects(ctx, map[string]inventory{dirPath: current})
+	if err != nil {
+		return err
+	}
+	if err := k.deleteObjects(ctx, unclaimed(removedObjects(previous, current), claimed), logger); err != nil {
+		return err
+	}
+	return k.saveInventory(ctx, dirPath, current)
+}
+
+// RemoveObject deletes the objects applied from the file, or from the files
+// within the directory when objectName ends with a slash. Objects still
+// applied from files elsewhere, such as those just moved to another
+// directory, are kept.
+func (k *KubernetesWriter) RemoveObject(objectName string) error {
+	ctx := context.Background()
+	target := filepath.Join(k.path, objectName)
+	logger := k.Log.WithValues("path", target)
+
+	inventories, err := k.listInventories(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous := map[string]inventory{}
+	current := map[string]inventory{}
+	for dirPath, inv := range inventories {
+		remaining := inventory{}
+		for file, objects := range inv {
+			filePath := filepath.Join(dirPath, file)
+			if filePath == target || strings.HasPrefix(filePath, target+"/") {
+				continue
+			}
+			remaining[file] = objects
+		}
+		if len(remaining) != len(inv) {
+			previous[dirPath] = inv
+			current[dirPath] = remaining
+		}
+	}
+	if len(current) == 0 {
+		return nil
+	}
+
+	claimed, err := k.claimedObjects(ctx, current)
+	if err != nil {
+		return err
+	}
+	dirPaths := []string{}
+	for dirPath := range current {
+		dirPaths = append(dirPaths, dirPath)
+	}
+	sort.Strings(dirPaths)
+	for _, dirPath := range dirPaths {
+		logger.Info("Removing objects from cluster", "inventory", dirPath)
+		if err := k.deleteObjects(ctx, unclaimed(removedObjects(previous[dirPath], current[dirPath]), claimed), logger); err != nil {
+			return err
+		}
+		if err := k.saveInventory(ctx, dirPath, current[dirPath]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestsOf returns the objects in the YAML or JSON documents of the
+// workload, or nil for workloads that are not Kubernetes manifests.
+// Documents without a kind, such as kustomization files, are skipped.
+func manifestsOf(workload platformv1alpha1.Workload) ([]*unstructured.Unstructured, error) {
+	switch filepath.Ext(workload.Filepath) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, nil
+	}
+
+	manifests := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(workload.Content), 2048)
+	for {
+		var object map[string]interface{}
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			return manifests, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		manifest := &unstructured.Unstructured{Object: object}
+		if object == nil || manifest.GetKind() == "" || manifest.GetAPIVersion() == "" {
+			continue
+		}
+		manifest.SetManagedFields(nil)
+		manifest.SetResourceVersion("")
+		manifests = append(manifests, manifest)
+	}
+}
+
+// defaultNamespace sets the default namespace on namespaced objects without
+// one, as kubectl does
+func (k *KubernetesWriter) defaultNamespace(object *unstructured.Unstructured) error {
+	if object.GetNamespace() != "" || applyPriority(object) == 0 {
+		return nil
+	}
+	namespaced, err := k.Client.IsObjectNamespaced(object)
+	if err != nil {
+		return err
+	}
+	if namespaced {
+		object.SetNamespace(defaultObjectNamespace)
+	}
+	return nil
+}
+
+func applyPriority(object *unstructured.Unstructured) int {
+	gvk := object.GroupVersionKind()
+	if (gvk.Group == "" && gvk.Kind == "Namespace") ||
+		(gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition") {
+		return 0
+	}
+	return 1
+}
+
+func inventoryObjectOf(object *unstructured.Unstructured) inventoryObject {
+	return inventoryObject{
+		APIVersion: object.GetAPIVersion(),
+		Kind:       object.GetKind(),
+		Namespace:  object.GetNamespace(),
+		Name:       object.GetName(),
+	}
+}
+
+// removedObjects returns the objects in the previous inventory that are no
+// longer in the current one
+func removedObjects(previous, current inventory) []inventoryObject {
+	kept := map[inventoryObject]bool{}
+	for _, objects := range current {
+		for _, object := range objects {
+			kept[object] = true
+		}
+	}
+
+	removed := []inventoryObject{}
+	seen := map[inventoryObject]bool{}
+	for _, objects := range previous {
+		for _, object := range objects {
+			if kept[object] || seen[object] {
+				continue
+			}
+			seen[object] = true
+			removed = append(removed, object)
+		}
+	}
+	return removed
+}
+
+// unclaimed returns the objects not in the claimed set
+func unclaimed(objects []inventoryObject, claimed map[inventoryObject]bool) []inventoryObject {
+	result := []inventoryObject{}
+	for _, object := range objects {
+		if !claimed[object] {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+// claimedObjects returns the objects in the inventories of every directory,
+// with those of the updated directories replaced by their new inventory
+func (k *KubernetesWriter) claimedObjects(ctx context.Context, updated map[string]inventory) (map[inventoryObject]bool, error) {
+	inventories, err := k.listInventories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for dirPath, inv := range updated {
+		inventories[dirPath] = inv
+	}
+
+	claimed := map[inventoryObject]bool{}
+	for _, inv := range inventories {
+		for _, objects := range inv {
+			for _, object := range objects {
+				claimed[object] = true
+			}
+		}
+	}
+	return claimed, nil
+}
+
+// listInventories returns the inventories on the cluster by the path of
+// their directory
+func (k *KubernetesWriter) listInventories(ctx context.Context) (map[string]inventory, error) {
+	inventories := map[string]inventory{}
+	configMaps := &v1.ConfigMapList{}
+	if err := k.Client.List(ctx, configMaps, client.InNamespace(k.InventoryNamespace), client.MatchingLabels{inventoryLabelKey: "true"}); err != nil {
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return inventories, nil
+		}
+		return nil, err
+	}
+
+	for i := range configMaps.Items {
+		inv, err := parseInventory(&configMaps.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		inventories[configMaps.Items[i].GetAnnotations()[inventoryPathAnnotation]] = inv
+	}
+	return inventories, nil
+}
+
+func (k *KubernetesWriter) deleteObjects(ctx context.Context, objects []inventoryObject, logger logr.Logger) error {
+	toDelete := []*unstructured.Unstructured{}
+	for _, object := range objects {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(object.APIVersion)
+		u.SetKind(object.Kind)
+		u.SetNamespace(object.Namespace)
+		u.SetName(object.Name)
+		toDelete = append(toDelete, u)
+	}
+
+	// CRDs and Namespaces last, as deleting them deletes the objects in them
+	sort.SliceStable(toDelete, func(i, j int) bool {
+		return applyPriority(toDelete[i]) > applyPriority(toDelete[j])
+	})
+	for _, object := range toDelete {
+		logger.Info("Pruning object", "kind", object.GetKind(), "namespace", object.GetNamespace(), "name", object.GetName())
+		err := k.Client.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			logger.Error(err, "Error pruning object", "kind", object.GetKind(), "namespace", object.GetNamespace(), "name", object.GetName())
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KubernetesWriter) getInventory(ctx context.Context, dirPath string) (inventory, error) {
+	configMap := &v1.ConfigMap{}
+	err := k.Client.Get(ctx, client.ObjectKey{Namespace: k.InventoryNamespace, Name: inventoryName(dirPath)}, configMap)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return inventory{}, nil
+		}
+		return nil, err
+	}
+	return parseInventory(configMap)
+}
+
+func parseInventory(configMap *v1.ConfigMap) (inventory, error) {
+	inv := inventory{}
+	if err := json.Unmarshal([]byte(configMap.Data[inventoryDataKey]), &inv); err != nil {
+		return nil, fmt.Errorf("invalid inventory %s: %w", configMap.GetName(), err)
+	}
+	return inv, nil
+}
+
+// saveInventory stores the inventory of the directory, deleting it once the
+// directory holds no files
+func (k *KubernetesWriter) saveInventory(ctx context.Context, dirPath string, inv inventory) error {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        inventoryName(dirPath),
+			Namespace:   k.InventoryNamespace,
+			Labels:      map[string]string{inventoryLabelKey: "true"},
+			Annotations: map[string]string{inventoryPathAnnotation: dirPath},
+		},
+	}
+
+	if len(inv) == 0 {
+		return client.IgnoreNotFound(k.Client.Delete(ctx, configMap))
+	}
+
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: k.InventoryNamespace}}
+	if err := k.Client.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{inventoryDataKey: string(data)}
+
+	if err := k.Client.Create(ctx, configMap); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return k.Client.Update(ctx, configMap)
+	}
+	return nil
+}
+
+func inventoryName(dirPath string) string {
+	return fmt.Sprintf("kratix-inventory-%x", sha256.Sum256([]byte(dirPath)))[:len("kratix-inventory-")+32]
+}
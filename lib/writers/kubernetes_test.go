@@ -0,0 +1,315 @@
+package writers_test
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/writers"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+var _ = Describe("Kubernetes", func() {
+	var (
+		dest           platformv1alpha1.Destination
+		stateStoreSpec platformv1alpha1.KubernetesStateStoreSpec
+	)
+
+	BeforeEach(func() {
+		stateStoreSpec = platformv1alpha1.KubernetesStateStoreSpec{}
+		dest = platformv1alpha1.Destination{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "worker",
+			},
+		}
+	})
+
+	Describe("NewKubernetesWriter", func() {
+		It("errors when the kubeconfig is missing", func() {
+			_, err := writers.NewKubernetesWriter(ctrl.Log, stateStoreSpec, dest, map[string][]byte{})
+			Expect(err).To(MatchError("missing key kubeconfig"))
+		})
+
+		It("errors when the kubeconfig is invalid", func() {
+			_, err := writers.NewKubernetesWriter(ctrl.Log, stateStoreSpec, dest, map[string][]byte{
+				"kubeconfig": []byte("not: [a kubeconfig"),
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid kubeconfig")))
+		})
+
+		It("errors when the inventory namespace is the canary namespace", func() {
+			stateStoreSpec.InventoryNamespace = "kratix-worker-system"
+			_, err := writers.NewKubernetesWriter(ctrl.Log, stateStoreSpec, dest, map[string][]byte{})
+			Expect(err).To(MatchError(ContainSubstring("invalid inventoryNamespace kratix-worker-system")))
+		})
+	})
+
+	Describe("applying and pruning", func() {
+		var (
+			ctx    = context.Background()
+			remote client.Client
+			writer *writers.KubernetesWriter
+		)
+
+		// the fake client does not support server-side apply, so applies are
+		// made as creates or updates
+		apply := func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			existing := &unstructured.Unstructured{}
+			existing.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+				if !errors.IsNotFound(err) {
+					return err
+				}
+				return c.Create(ctx, obj)
+			}
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			return c.Update(ctx, obj)
+		}
+
+		configMap := func(name string) string {
+			return "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n  namespace: app\n"
+		}
+
+		exists := func(name string) bool {
+			err := remote.Get(ctx, client.ObjectKey{Namespace: "app", Name: name}, &v1.ConfigMap{})
+			if errors.IsNotFound(err) {
+				return false
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return true
+		}
+
+		BeforeEach(func() {
+			remote = fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{Patch: apply}).Build()
+			writer = &writers.KubernetesWriter{
+				Log:                ctrl.Log,
+				Client:             remote,
+				InventoryNamespace: writers.DefaultInventoryNamespace,
+			}
+		})
+
+		It("applies the manifests and records them in an inventory", func() {
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: configMap("one")},
+			)).To(Succeed())
+			Expect(exists("one")).To(BeTrue())
+
+			inventories := &v1.ConfigMapList{}
+			Expect(remote.List(ctx, inventories, client.InNamespace(writers.DefaultInventoryNamespace), client.MatchingLabels{"kratix.io/inventory": "true"})).To(Succeed())
+			Expect(inventories.Items).To(HaveLen(1))
+			Expect(inventories.Items[0].GetAnnotations()).To(HaveKeyWithValue("kratix.io/inventory-path", "resources/app"))
+		})
+
+		It("prunes the objects removed from the directory", func() {
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: configMap("one") + "---\n" + configMap("two")},
+			)).To(Succeed())
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: configMap("one")},
+			)).To(Succeed())
+
+			Expect(exists("one")).To(BeTrue())
+			Expect(exists("two")).To(BeFalse())
+		})
+
+		It("keeps the objects removed from a directory that another directory still applies", func() {
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: configMap("shared")},
+			)).To(Succeed())
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/other",
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: configMap("shared")},
+			)).To(Succeed())
+
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app")).To(Succeed())
+			Expect(exists("shared")).To(BeTrue())
+
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/other")).To(Succeed())
+			Expect(exists("shared")).To(BeFalse())
+		})
+
+		It("keeps the workloads and the canary Namespace when the PathTemplate of the Destination changes", func() {
+			canary := platformv1alpha1.Workload{Filepath: "kratix-canary-namespace.yaml", Content: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: kratix-worker-system\n"}
+			workloads := []platformv1alpha1.Workload{
+				{Filepath: "namespace.yaml", Content: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n"},
+				{Filepath: "config.yaml", Content: configMap("one")},
+			}
+			resource := writers.PathData{Type: writers.ResourcesType, PromiseName: "redis", ResourceNamespace: "default", ResourceName: "my-redis", ID: "5058f"}
+
+			// as the Destination and WorkPlacement controllers write and move
+			// the workloads
+			write := func(layout writers.Layout) (string, string) {
+				typeDir, err := layout.TypeDir(writers.DependenciesType)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.WriteDirWithObjects(writers.PreserveExistingContentsInDir, typeDir, canary)).To(Succeed())
+
+				dir, err := layout.Dir(resource)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, dir, workloads...)).To(Succeed())
+				return typeDir, dir
+			}
+
+			dest.Spec.Path = "clusters"
+			oldTypeDir, oldDir := write(writers.DefaultLayout(dest))
+
+			dest.Spec.PathTemplate = "{{ .Destination.Name }}/{{ .Type }}/{{ .PromiseName }}/{{ .ResourceNamespace }}-{{ .ResourceName }}-{{ .ID }}"
+			layout, err := writers.NewLayout(platformv1alpha1.StateStoreCoreFields{}, dest)
+			Expect(err).NotTo(HaveOccurred())
+			_, newDir := write(layout)
+			Expect(newDir).NotTo(Equal(oldDir))
+
+			Expect(writer.RemoveObject(oldDir + "/")).To(Succeed())
+			Expect(writer.RemoveObject(oldTypeDir + "/" + canary.Filepath)).To(Succeed())
+
+			Expect(exists("one")).To(BeTrue())
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "app"}, &v1.Namespace{})).To(Succeed())
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "kratix-worker-system"}, &v1.Namespace{})).To(Succeed())
+		})
+
+		It("keeps the objects of a removed directory that were moved to another one", func() {
+			workloads := []platformv1alpha1.Workload{
+				{Filepath: "namespace.yaml", Content: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n"},
+				{Filepath: "config.yaml", Content: configMap("one")},
+			}
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "old/resources/app", workloads...)).To(Succeed())
+			Expect(writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "new/resources/app", workloads...)).To(Succeed())
+
+			Expect(writer.RemoveObject("old/")).To(Succeed())
+			Expect(exists("one")).To(BeTrue())
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "app"}, &v1.Namespace{})).To(Succeed())
+
+			Expect(writer.RemoveObject("new/")).To(Succeed())
+			Expect(exists("one")).To(BeFalse())
+			inventories := &v1.ConfigMapList{}
+			Expect(remote.List(ctx, inventories, client.InNamespace(writers.DefaultInventoryNamespace))).To(Succeed())
+			Expect(inventories.Items).To(BeEmpty())
+		})
+	})
+
+	Describe("writing to a cluster", Ordered, func() {
+		var (
+			testEnv    *envtest.Environment
+			kubeconfig []byte
+			remote     client.Client
+			writer     writers.StateStoreWriter
+			ctx        = context.Background()
+		)
+
+		BeforeAll(func() {
+			if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+				Skip("KUBEBUILDER_ASSETS is not set, run with make test")
+			}
+
+			testEnv = &envtest.Environment{}
+			cfg, err := testEnv.Start()
+			Expect(err).NotTo(HaveOccurred())
+
+			remote, err = client.New(cfg, client.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			user, err := testEnv.AddUser(envtest.User{Name: "kratix", Groups: []string{"system:masters"}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			kubeconfig, err = user.KubeConfig()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			if testEnv != nil {
+				Expect(testEnv.Stop()).To(Succeed())
+			}
+		})
+
+		BeforeEach(func() {
+			var err error
+			writer, err = writers.NewKubernetesWriter(ctrl.Log, stateStoreSpec, dest, map[string][]byte{
+				"kubeconfig": kubeconfig,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		getConfigMap := func(namespace, name string) (*v1.ConfigMap, error) {
+			cm := &v1.ConfigMap{}
+			err := remote.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm)
+			return cm, err
+		}
+
+		It("applies the manifests, defaulting the namespace of namespaced objects", func() {
+			err := writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "namespace.yaml", Content: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n"},
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: app\ndata:\n  key: one\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n"},
+				platformv1alpha1.Workload{Filepath: "README.md", Content: "# not a manifest"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			cm, err := getConfigMap("app", "app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cm.Data).To(Equal(map[string]string{"key": "one"}))
+			Expect(cm.GetManagedFields()[0].Manager).To(Equal(writers.KubernetesFieldManager))
+
+			_, err = getConfigMap("default", "other")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("updates the changed manifests and prunes the removed ones", func() {
+			err := writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "namespace.yaml", Content: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n"},
+				platformv1alpha1.Workload{Filepath: "config.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: app\ndata:\n  key: two\n"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			cm, err := getConfigMap("app", "app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cm.Data).To(Equal(map[string]string{"key": "two"}))
+
+			_, err = getConfigMap("default", "other")
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("keeps the objects of the files not written when preserving the existing contents", func() {
+			err := writer.WriteDirWithObjects(writers.PreserveExistingContentsInDir, "resources/app",
+				platformv1alpha1.Workload{Filepath: "extra.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: extra\n  namespace: app\n"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = getConfigMap("app", "app")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = getConfigMap("app", "extra")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("removes the objects applied from a directory", func() {
+			err := writer.WriteDirWithObjects(writers.DeleteExistingContentsInDir, "dependencies",
+				platformv1alpha1.Workload{Filepath: "dependencies.yaml", Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: dependency\n"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(writer.RemoveObject("resources/")).To(Succeed())
+
+			for _, name := range []string{"app", "extra"} {
+				_, err = getConfigMap("app", name)
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+			_, err = getConfigMap("default", "dependency")
+			Expect(err).NotTo(HaveOccurred())
+
+			inventories := &v1.ConfigMapList{}
+			Expect(remote.List(ctx, inventories, client.InNamespace(writers.DefaultInventoryNamespace), client.MatchingLabels{"kratix.io/inventory": "true"})).To(Succeed())
+			Expect(inventories.Items).To(HaveLen(1))
+			Expect(inventories.Items[0].GetAnnotations()).To(HaveKeyWithValue("kratix.io/inventory-path", "dependencies"))
+		})
+	})
+})
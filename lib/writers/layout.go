@@ -0,0 +1,222 @@
+package writers
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+)
+
+const (
+	// ResourcesType is the type of the resource request workloads
+	ResourcesType = "resources"
+	// DependenciesType is the type of the Promise dependency workloads
+	DependenciesType = "dependencies"
+)
+
+// PathData is the data available to the PathTemplate of a State Store or
+// Destination
+type PathData struct {
+	Destination PathDestination
+	// Type is resources or dependencies
+	Type              string
+	PromiseName       string
+	ResourceNamespace string
+	ResourceName      string
+	// ID is the short ID of the group of workloads
+	ID string
+}
+
+// PathDestination describes the Destination a path is rendered for
+type PathDestination struct {
+	Name      string
+	Namespace string
+	Path      string
+	Labels    map[string]string
+}
+
+// Layout decides the directories, within the Path of a State Store, the
+// workloads of a Destination are written to. The default layout is
+//
+//	<destination.path>/<destination.name>/dependencies/<promise>/<id>
+//	<destination.path>/<destination.name>/resources/<namespace>/<promise>/<resource>/<id>
+//
+// and a PathTemplate replaces it.
+type Layout struct {
+	destination platformv1alpha1.Destination
+	template    *template.Template
+}
+
+// NewLayout returns the layout of the Destination. The PathTemplate of the
+// Destination takes precedence over that of its State Store. A template must
+// use the Destination name, so Destinations sharing a State Store do not
+// write to, or remove, each other's directories.
+func NewLayout(stateStore platformv1alpha1.StateStoreCoreFields, destination platformv1alpha1.Destination) (Layout, error) {
+	pathTemplate := destination.Spec.PathTemplate
+	if pathTemplate == "" {
+		pathTemplate = stateStore.PathTemplate
+	}
+	if pathTemplate == "" {
+		return Layout{destination: destination}, nil
+	}
+
+	if !strings.Contains(pathTemplate, ".Destination.Name") {
+		return Layout{}, fmt.Errorf("invalid pathTemplate %q: must use .Destination.Name", pathTemplate)
+	}
+	tmpl, err := template.New("pathTemplate").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return Layout{}, fmt.Errorf("invalid pathTemplate %q: %w", pathTemplate, err)
+	}
+	return Layout{destination: destination, template: tmpl}, nil
+}
+
+// DefaultLayout returns the default layout of the Destination, regardless of
+// any PathTemplate
+func DefaultLayout(destination platformv1alpha1.Destination) Layout {
+	return Layout{destination: destination}
+}
+
+// TypeDir returns the directory of the workloads of the type. Kratix writes
+// its canary files to it, and removes it when the Destination is deleted, so
+// a PathTemplate must render the type as a directory of its own, within a
+// directory of the Destination.
+func (l Layout) TypeDir(workloadType string) (string, error) {
+	dir, err := l.typeDir(workloadType)
+	if err != nil || l.template == nil {
+		return dir, err
+	}
+
+	// the directory of another Destination sharing the State Store must
+	// differ, or removing one would remove the workloads of both
+	other := l
+	other.destination = *l.destination.DeepCopy()
+	other.destination.SetName(l.destination.GetName() + "-variant")
+	otherDir, err := other.typeDir(workloadType)
+	if err != nil {
+		return "", err
+	}
+	if otherDir == dir {
+		return "", fmt.Errorf("pathTemplate renders %s for any .Destination.Name, it must use .Destination.Name before .Type", dir)
+	}
+	return dir, nil
+}
+
+func (l Layout) typeDir(workloadType string) (string, error) {
+	dir, err := l.render(PathData{Type: workloadType})
+	if err != nil || l.template == nil {
+		return dir, err
+	}
+
+	// the fields of the group of workloads render empty, so only the
+	// directories up to the type are kept
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		if part == workloadType {
+			return filepath.Join(parts[:i+1]...), nil
+		}
+	}
+	return "", fmt.Errorf("pathTemplate renders %s, it must use .Type as a directory", dir)
+}
+
+// Dir returns the directory of a group of workloads, which must be within
+// the directory of its type
+func (l Layout) Dir(data PathData) (string, error) {
+	dir, err := l.render(data)
+	if err != nil {
+		return "", err
+	}
+
+	typeDir, err := l.TypeDir(data.Type)
+	if err != nil {
+		return "", err
+	}
+	if typeDir != "." && !strings.HasPrefix(dir, typeDir+"/") {
+		return "", fmt.Errorf("pathTemplate renders %s, which is not within %s, the directory of the %s", dir, typeDir, data.Type)
+	}
+
+	// groups of workloads differing in any field must not share a directory,
+	// as writing one would delete the other
+	if l.template != nil {
+		for _, variant := range variants(data) {
+			variantDir, err := l.render(variant.data)
+			if err != nil {
+				return "", err
+			}
+			if variantDir == dir {
+				return "", fmt.Errorf("pathTemplate renders %s for any .%s, it must use .%s", dir, variant.field, variant.field)
+			}
+		}
+	}
+	return dir, nil
+}
+
+func (l Layout) render(data PathData) (string, error) {
+	data.Destination = PathDestination{
+		Name:      l.destination.GetName(),
+		Namespace: l.destination.GetNamespace(),
+		Path:      l.destination.Spec.Path,
+		Labels:    l.destination.GetLabels(),
+	}
+
+	if l.template == nil {
+		return defaultDir(data), nil
+	}
+
+	var buf bytes.Buffer
+	if err := l.template.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render pathTemplate: %w", err)
+	}
+
+	dir := filepath.Clean(strings.TrimSpace(buf.String()))
+	if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("pathTemplate renders %s, which is not within the State Store path", dir)
+	}
+	return dir, nil
+}
+
+func defaultDir(data PathData) string {
+	typeDir := filepath.Join(data.Destination.Path, data.Destination.Namespace, data.Destination.Name, data.Type)
+	switch {
+	case data.PromiseName == "":
+		return typeDir
+	case data.ResourceName == "":
+		return filepath.Join(typeDir, data.PromiseName, data.ID)
+	default:
+		return filepath.Join(typeDir, data.ResourceNamespace, data.PromiseName, data.ResourceName, data.ID)
+	}
+}
+
+type pathVariant struct {
+	field string
+	data  PathData
+}
+
+// variants returns, for each of the workload fields set in the data, a copy
+// of the data with only that field changed
+func variants(data PathData) []pathVariant {
+	result := []pathVariant{}
+	if data.PromiseName != "" {
+		variant := data
+		variant.PromiseName += "-variant"
+		result = append(result, pathVariant{"PromiseName", variant})
+	}
+	if data.ResourceNamespace != "" {
+		variant := data
+		variant.ResourceNamespace += "-variant"
+		result = append(result, pathVariant{"ResourceNamespace", variant})
+	}
+	if data.ResourceName != "" {
+		variant := data
+		variant.ResourceName += "-variant"
+		result = append(result, pathVariant{"ResourceName", variant})
+	}
+	if data.ID != "" {
+		variant := data
+		variant.ID += "-variant"
+		result = append(result, pathVariant{"ID", variant})
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package writers_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/lib/writers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Layout", func() {
+	var (
+		destination  platformv1alpha1.Destination
+		stateStore   platformv1alpha1.StateStoreCoreFields
+		resource     writers.PathData
+		dependencies writers.PathData
+	)
+
+	BeforeEach(func() {
+		destination = platformv1alpha1.Destination{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "worker-1",
+				Labels: map[string]string{"environment": "dev"},
+			},
+			Spec: platformv1alpha1.DestinationSpec{
+				StateStoreCoreFields: platformv1alpha1.StateStoreCoreFields{Path: "clusters"},
+			},
+		}
+		stateStore = platformv1alpha1.StateStoreCoreFields{Path: "kratix"}
+		resource = writers.PathData{
+			Type:              writers.ResourcesType,
+			PromiseName:       "redis",
+			ResourceNamespace: "default",
+			ResourceName:      "my-redis",
+			ID:                "5058f",
+		}
+		dependencies = writers.PathData{
+			Type:        writers.DependenciesType,
+			PromiseName: "redis",
+			ID:          "5058f",
+		}
+	})
+
+	When("no PathTemplate is set", func() {
+		It("uses the default layout", func() {
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layout.Dir(resource)).To(Equal("clusters/worker-1/resources/default/redis/my-redis/5058f"))
+			Expect(layout.Dir(dependencies)).To(Equal("clusters/worker-1/dependencies/redis/5058f"))
+			Expect(layout.TypeDir(writers.ResourcesType)).To(Equal("clusters/worker-1/resources"))
+			Expect(layout.TypeDir(writers.DependenciesType)).To(Equal("clusters/worker-1/dependencies"))
+		})
+	})
+
+	When("a PathTemplate is set", func() {
+		BeforeEach(func() {
+			stateStore.PathTemplate = "{{ .Destination.Labels.environment }}/{{ .Destination.Name }}/{{ .Type }}/{{ .PromiseName }}/{{ .ResourceNamespace }}-{{ .ResourceName }}-{{ .ID }}"
+		})
+
+		It("renders the directories from the template of the State Store", func() {
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layout.Dir(resource)).To(Equal("dev/worker-1/resources/redis/default-my-redis-5058f"))
+			Expect(layout.Dir(dependencies)).To(Equal("dev/worker-1/dependencies/redis/--5058f"))
+			Expect(layout.TypeDir(writers.ResourcesType)).To(Equal("dev/worker-1/resources"))
+		})
+
+		It("prefers the template of the Destination", func() {
+			destination.Spec.PathTemplate = "apps/{{ .Destination.Name }}/{{ .Type }}/{{ .PromiseName }}/{{ .ResourceNamespace }}/{{ .ResourceName }}/{{ .ID }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layout.Dir(resource)).To(Equal("apps/worker-1/resources/redis/default/my-redis/5058f"))
+			Expect(layout.Dir(dependencies)).To(Equal("apps/worker-1/dependencies/redis/5058f"))
+		})
+
+		It("ignores the template for the default layout", func() {
+			Expect(writers.DefaultLayout(destination).Dir(resource)).To(Equal("clusters/worker-1/resources/default/redis/my-redis/5058f"))
+		})
+
+		It("errors when the template does not use the Destination name", func() {
+			stateStore.PathTemplate = "{{ .Type }}/{{ .PromiseName }}/{{ .ID }}"
+			_, err := writers.NewLayout(stateStore, destination)
+			Expect(err).To(MatchError(ContainSubstring("must use .Destination.Name")))
+		})
+
+		It("errors when the template is invalid", func() {
+			stateStore.PathTemplate = "{{ .Destination.Name "
+			_, err := writers.NewLayout(stateStore, destination)
+			Expect(err).To(MatchError(ContainSubstring("invalid pathTemplate")))
+		})
+
+		It("errors when the template uses an unknown field", func() {
+			stateStore.PathTemplate = "{{ .Destination.Name }}/{{ .Destination.Labels.region }}/{{ .Type }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = layout.TypeDir(writers.ResourcesType)
+			Expect(err).To(MatchError(ContainSubstring("failed to render pathTemplate")))
+		})
+
+		It("errors when the template does not render the type as a directory", func() {
+			stateStore.PathTemplate = "{{ .Destination.Name }}/{{ .Type }}-{{ .PromiseName }}/{{ .ResourceNamespace }}/{{ .ResourceName }}/{{ .ID }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = layout.TypeDir(writers.ResourcesType)
+			Expect(err).To(MatchError(ContainSubstring("must use .Type as a directory")))
+		})
+
+		It("errors when the directory of the type does not use the Destination name", func() {
+			stateStore.PathTemplate = "{{ .Type }}/{{ .Destination.Name }}/{{ .PromiseName }}/{{ .ResourceNamespace }}/{{ .ResourceName }}/{{ .ID }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = layout.TypeDir(writers.ResourcesType)
+			Expect(err).To(MatchError("pathTemplate renders resources for any .Destination.Name, it must use .Destination.Name before .Type"))
+			_, err = layout.Dir(resource)
+			Expect(err).To(MatchError(ContainSubstring("it must use .Destination.Name before .Type")))
+		})
+
+		It("errors when a directory is outside the State Store path", func() {
+			stateStore.PathTemplate = "../{{ .Destination.Name }}/{{ .Type }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = layout.TypeDir(writers.ResourcesType)
+			Expect(err).To(MatchError(ContainSubstring("not within the State Store path")))
+		})
+
+		It("errors when a group of workloads is outside the directory of its type", func() {
+			stateStore.PathTemplate = "{{ .Destination.Name }}/{{ if .PromiseName }}{{ .PromiseName }}/{{ .ResourceName }}-{{ .ResourceNamespace }}-{{ .ID }}/{{ end }}{{ .Type }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = layout.Dir(resource)
+			Expect(err).To(MatchError(ContainSubstring("not within worker-1/resources")))
+		})
+
+		It("errors when groups of workloads would share a directory", func() {
+			stateStore.PathTemplate = "{{ .Destination.Name }}/{{ .Type }}/{{ .PromiseName }}/{{ .ResourceName }}"
+			layout, err := writers.NewLayout(stateStore, destination)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = layout.Dir(resource)
+			Expect(err).To(MatchError("pathTemplate renders worker-1/resources/redis/my-redis for any .ResourceNamespace, it must use .ResourceNamespace"))
+		})
+	})
+})
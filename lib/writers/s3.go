@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -17,6 +18,10 @@ import (
 const (
 	AuthMethodIAM       = "IAM"
 	AuthMethodAccessKey = "accessKey"
+
+	// modeMetadataKey is the user metadata holding the file mode of objects,
+	// as buckets have no notion of file modes
+	modeMetadataKey = "Mode"
 )
 
 type S3Writer struct {
@@ -68,7 +73,7 @@ func NewS3Writer(logger logr.Logger, stateStoreSpec platformv1alpha1.BucketState
 		Log:        logger,
 		RepoClient: minioClient,
 		BucketName: stateStoreSpec.BucketName,
-		path:       filepath.Join(stateStoreSpec.Path, destination.Spec.Path, destination.Name),
+		path:       stateStoreSpec.Path,
 	}, nil
 }
 
@@ -119,14 +124,16 @@ func (b *S3Writer) WriteDirWithObjects(deleteExistingContentsInDir bool, dir str
 			logger.Info("Object does not exist yet")
 		} else {
 			contentMd5 := fmt.Sprintf("%x", md5.Sum([]byte(item.Content)))
-			if objStat.ETag == contentMd5 {
+			if objStat.ETag == contentMd5 && objectMode(objStat) == fileMode(item.FileMode()) {
 				logger.Info("Content has not changed, will not re-write to bucket")
 				continue
 			}
 		}
 
 		logger.Info("Writing object to bucket")
-		_, err = b.RepoClient.PutObject(ctx, b.BucketName, objectFullPath, reader, reader.Size(), minio.PutObjectOptions{})
+		_, err = b.RepoClient.PutObject(ctx, b.BucketName, objectFullPath, reader, reader.Size(), minio.PutObjectOptions{
+			UserMetadata: map[string]string{modeMetadataKey: fileMode(item.FileMode())},
+		})
 		if err != nil {
 			logger.Error(err, "Error writing object to bucket")
 			return err
@@ -228,3 +235,16 @@ func (b *S3Writer) RemoveObject(objectName string) error {
 
 	return nil
 }
+
+// objectMode returns the file mode stored on the object, defaulting for
+// objects written before modes were stored
+func objectMode(objInfo minio.ObjectInfo) string {
+	if mode, ok := objInfo.UserMetadata[modeMetadataKey]; ok {
+		return mode
+	}
+	return fileMode(platformv1alpha1.DefaultWorkloadFileMode)
+}
+
+func fileMode(mode os.FileMode) string {
+	return fmt.Sprintf("%#o", mode.Perm())
+}
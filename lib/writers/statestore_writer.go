@@ -7,6 +7,8 @@ const (
 	PreserveExistingContentsInDir = false
 )
 
+// StateStoreWriter writes to the Path of a State Store. The directories of
+// a Destination within it are decided by its Layout.
 type StateStoreWriter interface {
 	WriteDirWithObjects(deleteExistingContentsInDir bool, dir string, workloads ...platformv1alpha1.Workload) error
 	RemoveObject(objectName string) error
@@ -25,10 +25,12 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -52,17 +54,31 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var pipelineRunHistoryLimit int
+	var pipelineRunLogTailLines int64
+	var enableClusterAPIDestinations bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&pipelineRunHistoryLimit, "pipeline-run-history-limit", controllers.DefaultPipelineRunHistoryLimit,
+		"The number of finished PipelineRuns to keep for each workflow of a Promise or resource.")
+	flag.Int64Var(&pipelineRunLogTailLines, "pipeline-run-log-tail-lines", controllers.DefaultPipelineRunLogTailLines,
+		"The number of log lines to capture for each container of a finished pipeline.")
+	flag.BoolVar(&enableClusterAPIDestinations, "enable-cluster-api-destinations", false,
+		"Register Destinations for the Cluster API Clusters selected by DestinationTemplates. "+
+			"The Cluster API CRDs must be installed on the platform.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	if enableClusterAPIDestinations {
+		utilruntime.Must(clusterv1.AddToScheme(scheme.Scheme))
+	}
+
 	ctx, cancelManagerCtxFunc := context.WithCancel(context.Background())
 	restartManager := false
 	for {
@@ -92,9 +108,26 @@ func main() {
 			os.Exit(1)
 		}
 
+		if err = controllers.SetupIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+			setupLog.Error(err, "unable to set up field indexes")
+			os.Exit(1)
+		}
+
+		destinationInformer, err := mgr.GetCache().GetInformer(ctx, &platformv1alpha1.Destination{})
+		if err != nil {
+			setupLog.Error(err, "unable to get Destination informer")
+			os.Exit(1)
+		}
+		destinationSnapshot := controllers.NewDestinationSnapshot()
+		if err = destinationSnapshot.Watch(destinationInformer); err != nil {
+			setupLog.Error(err, "unable to watch Destinations")
+			os.Exit(1)
+		}
+
 		scheduler := controllers.Scheduler{
-			Client: mgr.GetClient(),
-			Log:    ctrl.Log.WithName("controllers").WithName("Scheduler"),
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Scheduler"),
+			Destinations: destinationSnapshot,
 		}
 
 		if err = (&controllers.PromiseReconciler{
@@ -120,20 +153,30 @@ func main() {
 			os.Exit(1)
 		}
 		if err = (&controllers.DestinationReconciler{
-			Client:    mgr.GetClient(),
-			Scheduler: &scheduler,
-			Log:       ctrl.Log.WithName("controllers").WithName("DestinationController"),
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("DestinationController"),
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Destination")
 			os.Exit(1)
 		}
 		if err = (&controllers.WorkPlacementReconciler{
-			Client: mgr.GetClient(),
-			Log:    ctrl.Log.WithName("controllers").WithName("WorkPlacementController"),
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Log:       ctrl.Log.WithName("controllers").WithName("WorkPlacementController"),
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "WorkPlacement")
 			os.Exit(1)
 		}
+		if err = (&controllers.PipelineRunReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("PipelineRunController"),
+			PodLogReader: controllers.NewPodLogReader(kubernetes.NewForConfigOrDie(config)),
+			HistoryLimit: pipelineRunHistoryLimit,
+			LogTailLines: pipelineRunLogTailLines,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PipelineRun")
+			os.Exit(1)
+		}
 		if err = (&platformv1alpha1.Promise{}).SetupWebhookWithManager(mgr, apiextensionsClient, mgr.GetClient()); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Promise")
 			os.Exit(1)
@@ -147,6 +190,16 @@ func main() {
 			setupLog.Error(err, "unable to create controller", "controller", "PromiseRelease")
 			os.Exit(1)
 		}
+		if enableClusterAPIDestinations {
+			if err = (&controllers.DestinationTemplateReconciler{
+				Client: mgr.GetClient(),
+				Scheme: mgr.GetScheme(),
+				Log:    ctrl.Log.WithName("controllers").WithName("DestinationTemplateController"),
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "DestinationTemplate")
+				os.Exit(1)
+			}
+		}
 		//+kubebuilder:scaffold:builder
 
 		if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
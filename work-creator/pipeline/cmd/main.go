@@ -1,31 +1,68 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/syntasso/kratix/api/v1alpha1"
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
 	"github.com/syntasso/kratix/work-creator/pipeline"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
+const usage = `Usage:
+  work-creator [flags]            create the Work from the pipeline output
+  work-creator reader [flags]     write the object the pipeline is running for to the input directory
+  work-creator update-status      write the pipeline status to the object the pipeline ran for
+
+The reader and update-status subcommands read the object to act on from the
+OBJECT_KIND, OBJECT_GROUP, OBJECT_NAME and OBJECT_NAMESPACE environment variables.
+`
+
 func main() {
+	ctrl.SetLogger(zap.New())
+
+	//Teach our client to speak platformv1alpha1.Work
+	platformv1alpha1.AddToScheme(scheme.Scheme)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reader":
+			runReader(os.Args[2:])
+			return
+		case "update-status":
+			runUpdateStatus(os.Args[2:])
+			return
+		case "help", "-h", "--help":
+			fmt.Print(usage)
+			return
+		}
+	}
+	runWorkCreator(os.Args[1:])
+}
+
+func runWorkCreator(args []string) {
 	var inputDirectoy string
 	var promiseName string
 	var namespace string
 	var resourceName string
 	var workflowType string
+	var pipelineRunName string
 
-	flag.StringVar(&inputDirectoy, "input-directory", "", "Absolute path to directory containing yaml documents required to build Work")
-	flag.StringVar(&promiseName, "promise-name", "", "Name of the promise")
-	flag.StringVar(&namespace, "namespace", v1alpha1.KratixSystemNamespace, "Namespace")
-	flag.StringVar(&resourceName, "resource-name", "", "Name of the resource")
-	flag.StringVar(&workflowType, "workflow-type", "resource", "Create a Work for Promise or Resource type scheduling")
-	flag.Parse()
+	flags := flag.NewFlagSet("work-creator", flag.ExitOnError)
+	flags.StringVar(&inputDirectoy, "input-directory", "", "Absolute path to directory containing yaml documents required to build Work")
+	flags.StringVar(&promiseName, "promise-name", "", "Name of the promise")
+	flags.StringVar(&namespace, "namespace", v1alpha1.KratixSystemNamespace, "Namespace")
+	flags.StringVar(&resourceName, "resource-name", "", "Name of the resource")
+	flags.StringVar(&workflowType, "workflow-type", "resource", "Create a Work for Promise or Resource type scheduling")
+	flags.StringVar(&pipelineRunName, "pipeline-run-name", "", "Name of the pipeline run")
+	flags.Parse(args)
 
 	if inputDirectoy == "" {
 		fmt.Println("Must provide -input-directory")
@@ -37,26 +74,79 @@ func main() {
 		os.Exit(1)
 	}
 
-	//Teach our client to speak platformv1alpha1.Work
-	platformv1alpha1.AddToScheme(scheme.Scheme)
-
-	k8sClient, err := getClient()
+	workCreator := pipeline.WorkCreator{
+		K8sClient:       getClient(),
+		PipelineRunName: pipelineRunName,
+	}
+	if ref := pipeline.ObjectReferenceFromEnv(); ref.Validate() == nil {
+		workCreator.Object = &ref
+	}
+	err := workCreator.Execute(inputDirectoy, promiseName, namespace, resourceName, workflowType)
 	if err != nil {
-		fmt.Println("Error creating k8s client")
+		fmt.Println(err.Error())
 		os.Exit(1)
 	}
+}
 
-	workCreator := pipeline.WorkCreator{
-		K8sClient: k8sClient,
+func runReader(args []string) {
+	var inputDirectory string
+	var outputDirectory string
+	var promiseConfigDirectory string
+
+	flags := flag.NewFlagSet("reader", flag.ExitOnError)
+	flags.StringVar(&inputDirectory, "input-directory", "/kratix/input", "Directory to write the object to")
+	flags.StringVar(&outputDirectory, "output-directory", "/kratix/output", "Directory to write the Promise dependencies to")
+	flags.StringVar(&promiseConfigDirectory, "promise-config-directory", kratixpipeline.PromiseConfigDirectory, "Directory containing the Promise config provided by Kratix to resource workflows")
+	flags.Parse(args)
+
+	ref := pipeline.ObjectReferenceFromEnv()
+	if err := ref.Validate(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
-	err = workCreator.Execute(inputDirectoy, promiseName, namespace, resourceName, workflowType)
+
+	reader := pipeline.Reader{
+		K8sClient:              getClient(),
+		PromiseConfigDirectory: promiseConfigDirectory,
+		PromiseName:            os.Getenv("KRATIX_PROMISE_NAME"),
+	}
+	err := reader.Execute(context.Background(), ref, os.Getenv("KRATIX_WORKFLOW_TYPE"), inputDirectory, outputDirectory)
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 }
 
-func getClient() (client.Client, error) {
+func runUpdateStatus(args []string) {
+	var metadataDirectory string
+	var kratixSystemDirectory string
+
+	flags := flag.NewFlagSet("update-status", flag.ExitOnError)
+	flags.StringVar(&metadataDirectory, "metadata-directory", "/work-creator-files/metadata", "Directory containing the status.yaml written by the pipeline")
+	flags.StringVar(&kratixSystemDirectory, "kratix-system-directory", "/work-creator-files/kratix-system", "Directory containing the status contract provided by Kratix")
+	flags.Parse(args)
+
+	ref := pipeline.ObjectReferenceFromEnv()
+	if err := ref.Validate(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	statusWriter := pipeline.StatusWriter{
+		K8sClient: getClient(),
+	}
+	if err := statusWriter.Execute(context.Background(), ref, metadataDirectory, kratixSystemDirectory); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+func getClient() client.Client {
 	config := ctrl.GetConfigOrDie()
-	return client.New(config, client.Options{Scheme: scheme.Scheme})
+	k8sClient, err := client.New(config, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		fmt.Println("Error creating k8s client")
+		os.Exit(1)
+	}
+	return k8sClient
 }
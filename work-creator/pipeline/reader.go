@@ -0,0 +1,307 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
+	"github.com/syntasso/kratix/lib/workloadcontent"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ObjectReference identifies the object (Promise or resource request) a
+// pipeline is running for. Kind is the lowercased kind, as set by Kratix on
+// the pipeline containers.
+type ObjectReference struct {
+	Kind      string
+	Group     string
+	Name      string
+	Namespace string
+}
+
+func ObjectReferenceFromEnv() ObjectReference {
+	return ObjectReference{
+		Kind:      os.Getenv("OBJECT_KIND"),
+		Group:     os.Getenv("OBJECT_GROUP"),
+		Name:      os.Getenv("OBJECT_NAME"),
+		Namespace: os.Getenv("OBJECT_NAMESPACE"),
+	}
+}
+
+func (o ObjectReference) Validate() error {
+	var missing []string
+	if o.Kind == "" {
+		missing = append(missing, "OBJECT_KIND")
+	}
+	if o.Group == "" {
+		missing = append(missing, "OBJECT_GROUP")
+	}
+	if o.Name == "" {
+		missing = append(missing, "OBJECT_NAME")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required object reference: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+type Reader struct {
+	K8sClient client.Client
+	// PromiseConfigDirectory is where Kratix mounts the Promise config and the
+	// Promise for resource workflows
+	PromiseConfigDirectory string
+	// PromiseName is the name of the Promise of resource workflows, used to
+	// find the Work previously created for the resource
+	PromiseName string
+}
+
+// Execute writes the object the pipeline is running for to
+// <inputDirectory>/object.yaml. For resource workflows, the Promise config and
+// the Promise are also written to <inputDirectory>/promise-config.yaml and
+// <inputDirectory>/promise.yaml, and the workloads of the previous Work for
+// the resource to <inputDirectory>/previous. For Promise workflows, the
+// Promise dependencies are written to <outputDirectory>/static/dependencies.yaml
+// and those of each DependencyGroup to <outputDirectory>/<directory>/dependencies.yaml
+func (r *Reader) Execute(ctx context.Context, ref ObjectReference, workflowType, inputDirectory, outputDirectory string) error {
+	logger := ctrl.Log.WithName("reader").
+		WithValues("kind", ref.Kind).
+		WithValues("name", ref.Name).
+		WithValues("namespace", ref.Namespace)
+
+	obj, err := getObject(ctx, r.K8sClient, ref)
+	if err != nil {
+		return err
+	}
+
+	objectContent, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Errorf("failed to marshal object: %w", err)
+	}
+
+	objectPath := filepath.Join(inputDirectory, "object.yaml")
+	if err := os.WriteFile(objectPath, objectContent, 0644); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	logger.Info("Object written", "path", objectPath)
+
+	if workflowType != platformv1alpha1.KratixWorkflowTypePromise {
+		if err := r.writePromiseConfig(ctx, logger, inputDirectory); err != nil {
+			return err
+		}
+		if err := r.copyPromiseConfigFile(logger, kratixpipeline.PromiseFile, inputDirectory); err != nil {
+			return err
+		}
+		return r.writePreviousWorkloads(ctx, logger, ref, filepath.Join(inputDirectory, "previous"))
+	}
+
+	dependencies, found, err := unstructured.NestedSlice(obj.Object, "spec", "dependencies")
+	if err != nil {
+		return fmt.Errorf("failed to read dependencies: %w", err)
+	}
+	if found {
+		if err := writeDependencies(logger, dependencies, filepath.Join(outputDirectory, "static")); err != nil {
+			return err
+		}
+	}
+
+	dependencyGroups, _, err := unstructured.NestedSlice(obj.Object, "spec", "dependencyGroups")
+	if err != nil {
+		return fmt.Errorf("failed to read dependency groups: %w", err)
+	}
+	for _, dependencyGroup := range dependencyGroups {
+		group, ok := dependencyGroup.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid dependency group: %v", dependencyGroup)
+		}
+		directory, _, err := unstructured.NestedString(group, "directory")
+		if err != nil {
+			return fmt.Errorf("failed to read dependency group directory: %w", err)
+		}
+		dependencies, _, err := unstructured.NestedSlice(group, "dependencies")
+		if err != nil {
+			return fmt.Errorf("failed to read dependencies of group %s: %w", directory, err)
+		}
+		if err := writeDependencies(logger, dependencies, filepath.Join(outputDirectory, filepath.Clean(directory))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDependencies writes the dependencies to a multi-document
+// dependencies.yaml in the directory
+func writeDependencies(logger logr.Logger, dependencies []interface{}, directory string) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", directory, err)
+	}
+
+	var documents []string
+	for _, dependency := range dependencies {
+		document, err := yaml.Marshal(dependency)
+		if err != nil {
+			return fmt.Errorf("failed to marshal dependency: %w", err)
+		}
+		documents = append(documents, string(document))
+	}
+
+	dependenciesPath := filepath.Join(directory, platformv1alpha1.DependenciesFile)
+	if err := os.WriteFile(dependenciesPath, []byte(strings.Join(documents, "---\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write dependencies: %w", err)
+	}
+	logger.Info("Dependencies written", "path", dependenciesPath, "count", len(documents))
+	return nil
+}
+
+// writePromiseConfig writes the Promise config provided by Kratix, with the
+// data of the Secrets it references, to <inputDirectory>/promise-config.yaml
+func (r *Reader) writePromiseConfig(ctx context.Context, logger logr.Logger, inputDirectory string) error {
+	if r.PromiseConfigDirectory == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(r.PromiseConfigDirectory, kratixpipeline.PromiseConfigFile))
+	if os.IsNotExist(err) {
+		logger.Info("File not provided by Kratix", "file", kratixpipeline.PromiseConfigFile)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", kratixpipeline.PromiseConfigFile, err)
+	}
+
+	source := kratixpipeline.PromiseConfigSource{}
+	if err := yaml.Unmarshal(content, &source); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", kratixpipeline.PromiseConfigFile, err)
+	}
+
+	promiseConfig := kratixpipeline.PromiseConfig{Config: source.Config}
+	for _, ref := range source.SecretRefs {
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Name: ref.SecretRef.Name, Namespace: ref.SecretRef.Namespace}
+		if err := r.K8sClient.Get(ctx, key, secret); err != nil {
+			return fmt.Errorf("failed to get secret %s for promise config %s: %w", key, ref.Name, err)
+		}
+
+		if promiseConfig.Secrets == nil {
+			promiseConfig.Secrets = map[string]map[string]string{}
+		}
+		data := map[string]string{}
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+		promiseConfig.Secrets[ref.Name] = data
+	}
+
+	promiseConfigYAML, err := yaml.Marshal(promiseConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal promise config: %w", err)
+	}
+
+	path := filepath.Join(inputDirectory, kratixpipeline.PromiseConfigFile)
+	if err := os.WriteFile(path, promiseConfigYAML, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", kratixpipeline.PromiseConfigFile, err)
+	}
+	logger.Info("File written", "path", path, "secrets", len(source.SecretRefs))
+	return nil
+}
+
+func (r *Reader) copyPromiseConfigFile(logger logr.Logger, file, inputDirectory string) error {
+	if r.PromiseConfigDirectory == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(r.PromiseConfigDirectory, file))
+	if os.IsNotExist(err) {
+		logger.Info("File not provided by Kratix", "file", file)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	path := filepath.Join(inputDirectory, file)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	logger.Info("File written", "path", path)
+	return nil
+}
+
+// writePreviousWorkloads writes the workloads of the Work created by the
+// previous run of the pipeline, if any, keeping their paths in the output
+func (r *Reader) writePreviousWorkloads(ctx context.Context, logger logr.Logger, ref ObjectReference, previousDirectory string) error {
+	if r.PromiseName == "" {
+		return nil
+	}
+
+	work := &platformv1alpha1.Work{}
+	workName := fmt.Sprintf("%s-%s", r.PromiseName, ref.Name)
+	err := r.K8sClient.Get(ctx, types.NamespacedName{Name: workName, Namespace: ref.Namespace}, work)
+	if errors.IsNotFound(err) {
+		logger.Info("No previous Work found", "work", workName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get previous Work %s: %w", workName, err)
+	}
+
+	count := 0
+	for _, workloadGroup := range work.Spec.WorkloadGroups {
+		workloads, err := workloadcontent.Decode(ctx, r.K8sClient, work.GetNamespace(), workloadGroup.Workloads)
+		if err != nil {
+			return fmt.Errorf("failed to read previous workloads: %w", err)
+		}
+		for _, workload := range workloads {
+			path := filepath.Join(previousDirectory, filepath.Clean("/"+workload.Filepath))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create directory for previous workload %s: %w", workload.Filepath, err)
+			}
+			if err := os.WriteFile(path, []byte(workload.Content), workload.FileMode()); err != nil {
+				return fmt.Errorf("failed to write previous workload %s: %w", workload.Filepath, err)
+			}
+			count++
+		}
+	}
+	logger.Info("Previous workloads written", "path", previousDirectory, "work", workName, "count", count)
+	return nil
+}
+
+func getObject(ctx context.Context, k8sClient client.Client, ref ObjectReference) (*unstructured.Unstructured, error) {
+	gvk, err := k8sClient.RESTMapper().KindFor(schema.GroupVersionResource{
+		Group:    ref.Group,
+		Resource: ref.Kind,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s.%s: %w", ref.Kind, ref.Group, err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+
+	namespace := ref.Namespace
+	namespaced, err := k8sClient.IsObjectNamespaced(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine scope of %s: %w", gvk.Kind, err)
+	}
+	if !namespaced {
+		// Kratix always provides a namespace, including for cluster-scoped objects
+		namespace = ""
+	}
+
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, ref.Name, err)
+	}
+	return obj, nil
+}
@@ -0,0 +1,273 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	"github.com/syntasso/kratix/work-creator/pipeline"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+var redisGVK = schema.GroupVersionKind{Group: "marketplace.kratix.io", Version: "v1alpha1", Kind: "Redis"}
+
+var _ = Describe("Reader", func() {
+	var (
+		ctx             context.Context
+		reader          pipeline.Reader
+		inputDirectory  string
+		outputDirectory string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		inputDirectory = GinkgoT().TempDir()
+		outputDirectory = GinkgoT().TempDir()
+	})
+
+	When("reading a resource request", func() {
+		BeforeEach(func() {
+			reader = pipeline.Reader{K8sClient: newClientWithRESTMapper(newRedis())}
+		})
+
+		It("writes the object to the input directory", func() {
+			ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+			Expect(reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)).To(Succeed())
+
+			object := readYAML(filepath.Join(inputDirectory, "object.yaml"))
+			Expect(object).To(HaveKeyWithValue("kind", "Redis"))
+			Expect(object["spec"]).To(HaveKeyWithValue("size", "small"))
+			Expect(filepath.Join(outputDirectory, "static")).NotTo(BeADirectory())
+		})
+
+		It("errors when the object does not exist", func() {
+			ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "missing", Namespace: "default"}
+			err := reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)
+			Expect(err).To(MatchError(ContainSubstring("failed to get Redis missing")))
+		})
+
+		It("errors when the kind cannot be resolved", func() {
+			ref := pipeline.ObjectReference{Kind: "postgres", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+			err := reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)
+			Expect(err).To(MatchError(ContainSubstring("failed to resolve postgres.marketplace.kratix.io")))
+		})
+
+		When("Kratix provides the Promise config", func() {
+			BeforeEach(func() {
+				reader.PromiseConfigDirectory = GinkgoT().TempDir()
+				Expect(os.WriteFile(filepath.Join(reader.PromiseConfigDirectory, "promise-config.yaml"), []byte("config:\n  region: eu-west-2\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(reader.PromiseConfigDirectory, "promise.yaml"), []byte("kind: Promise\n"), 0644)).To(Succeed())
+			})
+
+			It("writes it, and the Promise, to the input directory", func() {
+				ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+				Expect(reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)).To(Succeed())
+
+				promiseConfig := readYAML(filepath.Join(inputDirectory, "promise-config.yaml"))
+				Expect(promiseConfig["config"]).To(HaveKeyWithValue("region", "eu-west-2"))
+				Expect(readYAML(filepath.Join(inputDirectory, "promise.yaml"))).To(HaveKeyWithValue("kind", "Promise"))
+			})
+
+			It("reads the Secrets referenced by the config", func() {
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: "kratix-platform-system"},
+					Data:       map[string][]byte{"password": []byte("secret")},
+				}
+				reader.K8sClient = newClientWithRESTMapper(newRedis(), secret)
+				Expect(os.WriteFile(filepath.Join(reader.PromiseConfigDirectory, "promise-config.yaml"), []byte(`
+config:
+  region: eu-west-2
+secretRefs:
+- name: registry
+  secretRef:
+    name: registry-credentials
+    namespace: kratix-platform-system
+`), 0644)).To(Succeed())
+
+				ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+				Expect(reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)).To(Succeed())
+
+				content, err := os.ReadFile(filepath.Join(inputDirectory, "promise-config.yaml"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(MatchYAML(`
+config:
+  region: eu-west-2
+secrets:
+  registry:
+    password: secret
+`))
+			})
+
+			It("errors when a referenced Secret cannot be read", func() {
+				Expect(os.WriteFile(filepath.Join(reader.PromiseConfigDirectory, "promise-config.yaml"), []byte(`
+secretRefs:
+- name: registry
+  secretRef:
+    name: registry-credentials
+    namespace: kratix-platform-system
+`), 0644)).To(Succeed())
+
+				ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+				err := reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)
+				Expect(err).To(MatchError(ContainSubstring("failed to get secret kratix-platform-system/registry-credentials for promise config registry")))
+			})
+		})
+
+		When("a Work exists from a previous run", func() {
+			BeforeEach(func() {
+				work := &platformv1alpha1.Work{
+					ObjectMeta: metav1.ObjectMeta{Name: "redis-example", Namespace: "default"},
+					Spec: platformv1alpha1.WorkSpec{
+						WorkloadCoreFields: platformv1alpha1.WorkloadCoreFields{
+							WorkloadGroups: []platformv1alpha1.WorkloadGroup{
+								{Directory: ".", Workloads: []platformv1alpha1.Workload{
+									{Filepath: "redis.yaml", Content: "kind: Redis\n"},
+								}},
+								{Directory: "monitoring", Workloads: []platformv1alpha1.Workload{
+									{Filepath: "monitoring/dashboard.yaml", Content: "kind: ConfigMap\n"},
+									{Filepath: "../escape.yaml", Content: "kind: Secret\n"},
+								}},
+							},
+						},
+					},
+				}
+				reader = pipeline.Reader{K8sClient: newClientWithRESTMapper(newRedis(), work), PromiseName: "redis"}
+			})
+
+			It("writes its workloads to the previous directory", func() {
+				ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+				Expect(reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)).To(Succeed())
+
+				previousDirectory := filepath.Join(inputDirectory, "previous")
+				Expect(readYAML(filepath.Join(previousDirectory, "redis.yaml"))).To(HaveKeyWithValue("kind", "Redis"))
+				Expect(readYAML(filepath.Join(previousDirectory, "monitoring", "dashboard.yaml"))).To(HaveKeyWithValue("kind", "ConfigMap"))
+				Expect(filepath.Join(previousDirectory, "escape.yaml")).To(BeAnExistingFile())
+				Expect(filepath.Join(inputDirectory, "escape.yaml")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		It("does not write previous workloads when there is no previous Work", func() {
+			reader.PromiseName = "redis"
+			ref := pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+			Expect(reader.Execute(ctx, ref, "resource", inputDirectory, outputDirectory)).To(Succeed())
+			Expect(filepath.Join(inputDirectory, "previous")).NotTo(BeADirectory())
+		})
+	})
+
+	When("reading a Promise", func() {
+		var ref pipeline.ObjectReference
+
+		BeforeEach(func() {
+			ref = pipeline.ObjectReference{Kind: "promise", Group: "platform.kratix.io", Name: "redis", Namespace: platformv1alpha1.KratixSystemNamespace}
+		})
+
+		It("writes the Promise dependencies as a multi-document file", func() {
+			promise := newPromise()
+			promise.Spec.Dependencies = platformv1alpha1.Dependencies{
+				{Unstructured: unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]interface{}{"name": "redis-operator"},
+				}}},
+				{Unstructured: unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "redis-config"},
+				}}},
+			}
+			reader = pipeline.Reader{K8sClient: newClientWithRESTMapper(promise)}
+
+			Expect(reader.Execute(ctx, ref, "promise", inputDirectory, outputDirectory)).To(Succeed())
+
+			object := readYAML(filepath.Join(inputDirectory, "object.yaml"))
+			Expect(object).To(HaveKeyWithValue("kind", "Promise"))
+
+			dependencies, err := os.ReadFile(filepath.Join(outputDirectory, "static", "dependencies.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dependencies)).To(ContainSubstring("name: redis-operator"))
+			Expect(string(dependencies)).To(ContainSubstring("---\n"))
+			Expect(string(dependencies)).To(ContainSubstring("name: redis-config"))
+		})
+
+		It("writes the dependencies of each dependency group to its directory", func() {
+			promise := newPromise()
+			promise.Spec.DependencyGroups = []platformv1alpha1.DependencyGroup{
+				{
+					Directory: "operator/",
+					Dependencies: platformv1alpha1.Dependencies{
+						{Unstructured: unstructured.Unstructured{Object: map[string]interface{}{
+							"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "redis-operator"},
+						}}},
+					},
+				},
+			}
+			reader = pipeline.Reader{K8sClient: newClientWithRESTMapper(promise)}
+
+			Expect(reader.Execute(ctx, ref, "promise", inputDirectory, outputDirectory)).To(Succeed())
+
+			Expect(readYAML(filepath.Join(outputDirectory, "operator", "dependencies.yaml"))).To(HaveKeyWithValue("kind", "Deployment"))
+			Expect(filepath.Join(outputDirectory, "static")).NotTo(BeADirectory())
+		})
+
+		It("does not write dependencies when the Promise has none", func() {
+			reader = pipeline.Reader{K8sClient: newClientWithRESTMapper(newPromise())}
+
+			Expect(reader.Execute(ctx, ref, "promise", inputDirectory, outputDirectory)).To(Succeed())
+			Expect(filepath.Join(inputDirectory, "object.yaml")).To(BeAnExistingFile())
+			Expect(filepath.Join(outputDirectory, "static")).NotTo(BeADirectory())
+		})
+	})
+
+	Describe("ObjectReference", func() {
+		It("requires the kind, group and name", func() {
+			Expect(pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example"}.Validate()).To(Succeed())
+			Expect(pipeline.ObjectReference{Kind: "redis"}.Validate()).To(MatchError(
+				"missing required object reference: OBJECT_GROUP, OBJECT_NAME",
+			))
+		})
+	})
+})
+
+func newClientWithRESTMapper(objs ...client.Object) client.Client {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{platformv1alpha1.GroupVersion, redisGVK.GroupVersion()})
+	mapper.Add(platformv1alpha1.GroupVersion.WithKind("Promise"), meta.RESTScopeRoot)
+	mapper.Add(redisGVK, meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithRESTMapper(mapper).
+		WithObjects(objs...).
+		WithStatusSubresource(objs...).
+		Build()
+}
+
+func newRedis() *unstructured.Unstructured {
+	redis := &unstructured.Unstructured{}
+	redis.SetGroupVersionKind(redisGVK)
+	redis.SetName("example")
+	redis.SetNamespace("default")
+	redis.Object["spec"] = map[string]interface{}{"size": "small"}
+	return redis
+}
+
+func newPromise() *platformv1alpha1.Promise {
+	return &platformv1alpha1.Promise{
+		TypeMeta:   metav1.TypeMeta{APIVersion: platformv1alpha1.GroupVersion.String(), Kind: "Promise"},
+		ObjectMeta: metav1.ObjectMeta{Name: "redis"},
+	}
+}
+
+func readYAML(path string) map[string]interface{} {
+	content, err := os.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	object := map[string]interface{}{}
+	Expect(yaml.Unmarshal(content, &object)).To(Succeed())
+	return object
+}
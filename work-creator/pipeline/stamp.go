@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	goerr "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
+	"github.com/syntasso/kratix/lib/workloadcontent"
+)
+
+// stampWorkloads adds the labels and annotations of the stamp to every
+// manifest in the workloads. Files that are not YAML or JSON, binary files
+// and documents without a kind, such as kustomization files, are left as is.
+// Templates are stamped once rendered for each Destination.
+func stampWorkloads(workloadGroups []platformv1alpha1.WorkloadGroup, stamp workloadcontent.OwnershipStamp) error {
+	for i := range workloadGroups {
+		for j := range workloadGroups[i].Workloads {
+			if err := stamp.Apply(&workloadGroups[i].Workloads[j]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getStampWorkloads returns whether the Promise sets StampWorkloads
+func (w *WorkCreator) getStampWorkloads(rootDirectory string) (bool, error) {
+	file := filepath.Join(rootDirectory, "kratix-system", kratixpipeline.PromiseStampWorkloadsFile)
+	fileContents, err := os.ReadFile(file)
+	if err != nil {
+		if goerr.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	value := strings.TrimSpace(string(fileContents))
+	if value == "" {
+		return false, nil
+	}
+	stamp, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+	}
+	return stamp, nil
+}
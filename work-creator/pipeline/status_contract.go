@@ -0,0 +1,246 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PipelineStatusAPIVersion is the version of the status contract a
+	// pipeline can write to /kratix/metadata/status.yaml. A status file without
+	// an apiVersion is treated as a plain set of status fields.
+	PipelineStatusAPIVersion = "platform.kratix.io/v1alpha1"
+	PipelineStatusKind       = "PipelineStatus"
+
+	pipelineCompletedConditionType = "PipelineCompleted"
+)
+
+// PipelineStatus is the versioned status contract between a pipeline and
+// Kratix
+type PipelineStatus struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	// Fields to merge into the object .status
+	Status map[string]interface{} `json:"status,omitempty"`
+	// Conditions to set on the object, alongside the ones owned by Kratix
+	Conditions []PipelineStatusCondition `json:"conditions,omitempty"`
+	// Named outputs of the pipeline, written to .status.outputs
+	Outputs map[string]PipelineStatusOutput `json:"outputs,omitempty"`
+	// Values for the printer columns of the Promise API, keyed by column name
+	PrinterColumns map[string]interface{} `json:"printerColumns,omitempty"`
+}
+
+type PipelineStatusCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// PipelineStatusOutput is either a plain value or a reference to a Secret key.
+// Sensitive outputs, such as connection details, must be secret references.
+type PipelineStatusOutput struct {
+	Value     string           `json:"value,omitempty"`
+	SecretRef *OutputSecretRef `json:"secretRef,omitempty"`
+}
+
+type OutputSecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// simple dotted paths, e.g. .status.endpoint.host
+var printerColumnPath = regexp.MustCompile(`^\.status(\.[A-Za-z0-9_-]+)+$`)
+
+func readStatusFile(path string) (*PipelineStatus, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &PipelineStatus{Status: map[string]interface{}{"message": defaultStatusMessage}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status file: %w", err)
+	}
+
+	statusValues := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &statusValues); err != nil {
+		return nil, fmt.Errorf("failed to parse status file %s: %w", path, err)
+	}
+
+	apiVersion, versioned := statusValues["apiVersion"]
+	if !versioned {
+		return &PipelineStatus{Status: statusValues}, nil
+	}
+
+	if apiVersion != PipelineStatusAPIVersion {
+		return nil, fmt.Errorf("unsupported status file apiVersion %q, expected %q", apiVersion, PipelineStatusAPIVersion)
+	}
+
+	pipelineStatus := &PipelineStatus{}
+	if err := yaml.UnmarshalStrict(content, pipelineStatus); err != nil {
+		return nil, fmt.Errorf("failed to parse status file %s: %w", path, err)
+	}
+	if pipelineStatus.Kind != PipelineStatusKind {
+		return nil, fmt.Errorf("unsupported status file kind %q, expected %q", pipelineStatus.Kind, PipelineStatusKind)
+	}
+	return pipelineStatus, pipelineStatus.validate()
+}
+
+func (p *PipelineStatus) validate() error {
+	var errs []string
+	for i, condition := range p.Conditions {
+		if condition.Type == "" {
+			errs = append(errs, fmt.Sprintf("conditions[%d].type is required", i))
+		}
+		if condition.Type == pipelineCompletedConditionType {
+			errs = append(errs, fmt.Sprintf("conditions[%d].type %s is reserved", i, pipelineCompletedConditionType))
+		}
+		switch v1.ConditionStatus(condition.Status) {
+		case v1.ConditionTrue, v1.ConditionFalse, v1.ConditionUnknown:
+		default:
+			errs = append(errs, fmt.Sprintf("conditions[%d].status must be one of True, False, Unknown", i))
+		}
+	}
+
+	for name, output := range p.Outputs {
+		if (output.SecretRef == nil) == (output.Value == "") {
+			errs = append(errs, fmt.Sprintf("outputs.%s must set exactly one of value, secretRef", name))
+		}
+		if output.SecretRef != nil && output.SecretRef.Name == "" {
+			errs = append(errs, fmt.Sprintf("outputs.%s.secretRef.name is required", name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid status file: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (p *PipelineStatus) conditions() []*clusterv1.Condition {
+	var conditions []*clusterv1.Condition
+	for _, condition := range p.Conditions {
+		conditions = append(conditions, &clusterv1.Condition{
+			Type:    clusterv1.ConditionType(condition.Type),
+			Status:  v1.ConditionStatus(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+	return conditions
+}
+
+// outputs returns the pipeline outputs in their .status.outputs form. Secret
+// references default to the namespace of the object.
+func (p *PipelineStatus) outputs(namespace string) map[string]interface{} {
+	outputs := map[string]interface{}{}
+	for name, output := range p.Outputs {
+		if output.SecretRef == nil {
+			outputs[name] = map[string]interface{}{"value": output.Value}
+			continue
+		}
+		secretRef := map[string]interface{}{
+			"name":      output.SecretRef.Name,
+			"namespace": output.SecretRef.Namespace,
+		}
+		if output.SecretRef.Namespace == "" {
+			secretRef["namespace"] = namespace
+		}
+		if output.SecretRef.Key != "" {
+			secretRef["key"] = output.SecretRef.Key
+		}
+		outputs[name] = map[string]interface{}{"secretRef": secretRef}
+	}
+	return outputs
+}
+
+func readStatusContract(kratixSystemDirectory string) (*kratixpipeline.StatusContract, error) {
+	path := filepath.Join(kratixSystemDirectory, kratixpipeline.StatusContractFile)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status contract: %w", err)
+	}
+
+	contract := &kratixpipeline.StatusContract{}
+	if err := yaml.Unmarshal(content, contract); err != nil {
+		return nil, fmt.Errorf("failed to parse status contract %s: %w", path, err)
+	}
+	return contract, nil
+}
+
+// setPrinterColumns writes each value to the .status path of the printer
+// column with the same name
+func setPrinterColumns(contract *kratixpipeline.StatusContract, status map[string]interface{}, values map[string]interface{}) error {
+	for name, value := range values {
+		column := findPrinterColumn(contract, name)
+		if column == nil {
+			return fmt.Errorf("printer column %q is not declared by the Promise API", name)
+		}
+		if !printerColumnPath.MatchString(column.JSONPath) {
+			return fmt.Errorf("printer column %q has JSONPath %s, only simple .status paths can be set by pipelines", name, column.JSONPath)
+		}
+
+		fields := strings.Split(strings.TrimPrefix(column.JSONPath, ".status."), ".")
+		current := status
+		for _, f := range fields[:len(fields)-1] {
+			next, ok := current[f].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				current[f] = next
+			}
+			current = next
+		}
+		current[fields[len(fields)-1]] = value
+	}
+	return nil
+}
+
+func findPrinterColumn(contract *kratixpipeline.StatusContract, name string) *apiextensionsv1.CustomResourceColumnDefinition {
+	if contract == nil {
+		return nil
+	}
+	for i, column := range contract.PrinterColumns {
+		if strings.EqualFold(column.Name, name) {
+			return &contract.PrinterColumns[i]
+		}
+	}
+	return nil
+}
+
+// validateStatus validates the complete .status against the status schema of
+// the Promise API
+func validateStatus(contract *kratixpipeline.StatusContract, status map[string]interface{}) error {
+	if contract == nil || contract.Schema == nil {
+		return nil
+	}
+
+	internalSchema := &apiextensions.JSONSchemaProps{}
+	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(contract.Schema, internalSchema, nil); err != nil {
+		return fmt.Errorf("failed to convert status schema: %w", err)
+	}
+
+	validator, _, err := validation.NewSchemaValidator(internalSchema)
+	if err != nil {
+		return fmt.Errorf("failed to build status schema validator: %w", err)
+	}
+
+	if errs := validation.ValidateCustomResource(field.NewPath("status"), status, validator); len(errs) > 0 {
+		return fmt.Errorf("status does not match the Promise API status schema: %w", errs.ToAggregate())
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/syntasso/kratix/lib/resourceutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultStatusMessage = "Resource requested"
+
+type StatusWriter struct {
+	K8sClient client.Client
+}
+
+// Execute merges the status written by the pipeline to
+// <metadataDirectory>/status.yaml into the status of the object, and marks the
+// pipeline as completed. When Kratix provides a status contract in
+// kratixSystemDirectory, the resulting status is validated against it before
+// being written.
+func (s *StatusWriter) Execute(ctx context.Context, ref ObjectReference, metadataDirectory, kratixSystemDirectory string) error {
+	logger := ctrl.Log.WithName("status-writer").
+		WithValues("kind", ref.Kind).
+		WithValues("name", ref.Name).
+		WithValues("namespace", ref.Namespace)
+
+	pipelineStatus, err := readStatusFile(filepath.Join(metadataDirectory, "status.yaml"))
+	if err != nil {
+		return err
+	}
+
+	contract, err := readStatusContract(kratixSystemDirectory)
+	if err != nil {
+		return err
+	}
+
+	obj, err := getObject(ctx, s.K8sClient, ref)
+	if err != nil {
+		return err
+	}
+
+	status, ok := obj.Object["status"].(map[string]interface{})
+	if !ok {
+		status = map[string]interface{}{}
+	}
+	existingConditions, hasConditions := status["conditions"]
+
+	// Conditions are owned by Kratix, pipelines set them through the
+	// conditions of the versioned status contract
+	mergeStatus(status, pipelineStatus.Status)
+	delete(status, "conditions")
+	if hasConditions {
+		status["conditions"] = existingConditions
+	}
+
+	if len(pipelineStatus.Outputs) > 0 {
+		outputs, ok := status["outputs"].(map[string]interface{})
+		if !ok {
+			outputs = map[string]interface{}{}
+		}
+		mergeStatus(outputs, pipelineStatus.outputs(obj.GetNamespace()))
+		status["outputs"] = outputs
+	}
+
+	if err := setPrinterColumns(contract, status, pipelineStatus.PrinterColumns); err != nil {
+		return err
+	}
+	obj.Object["status"] = status
+
+	for _, condition := range pipelineStatus.conditions() {
+		resourceutil.SetCondition(obj, condition)
+	}
+	resourceutil.MarkPipelineAsCompleted(logger, obj)
+
+	if err := validateStatus(contract, obj.Object["status"].(map[string]interface{})); err != nil {
+		return err
+	}
+
+	if err := s.K8sClient.Status().Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	logger.Info("Status updated")
+	return nil
+}
+
+// mergeStatus applies src onto dst following JSON merge patch semantics:
+// nested maps are merged, null values remove the key, everything else replaces
+func mergeStatus(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if value == nil {
+			delete(dst, key)
+			continue
+		}
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeStatus(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,288 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/syntasso/kratix/lib/resourceutil"
+	"github.com/syntasso/kratix/work-creator/pipeline"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("StatusWriter", func() {
+	var (
+		ctx                   context.Context
+		k8sClient             client.Client
+		statusWriter          pipeline.StatusWriter
+		metadataDirectory     string
+		kratixSystemDirectory string
+		ref                   pipeline.ObjectReference
+		redis                 *unstructured.Unstructured
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		metadataDirectory = GinkgoT().TempDir()
+		kratixSystemDirectory = GinkgoT().TempDir()
+		ref = pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+
+		redis = newRedis()
+		redis.Object["status"] = map[string]interface{}{
+			"message": "Pending",
+			"endpoint": map[string]interface{}{
+				"host": "old-host",
+				"port": "6379",
+			},
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":               "PipelineCompleted",
+					"status":             "False",
+					"reason":             "PipelineNotCompleted",
+					"message":            "Pipeline has not completed",
+					"lastTransitionTime": "2024-01-01T00:00:00Z",
+				},
+				map[string]interface{}{
+					"type":               "Ready",
+					"status":             "True",
+					"lastTransitionTime": "2024-01-01T00:00:00Z",
+				},
+			},
+		}
+		k8sClient = newClientWithRESTMapper(redis)
+		statusWriter = pipeline.StatusWriter{K8sClient: k8sClient}
+	})
+
+	getRedis := func() *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(redisGVK)
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "example", Namespace: "default"}, obj)).To(Succeed())
+		return obj
+	}
+
+	It("marks the pipeline as completed, keeping other conditions", func() {
+		Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(Succeed())
+
+		obj := getRedis()
+		Expect(resourceutil.GetPipelineCompletedConditionStatus(obj)).To(Equal(v1.ConditionTrue))
+		Expect(resourceutil.GetCondition(obj, "PipelineCompleted").Reason).To(Equal("PipelineExecutedSuccessfully"))
+		Expect(resourceutil.HasCondition(obj, "Ready")).To(BeTrue())
+	})
+
+	When("the pipeline did not write a status file", func() {
+		It("sets the default message", func() {
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(Succeed())
+
+			message, _, _ := unstructured.NestedString(getRedis().Object, "status", "message")
+			Expect(message).To(Equal("Resource requested"))
+		})
+	})
+
+	When("the pipeline wrote a status file", func() {
+		BeforeEach(func() {
+			status := `
+message: Redis is ready
+endpoint:
+  host: new-host
+replicas: 3
+conditions:
+- type: Injected
+  status: "True"
+`
+			Expect(os.WriteFile(filepath.Join(metadataDirectory, "status.yaml"), []byte(status), 0644)).To(Succeed())
+		})
+
+		It("merges the status into the existing status", func() {
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(Succeed())
+
+			obj := getRedis()
+			message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+			Expect(message).To(Equal("Redis is ready"))
+			host, _, _ := unstructured.NestedString(obj.Object, "status", "endpoint", "host")
+			Expect(host).To(Equal("new-host"))
+			port, _, _ := unstructured.NestedString(obj.Object, "status", "endpoint", "port")
+			Expect(port).To(Equal("6379"))
+			Expect(obj.Object["status"]).To(HaveKey("replicas"))
+		})
+
+		It("does not let the pipeline set conditions", func() {
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(Succeed())
+
+			obj := getRedis()
+			Expect(resourceutil.HasCondition(obj, "Injected")).To(BeFalse())
+			Expect(resourceutil.HasCondition(obj, "Ready")).To(BeTrue())
+		})
+	})
+
+	When("the status file is not valid YAML", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(metadataDirectory, "status.yaml"), []byte("message: [unclosed"), 0644)).To(Succeed())
+		})
+
+		It("errors without updating the object", func() {
+			err := statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)
+			Expect(err).To(MatchError(ContainSubstring("failed to parse status file")))
+
+			message, _, _ := unstructured.NestedString(getRedis().Object, "status", "message")
+			Expect(message).To(Equal("Pending"))
+		})
+	})
+
+	When("the pipeline wrote a versioned status file", func() {
+		writeStatus := func(status string) {
+			Expect(os.WriteFile(filepath.Join(metadataDirectory, "status.yaml"), []byte(status), 0644)).To(Succeed())
+		}
+
+		It("sets custom conditions, outputs and printer columns", func() {
+			writeStatus(`
+apiVersion: platform.kratix.io/v1alpha1
+kind: PipelineStatus
+status:
+  message: Redis is ready
+conditions:
+- type: DatabaseReady
+  status: "True"
+  reason: Provisioned
+outputs:
+  connection:
+    secretRef:
+      name: redis-credentials
+      key: url
+  dashboard:
+    value: https://redis.example.com
+printerColumns:
+  Host: redis.default.svc
+`)
+			writeStatusContract(kratixSystemDirectory, `
+printerColumns:
+- name: status
+  type: string
+  jsonPath: .status.message
+- name: host
+  type: string
+  jsonPath: .status.endpoint.host
+`)
+
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(Succeed())
+
+			obj := getRedis()
+			message, _, _ := unstructured.NestedString(obj.Object, "status", "message")
+			Expect(message).To(Equal("Redis is ready"))
+
+			condition := resourceutil.GetCondition(obj, "DatabaseReady")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(v1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("Provisioned"))
+			Expect(resourceutil.GetPipelineCompletedConditionStatus(obj)).To(Equal(v1.ConditionTrue))
+
+			secretRef, _, _ := unstructured.NestedStringMap(obj.Object, "status", "outputs", "connection", "secretRef")
+			Expect(secretRef).To(Equal(map[string]string{"name": "redis-credentials", "namespace": "default", "key": "url"}))
+			dashboard, _, _ := unstructured.NestedString(obj.Object, "status", "outputs", "dashboard", "value")
+			Expect(dashboard).To(Equal("https://redis.example.com"))
+
+			host, _, _ := unstructured.NestedString(obj.Object, "status", "endpoint", "host")
+			Expect(host).To(Equal("redis.default.svc"))
+			port, _, _ := unstructured.NestedString(obj.Object, "status", "endpoint", "port")
+			Expect(port).To(Equal("6379"))
+		})
+
+		It("rejects conditions owned by Kratix", func() {
+			writeStatus(`
+apiVersion: platform.kratix.io/v1alpha1
+kind: PipelineStatus
+conditions:
+- type: PipelineCompleted
+  status: "True"
+`)
+			err := statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)
+			Expect(err).To(MatchError(ContainSubstring("conditions[0].type PipelineCompleted is reserved")))
+		})
+
+		It("rejects outputs that are neither values nor secret references", func() {
+			writeStatus(`
+apiVersion: platform.kratix.io/v1alpha1
+kind: PipelineStatus
+outputs:
+  connection: {}
+`)
+			err := statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)
+			Expect(err).To(MatchError(ContainSubstring("outputs.connection must set exactly one of value, secretRef")))
+		})
+
+		It("rejects unknown fields and versions", func() {
+			writeStatus(`
+apiVersion: platform.kratix.io/v1alpha1
+kind: PipelineStatus
+unknown: field
+`)
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(MatchError(ContainSubstring("failed to parse status file")))
+
+			writeStatus(`
+apiVersion: platform.kratix.io/v2
+kind: PipelineStatus
+`)
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(MatchError(ContainSubstring("unsupported status file apiVersion")))
+		})
+
+		It("rejects printer columns not declared by the Promise API", func() {
+			writeStatus(`
+apiVersion: platform.kratix.io/v1alpha1
+kind: PipelineStatus
+printerColumns:
+  Host: redis.default.svc
+`)
+			err := statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)
+			Expect(err).To(MatchError(ContainSubstring(`printer column "Host" is not declared by the Promise API`)))
+		})
+	})
+
+	When("the Promise API declares a status schema", func() {
+		BeforeEach(func() {
+			writeStatusContract(kratixSystemDirectory, `
+schema:
+  type: object
+  properties:
+    message:
+      type: string
+    conditions:
+      type: array
+      items:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+    endpoint:
+      type: object
+      properties:
+        host:
+          type: string
+        port:
+          type: string
+    replicas:
+      type: integer
+`)
+		})
+
+		It("accepts a status matching the schema", func() {
+			Expect(os.WriteFile(filepath.Join(metadataDirectory, "status.yaml"), []byte("replicas: 3\n"), 0644)).To(Succeed())
+			Expect(statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)).To(Succeed())
+		})
+
+		It("rejects a status not matching the schema, without updating the object", func() {
+			Expect(os.WriteFile(filepath.Join(metadataDirectory, "status.yaml"), []byte("replicas: three\n"), 0644)).To(Succeed())
+			err := statusWriter.Execute(ctx, ref, metadataDirectory, kratixSystemDirectory)
+			Expect(err).To(MatchError(ContainSubstring("status does not match the Promise API status schema")))
+			Expect(err).To(MatchError(ContainSubstring("status.replicas")))
+
+			message, _, _ := unstructured.NestedString(getRedis().Object, "status", "message")
+			Expect(message).To(Equal("Pending"))
+		})
+	})
+})
+
+func writeStatusContract(directory, contract string) {
+	Expect(os.WriteFile(filepath.Join(directory, "status-contract"), []byte(contract), 0644)).To(Succeed())
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	goerr "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
+	"github.com/syntasso/kratix/lib/resourceutil"
+	"github.com/syntasso/kratix/lib/workloadcontent"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const invalidWorkloadsReason = "InvalidWorkloads"
+
+// validateWorkloads parses every YAML document in the workloads and checks
+// the manifests against the WorkloadPolicy of the Promise, see
+// workloadcontent.CheckPolicy. Files that are not YAML or JSON, and binary
+// files, are not validated. Templates are validated once rendered for each
+// Destination, before they are written.
+func validateWorkloads(workloadGroups []platformv1alpha1.WorkloadGroup, policy *platformv1alpha1.WorkloadPolicy) error {
+	var problems []string
+	for _, workloadGroup := range workloadGroups {
+		for _, workload := range workloadGroup.Workloads {
+			if !workloadcontent.IsManifest(workload) {
+				continue
+			}
+			for _, problem := range workloadcontent.CheckPolicy(workload, policy) {
+				problems = append(problems, fmt.Sprintf("%s: %s", workload.Filepath, problem))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid workloads in the pipeline output:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// getWorkloadPolicy returns the WorkloadPolicy set on the Promise, which
+// applies to resource workflows only
+func (w *WorkCreator) getWorkloadPolicy(rootDirectory, workflowType string) (*platformv1alpha1.WorkloadPolicy, error) {
+	if workflowType == platformv1alpha1.KratixWorkflowTypePromise {
+		return nil, nil
+	}
+
+	file := filepath.Join(rootDirectory, "kratix-system", kratixpipeline.PromiseWorkloadPolicyFile)
+	fileContents, err := os.ReadFile(file)
+	if err != nil {
+		if goerr.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var policy *platformv1alpha1.WorkloadPolicy
+	if err := yaml.Unmarshal(fileContents, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+	}
+	return policy, nil
+}
+
+// reportInvalidWorkloads marks the pipeline of the object as failed, with the
+// validation error as the message
+func (w *WorkCreator) reportInvalidWorkloads(ctx context.Context, validationErr error) error {
+	if w.Object == nil {
+		return nil
+	}
+
+	obj, err := getObject(ctx, w.K8sClient, *w.Object)
+	if err != nil {
+		return err
+	}
+
+	logger := ctrl.Log.WithName("work-creator").WithValues("kind", w.Object.Kind, "name", w.Object.Name)
+	resourceutil.MarkPipelineAsFailed(logger, obj, invalidWorkloadsReason, validationErr.Error())
+	if err := w.K8sClient.Status().Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
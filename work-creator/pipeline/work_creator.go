@@ -4,9 +4,9 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	goerr "errors"
 
@@ -16,13 +16,22 @@ import (
 
 	platformv1alpha1 "github.com/syntasso/kratix/api/v1alpha1"
 	"github.com/syntasso/kratix/lib/hash"
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
+	"github.com/syntasso/kratix/lib/workloadcontent"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type WorkCreator struct {
 	K8sClient client.Client
+	// Object the pipeline runs for. When set, invalid workloads are reported
+	// in its status.
+	Object *ObjectReference
+	// PipelineRunName is the name of the pipeline run, added to the manifests
+	// of the output when the Promise sets StampWorkloads
+	PipelineRunName string
 }
 
 func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceName, workflowType string) error {
@@ -44,38 +53,72 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 		return err
 	}
 
+	dependencyGroupScheduling, err := w.getDependencyGroupScheduling(rootDirectory, workflowType, workflowScheduling)
+	if err != nil {
+		return err
+	}
+	dependencyGroupDirectories := map[string]bool{}
+	for _, scheduling := range dependencyGroupScheduling {
+		dependencyGroupDirectories[scheduling.Directory] = true
+	}
+	workflowScheduling = append(workflowScheduling, dependencyGroupScheduling...)
+
 	var workloadGroups []platformv1alpha1.WorkloadGroup
-	var directoriesToIgnoreForTheBaseScheduling []string
-	var defaultDestinationSelectors map[string]string
+	var scheduledDirectories []string
+	var defaultDestinationSelectors *metav1.LabelSelector
+	var defaultWave int
 	pipelineOutputDir := filepath.Join(rootDirectory, "input")
+	for _, workflowDestinationSelector := range workflowScheduling {
+		if !isRootDirectory(workflowDestinationSelector.Directory) {
+			scheduledDirectories = append(scheduledDirectories, workflowDestinationSelector.Directory)
+		}
+	}
+
 	for _, workflowDestinationSelector := range workflowScheduling {
 		directory := workflowDestinationSelector.Directory
 		if !isRootDirectory(directory) {
-			directoriesToIgnoreForTheBaseScheduling = append(directoriesToIgnoreForTheBaseScheduling, directory)
-
-			workloads, err := w.getWorkloadsFromDir(pipelineOutputDir, filepath.Join(pipelineOutputDir, directory), nil)
+			// the workloads of nested directories with their own selectors
+			// belong to the most specific directory only
+			workloads, err := w.getWorkloadsFromDir(pipelineOutputDir, filepath.Join(pipelineOutputDir, directory), nestedDirectories(directory, scheduledDirectories))
 
 			if err != nil {
 				return err
 			}
 
-			workloadGroups = append(workloadGroups, platformv1alpha1.WorkloadGroup{
+			workloadGroup := platformv1alpha1.WorkloadGroup{
 				Workloads: workloads,
 				Directory: directory,
 				ID:        fmt.Sprintf("%x", md5.Sum([]byte(directory))),
+				Wave:      workflowDestinationSelector.Wave,
 				DestinationSelectors: []platformv1alpha1.WorkloadGroupScheduling{
-					{
-						MatchLabels: workflowDestinationSelector.MatchLabels,
-						Source:      workflowType + "-" + "workflow",
-					},
+					platformv1alpha1.NewWorkloadGroupScheduling(workflowDestinationSelector.LabelSelector(), workflowType+"-"+"workflow"),
 				},
-			})
+			}
+
+			// dependency groups are also scheduled with the selectors of the
+			// Promise, as when the Promise has no configure workflow
+			if dependencyGroupDirectories[directory] {
+				promiseSelectors, err := w.getPromiseSelectors(rootDirectory)
+				if err != nil {
+					return err
+				}
+				workloadGroup.DestinationSelectors = []platformv1alpha1.WorkloadGroupScheduling{
+					platformv1alpha1.NewWorkloadGroupScheduling(workflowDestinationSelector.LabelSelector(), "promise-dependency-group"),
+				}
+				if promiseSelectors != nil {
+					workloadGroup.DestinationSelectors = append(workloadGroup.DestinationSelectors, *promiseSelectors)
+				}
+			}
+
+			workloadGroups = append(workloadGroups, workloadGroup)
 		} else {
-			defaultDestinationSelectors = workflowDestinationSelector.MatchLabels
+			selector := workflowDestinationSelector.LabelSelector()
+			defaultDestinationSelectors = &selector
+			defaultWave = workflowDestinationSelector.Wave
 		}
 	}
 
-	workloads, err := w.getWorkloadsFromDir(pipelineOutputDir, pipelineOutputDir, directoriesToIgnoreForTheBaseScheduling)
+	workloads, err := w.getWorkloadsFromDir(pipelineOutputDir, pipelineOutputDir, scheduledDirectories)
 	if err != nil {
 		return err
 	}
@@ -85,14 +128,12 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 			Workloads: workloads,
 			Directory: platformv1alpha1.DefaultWorkloadGroupDirectory,
 			ID:        hash.ComputeHash(platformv1alpha1.DefaultWorkloadGroupDirectory),
+			Wave:      defaultWave,
 		}
 
 		if defaultDestinationSelectors != nil {
 			defaultWorkloadGroup.DestinationSelectors = []platformv1alpha1.WorkloadGroupScheduling{
-				{
-					MatchLabels: defaultDestinationSelectors,
-					Source:      workflowType + "-" + "workflow",
-				},
+				platformv1alpha1.NewWorkloadGroupScheduling(*defaultDestinationSelectors, workflowType+"-"+"workflow"),
 			}
 		}
 
@@ -107,29 +148,28 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 				switch selector.Source {
 				case "promise":
 					p = append(p, platformv1alpha1.PromiseScheduling{
-						MatchLabels: selector.MatchLabels,
+						MatchLabels:      selector.MatchLabels,
+						MatchExpressions: selector.MatchExpressions,
 					})
 				case "promise-workflow":
 					pw = append(pw, platformv1alpha1.PromiseScheduling{
-						MatchLabels: selector.MatchLabels,
+						MatchLabels:      selector.MatchLabels,
+						MatchExpressions: selector.MatchExpressions,
 					})
 				}
 			}
 
 			if len(pw) > 0 {
-				defaultWorkloadGroup.DestinationSelectors = append(defaultWorkloadGroup.DestinationSelectors, platformv1alpha1.WorkloadGroupScheduling{
-					MatchLabels: platformv1alpha1.SquashPromiseScheduling(pw),
-					Source:      "promise-workflow",
-				})
+				defaultWorkloadGroup.DestinationSelectors = append(
+					defaultWorkloadGroup.DestinationSelectors,
+					platformv1alpha1.NewWorkloadGroupScheduling(platformv1alpha1.SquashPromiseScheduling(pw), "promise-workflow"),
+				)
 			}
 
 			if len(p) > 0 {
 				defaultWorkloadGroup.DestinationSelectors = append(
 					defaultWorkloadGroup.DestinationSelectors,
-					platformv1alpha1.WorkloadGroupScheduling{
-						MatchLabels: platformv1alpha1.SquashPromiseScheduling(p),
-						Source:      "promise",
-					},
+					platformv1alpha1.NewWorkloadGroupScheduling(platformv1alpha1.SquashPromiseScheduling(p), "promise"),
 				)
 			}
 		}
@@ -137,6 +177,27 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 		workloadGroups = append(workloadGroups, defaultWorkloadGroup)
 	}
 
+	workloadPolicy, err := w.getWorkloadPolicy(rootDirectory, workflowType)
+	if err != nil {
+		return err
+	}
+	if err := validateWorkloads(workloadGroups, workloadPolicy); err != nil {
+		if reportErr := w.reportInvalidWorkloads(context.Background(), err); reportErr != nil {
+			logger.Error(reportErr, "Error reporting invalid workloads")
+		}
+		return err
+	}
+
+	stamp, err := w.getStampWorkloads(rootDirectory)
+	if err != nil {
+		return err
+	}
+	if stamp {
+		if err := stampWorkloads(workloadGroups, workloadcontent.NewOwnershipStamp(promiseName, resourceName, namespace, w.PipelineRunName)); err != nil {
+			return err
+		}
+	}
+
 	work := &platformv1alpha1.Work{}
 
 	work.Name = identifier
@@ -146,6 +207,19 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 	work.Spec.PromiseName = promiseName
 	work.Spec.ResourceName = resourceName
 
+	if workflowType != platformv1alpha1.KratixWorkflowTypePromise {
+		placement, err := w.getPlacement(rootDirectory)
+		if err != nil {
+			return err
+		}
+		if placement != nil {
+			if placement.Replicas > 0 {
+				work.Spec.Replicas = placement.Replicas
+			}
+			work.Spec.SpreadConstraint = placement.SpreadConstraint
+		}
+	}
+
 	if workflowType == platformv1alpha1.KratixWorkflowTypePromise {
 		work.Name = promiseName
 		work.Namespace = platformv1alpha1.KratixSystemNamespace
@@ -154,6 +228,15 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 		work.Labels = platformv1alpha1.GenerateSharedLabelsForPromise(promiseName)
 	}
 
+	// templates are stamped once rendered, with the run recorded on the Work
+	if stamp && w.PipelineRunName != "" {
+		metav1.SetMetaDataAnnotation(&work.ObjectMeta, workloadcontent.StampPipelineRunKey, w.PipelineRunName)
+	}
+
+	if err := workloadcontent.Encode(context.Background(), w.K8sClient, work); err != nil {
+		return err
+	}
+
 	err = w.K8sClient.Create(context.Background(), work)
 
 	if errors.IsAlreadyExists(err) {
@@ -167,23 +250,45 @@ func (w *WorkCreator) Execute(rootDirectory, promiseName, namespace, resourceNam
 		}
 
 		currentWork.Spec = work.Spec
+		if run, ok := work.GetAnnotations()[workloadcontent.StampPipelineRunKey]; ok {
+			metav1.SetMetaDataAnnotation(&currentWork.ObjectMeta, workloadcontent.StampPipelineRunKey, run)
+		}
 		err = w.K8sClient.Update(context.Background(), &currentWork)
 
 		if err != nil {
 			logger.Error(err, "Error updating Work")
+			return nil
 		}
 		logger.Info("Work updated")
-		return nil
+		return workloadcontent.Cleanup(context.Background(), w.K8sClient, &currentWork)
 	} else if err != nil {
 		return err
 	} else {
 		logger.Info("Work created")
-		return nil
+		return workloadcontent.Cleanup(context.Background(), w.K8sClient, work)
+	}
+}
+
+// getWorkloadsFromDir reads the workloads in rootDir, skipping the
+// directoriesToIgnore, given relative to prefixToTrimFromWorkloadFilepath
+func (w *WorkCreator) getWorkloadsFromDir(prefixToTrimFromWorkloadFilepath, rootDir string, directoriesToIgnore []string) ([]platformv1alpha1.Workload, error) {
+	outputDir, err := filepath.EvalSymlinks(prefixToTrimFromWorkloadFilepath)
+	if err != nil {
+		return nil, err
 	}
+	realRootDir, err := filepath.EvalSymlinks(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.getWorkloadsFromDirWithin(outputDir, prefixToTrimFromWorkloadFilepath, rootDir, directoriesToIgnore, map[string]bool{realRootDir: true})
 }
 
-// /kratix/output/     /kratix/output/   "bar"
-func (w *WorkCreator) getWorkloadsFromDir(prefixToTrimFromWorkloadFilepath, rootDir string, directoriesToIgnoreAtTheRootLevel []string) ([]platformv1alpha1.Workload, error) {
+// getWorkloadsFromDirWithin reads the workloads in rootDir. Symlinks are
+// followed as long as they resolve within outputDir, with the workloads of a
+// symlink placed at the path of the symlink. visitedDirs holds the resolved
+// directories being read, to reject symlinks to one of their parents.
+func (w *WorkCreator) getWorkloadsFromDirWithin(outputDir, prefixToTrimFromWorkloadFilepath, rootDir string, directoriesToIgnore []string, visitedDirs map[string]bool) ([]platformv1alpha1.Workload, error) {
 	filesAndDirs, err := os.ReadDir(rootDir)
 	if err != nil {
 		return nil, err
@@ -191,46 +296,77 @@ func (w *WorkCreator) getWorkloadsFromDir(prefixToTrimFromWorkloadFilepath, root
 
 	workloads := []platformv1alpha1.Workload{}
 
-	for _, info := range filesAndDirs {
-		// TODO: currently we assume everything is a file or a dir, we don't handle
-		// more advanced scenarios, e.g. symlinks, file sizes, file permissions etc
-		if info.IsDir() {
-			if !slices.Contains(directoriesToIgnoreAtTheRootLevel, info.Name()) {
-				dir := filepath.Join(rootDir, info.Name())
-				newWorkloads, err := w.getWorkloadsFromDir(prefixToTrimFromWorkloadFilepath, dir, nil)
-				if err != nil {
-					return nil, err
-				}
-				workloads = append(workloads, newWorkloads...)
+	for _, entry := range filesAndDirs {
+		filePath := filepath.Join(rootDir, entry.Name())
+
+		// trim /kratix/output/ from the filepath
+		path, err := filepath.Rel(prefixToTrimFromWorkloadFilepath, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		realPath := filePath
+		if entry.Type()&os.ModeSymlink != 0 {
+			realPath, err = resolveSymlink(outputDir, filePath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid symlink %s: %w", path, err)
 			}
-		} else {
-			filePath := filepath.Join(rootDir, info.Name())
-			file, err := os.Open(filePath)
+		}
+
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case info.IsDir():
+			if slices.Contains(directoriesToIgnore, path) {
+				continue
+			}
+			realDir, err := filepath.EvalSymlinks(realPath)
 			if err != nil {
 				return nil, err
 			}
-			byteValue, err := io.ReadAll(file)
+			if visitedDirs[realDir] {
+				return nil, fmt.Errorf("invalid symlink %s: links to a parent directory", path)
+			}
+			visitedDirs[realDir] = true
+			newWorkloads, err := w.getWorkloadsFromDirWithin(outputDir, prefixToTrimFromWorkloadFilepath, filePath, directoriesToIgnore, visitedDirs)
+			delete(visitedDirs, realDir)
 			if err != nil {
 				return nil, err
 			}
-
-			// trim /kratix/output/ from the filepath
-			path, err := filepath.Rel(prefixToTrimFromWorkloadFilepath, filePath)
+			workloads = append(workloads, newWorkloads...)
+		case info.Mode().IsRegular():
+			content, err := os.ReadFile(filePath)
 			if err != nil {
 				return nil, err
 			}
-
-			workload := platformv1alpha1.Workload{
-				Content:  string(byteValue),
-				Filepath: path,
-			}
-
-			workloads = append(workloads, workload)
+			workloads = append(workloads, workloadcontent.NewWorkload(path, content, info.Mode()))
+		default:
+			return nil, fmt.Errorf("unsupported file %s: only regular files, directories and symlinks are supported", path)
 		}
 	}
 	return workloads, nil
 }
 
+// resolveSymlink returns the path the symlink resolves to, erroring when it
+// resolves outside of outputDir
+func resolveSymlink(outputDir, symlink string) (string, error) {
+	target, err := filepath.EvalSymlinks(symlink)
+	if err != nil {
+		return "", err
+	}
+	relativePath, err := filepath.Rel(outputDir, target)
+	if err != nil {
+		return "", err
+	}
+	if relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("resolves to %s, outside of the pipeline output", target)
+	}
+	return target, nil
+}
+
 func (w *WorkCreator) getWorkflowScheduling(rootDirectory string) ([]platformv1alpha1.WorkflowDestinationSelectors, error) {
 	metadataDirectory := filepath.Join(rootDirectory, "metadata")
 	return getSelectorsFromFile(filepath.Join(metadataDirectory, "destination-selectors.yaml"))
@@ -257,6 +393,111 @@ func (w *WorkCreator) getPromiseScheduling(rootDirectory string) ([]platformv1al
 	return schedulingConfig, nil
 }
 
+// getDependencyGroupScheduling returns the scheduling of the DependencyGroups
+// of the Promise whose directory is in the pipeline output, unless the
+// pipeline schedules the directory itself in destination-selectors.yaml
+func (w *WorkCreator) getDependencyGroupScheduling(rootDirectory, workflowType string, workflowScheduling []platformv1alpha1.WorkflowDestinationSelectors) ([]platformv1alpha1.WorkflowDestinationSelectors, error) {
+	if workflowType != platformv1alpha1.KratixWorkflowTypePromise {
+		return nil, nil
+	}
+
+	file := filepath.Join(rootDirectory, "kratix-system", kratixpipeline.PromiseDependencyGroupsFile)
+	fileContents, err := os.ReadFile(file)
+	if err != nil {
+		if goerr.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var dependencyGroups []platformv1alpha1.WorkflowDestinationSelectors
+	if err := yaml.Unmarshal(fileContents, &dependencyGroups); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+	}
+
+	var scheduling []platformv1alpha1.WorkflowDestinationSelectors
+	for _, dependencyGroup := range dependencyGroups {
+		directory := filepath.Clean(dependencyGroup.Directory)
+		scheduled := slices.ContainsFunc(workflowScheduling, func(selector platformv1alpha1.WorkflowDestinationSelectors) bool {
+			return selector.Directory == directory
+		})
+		if scheduled {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(rootDirectory, "input", directory))
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		dependencyGroup.Directory = directory
+		scheduling = append(scheduling, dependencyGroup)
+	}
+	return scheduling, nil
+}
+
+// getPromiseSelectors returns the destination selectors of the Promise, or
+// nil when it has none
+func (w *WorkCreator) getPromiseSelectors(rootDirectory string) (*platformv1alpha1.WorkloadGroupScheduling, error) {
+	destinationSelectors, err := w.getPromiseScheduling(rootDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var promiseScheduling []platformv1alpha1.PromiseScheduling
+	for _, selector := range destinationSelectors {
+		if selector.Source == "promise" {
+			promiseScheduling = append(promiseScheduling, platformv1alpha1.PromiseScheduling{
+				MatchLabels:      selector.MatchLabels,
+				MatchExpressions: selector.MatchExpressions,
+			})
+		}
+	}
+	if len(promiseScheduling) == 0 {
+		return nil, nil
+	}
+
+	scheduling := platformv1alpha1.NewWorkloadGroupScheduling(platformv1alpha1.SquashPromiseScheduling(promiseScheduling), "promise")
+	return &scheduling, nil
+}
+
+// getPlacement returns the placement written by the pipeline, with unset
+// fields defaulted from the placement set on the Promise
+func (w *WorkCreator) getPlacement(rootDirectory string) (*platformv1alpha1.Placement, error) {
+	workflowPlacement, err := getPlacementFromFile(filepath.Join(rootDirectory, "metadata", "placement.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	promisePlacement, err := getPlacementFromFile(filepath.Join(rootDirectory, "kratix-system", kratixpipeline.PromisePlacementFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return workflowPlacement.Merge(promisePlacement), nil
+}
+
+func getPlacementFromFile(file string) (*platformv1alpha1.Placement, error) {
+	fileContents, err := os.ReadFile(file)
+	if err != nil {
+		if goerr.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var placement *platformv1alpha1.Placement
+	if err := yaml.Unmarshal(fileContents, &placement); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+	}
+
+	if err := placement.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid placement in %s: %w", filepath.Base(file), err)
+	}
+
+	return placement, nil
+}
+
 func getSelectorsFromFile(file string) ([]platformv1alpha1.WorkflowDestinationSelectors, error) {
 	fileContents, err := os.ReadFile(file)
 	if err != nil {
@@ -274,6 +515,14 @@ func getSelectorsFromFile(file string) ([]platformv1alpha1.WorkflowDestinationSe
 	}
 	for i := range schedulingConfig {
 		schedulingConfig[i].Directory = filepath.Clean(schedulingConfig[i].Directory)
+
+		selector := schedulingConfig[i].LabelSelector()
+		if _, err := metav1.LabelSelectorAsSelector(&selector); err != nil {
+			return nil, fmt.Errorf("invalid selector for directory %s in destination-selectors.yaml: %w", schedulingConfig[i].Directory, err)
+		}
+		if schedulingConfig[i].Wave < 0 {
+			return nil, fmt.Errorf("invalid wave for directory %s in destination-selectors.yaml: %d, wave must not be negative", schedulingConfig[i].Directory, schedulingConfig[i].Wave)
+		}
 	}
 
 	if containsDuplicateScheduling(schedulingConfig) {
@@ -281,17 +530,17 @@ func getSelectorsFromFile(file string) ([]platformv1alpha1.WorkflowDestinationSe
 		return nil, err
 	}
 
-	if path, found := containsNonRootDirectory(schedulingConfig); found {
-		return nil, fmt.Errorf("invalid directory in destination-selectors.yaml: %s, directory must be top-level", path)
+	if path, found := containsDirectoryOutsideOutput(schedulingConfig); found {
+		return nil, fmt.Errorf("invalid directory in destination-selectors.yaml: %s, directory must be within the pipeline output", path)
 	}
 
 	return schedulingConfig, nil
 }
 
-func containsNonRootDirectory(schedulingConfig []platformv1alpha1.WorkflowDestinationSelectors) (string, bool) {
+func containsDirectoryOutsideOutput(schedulingConfig []platformv1alpha1.WorkflowDestinationSelectors) (string, bool) {
 	for _, selector := range schedulingConfig {
 		directory := selector.Directory
-		if filepath.Base(directory) != directory {
+		if filepath.IsAbs(directory) || directory == ".." || strings.HasPrefix(directory, ".."+string(filepath.Separator)) {
 			return directory, true
 		}
 	}
@@ -299,6 +548,17 @@ func containsNonRootDirectory(schedulingConfig []platformv1alpha1.WorkflowDestin
 	return "", false
 }
 
+// nestedDirectories returns the directories within directory
+func nestedDirectories(directory string, directories []string) []string {
+	nested := []string{}
+	for _, candidate := range directories {
+		if strings.HasPrefix(candidate, directory+string(filepath.Separator)) {
+			nested = append(nested, candidate)
+		}
+	}
+	return nested
+}
+
 func containsDuplicateScheduling(schedulingConfig []platformv1alpha1.WorkflowDestinationSelectors) bool {
 	directoriesSeen := []string{}
 
@@ -2,19 +2,27 @@ package pipeline_test
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/syntasso/kratix/api/v1alpha1"
+	kratixpipeline "github.com/syntasso/kratix/lib/pipeline"
+	"github.com/syntasso/kratix/lib/resourceutil"
+	"github.com/syntasso/kratix/lib/workloadcontent"
 	"github.com/syntasso/kratix/work-creator/pipeline"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("WorkCreator", func() {
@@ -126,11 +134,106 @@ var _ = Describe("WorkCreator", func() {
 			})
 		})
 
-		When("the destination-selectors contain a non-root directory", func() {
-			It("errors", func() {
+		When("the destination-selectors contain nested directories", func() {
+			It("schedules the workloads with the selectors of the most specific directory", func() {
 				mockPipelineDirectory := filepath.Join(getRootDirectory(), "destination-selectors-with-non-root-directory")
 				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
-				Expect(err).To(MatchError(ContainSubstring("invalid directory in destination-selectors.yaml: foo/bar, directory must be top-level")))
+				Expect(err).NotTo(HaveOccurred())
+
+				workResource := getWork(expectedNamespace, resourceWorkName)
+				paths := map[string][]string{}
+				selectors := map[string][]v1alpha1.WorkloadGroupScheduling{}
+				for _, workloadGroup := range workResource.Spec.WorkloadGroups {
+					for _, workload := range workloadGroup.Workloads {
+						paths[workloadGroup.Directory] = append(paths[workloadGroup.Directory], workload.Filepath)
+					}
+					selectors[workloadGroup.Directory] = workloadGroup.DestinationSelectors
+				}
+
+				Expect(paths).To(Equal(map[string][]string{
+					"foo/bar": {"foo/bar/namespace-resource-request.yaml"},
+					"foo":     {"foo/multi-resource-requests.yaml"},
+					".":       {"baz/baz-namespace-resource-request.yaml", "configmap.yaml"},
+				}))
+				Expect(selectors["foo/bar"]).To(ConsistOf(v1alpha1.WorkloadGroupScheduling{
+					MatchLabels: map[string]string{"environment": "staging"},
+					Source:      "resource-workflow",
+				}))
+				Expect(selectors["foo"]).To(ConsistOf(v1alpha1.WorkloadGroupScheduling{
+					MatchLabels: map[string]string{"environment": "qa"},
+					Source:      "resource-workflow",
+				}))
+			})
+		})
+
+		When("the destination-selectors contain a directory outside of the pipeline output", func() {
+			It("errors", func() {
+				mockPipelineDirectory := GinkgoT().TempDir()
+				Expect(os.MkdirAll(filepath.Join(mockPipelineDirectory, "input"), 0755)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(mockPipelineDirectory, "metadata"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(mockPipelineDirectory, "metadata", "destination-selectors.yaml"), []byte("- directory: ../metadata\n  matchLabels:\n    environment: staging\n"), 0644)).To(Succeed())
+
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError(ContainSubstring("invalid directory in destination-selectors.yaml: ../metadata, directory must be within the pipeline output")))
+			})
+		})
+
+		When("the destination-selectors contain matchExpressions", func() {
+			It("sets them on the workload group scheduling", func() {
+				mockPipelineDirectory := filepath.Join(getRootDirectory(), "destination-selectors-with-match-expressions")
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).ToNot(HaveOccurred())
+
+				workResource := getWork(expectedNamespace, resourceWorkName)
+				Expect(workResource.Spec.WorkloadGroups).To(HaveLen(1))
+				Expect(workResource.Spec.WorkloadGroups[0].DestinationSelectors).To(ContainElement(
+					v1alpha1.WorkloadGroupScheduling{
+						MatchLabels: map[string]string{"environment": "production"},
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"europe", "asia"}},
+							{Key: "pci", Operator: metav1.LabelSelectorOpDoesNotExist},
+						},
+						Source: "resource-workflow",
+					},
+				))
+			})
+		})
+
+		When("the destination-selectors contain an invalid matchExpression", func() {
+			It("errors", func() {
+				mockPipelineDirectory := filepath.Join(getRootDirectory(), "destination-selectors-with-invalid-match-expressions")
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError(ContainSubstring("invalid selector for directory")))
+			})
+		})
+
+		When("the pipeline writes a placement", func() {
+			It("sets the replicas, defaulting unset fields from the Promise placement", func() {
+				mockPipelineDirectory := filepath.Join(getRootDirectory(), "placement")
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).ToNot(HaveOccurred())
+
+				workResource := getWork(expectedNamespace, resourceWorkName)
+				Expect(workResource.Spec.Replicas).To(Equal(3))
+				Expect(workResource.Spec.SpreadConstraint).To(Equal(&v1alpha1.SpreadConstraint{TopologyKey: "zone", MaxReplicasPerDomain: 1}))
+			})
+
+			It("errors when the placement is invalid", func() {
+				mockPipelineDirectory := filepath.Join(getRootDirectory(), "invalid-placement")
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError(ContainSubstring("invalid placement in placement.yaml: spreadConstraint.topologyKey is required")))
+			})
+		})
+
+		When("only the Promise sets a placement", func() {
+			It("sets the replicas from the Promise", func() {
+				mockPipelineDirectory := filepath.Join(getRootDirectory(), "placement-from-promise")
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).ToNot(HaveOccurred())
+
+				workResource := getWork(expectedNamespace, resourceWorkName)
+				Expect(workResource.Spec.Replicas).To(Equal(2))
+				Expect(workResource.Spec.SpreadConstraint).To(Equal(&v1alpha1.SpreadConstraint{TopologyKey: "region"}))
 			})
 		})
 
@@ -211,6 +314,398 @@ var _ = Describe("WorkCreator", func() {
 			})
 		})
 
+		When("the pipeline outputs workloads too large to store in the Work", func() {
+			var content string
+
+			BeforeEach(func() {
+				mockPipelineDirectory := GinkgoT().TempDir()
+				Expect(os.MkdirAll(filepath.Join(mockPipelineDirectory, "input"), 0755)).To(Succeed())
+
+				random := make([]byte, 2*workloadcontent.InlineLimit)
+				_, err := rand.Read(random)
+				Expect(err).NotTo(HaveOccurred())
+				content = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: chart\ndata:\n  chart: " + base64.StdEncoding.EncodeToString(random) + "\n"
+				Expect(os.WriteFile(filepath.Join(mockPipelineDirectory, "input", "chart.yaml"), []byte(content), 0644)).To(Succeed())
+
+				err = workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("stores the content in ConfigMaps owned by the Work", func() {
+				workResource := getWork(expectedNamespace, resourceWorkName)
+				workload := workResource.Spec.WorkloadGroups[0].Workloads[0]
+				Expect(workload.Content).To(BeEmpty())
+				Expect(workload.ContentFrom).NotTo(BeNil())
+
+				configMaps := &v1.ConfigMapList{}
+				Expect(k8sClient.List(context.Background(), configMaps, client.InNamespace(expectedNamespace))).To(Succeed())
+				Expect(configMaps.Items).To(HaveLen(len(workload.ContentFrom.ConfigMaps)))
+				for _, configMap := range configMaps.Items {
+					Expect(configMap.GetOwnerReferences()).To(ConsistOf(HaveField("Name", resourceWorkName)))
+				}
+
+				workloads, err := workloadcontent.Decode(context.Background(), k8sClient, expectedNamespace, workResource.Spec.WorkloadGroups[0].Workloads)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(workloads).To(ConsistOf(v1alpha1.Workload{Filepath: "chart.yaml", Content: content}))
+			})
+		})
+
+		When("the pipeline outputs binary files, file modes and symlinks", func() {
+			var (
+				mockPipelineDirectory string
+				outputDirectory       string
+				binaryContent         []byte
+			)
+
+			BeforeEach(func() {
+				mockPipelineDirectory = GinkgoT().TempDir()
+				outputDirectory = filepath.Join(mockPipelineDirectory, "input")
+				Expect(os.MkdirAll(filepath.Join(outputDirectory, "bin"), 0755)).To(Succeed())
+
+				binaryContent = []byte{0x00, 0xff, 0xfe, 0x01}
+				Expect(os.WriteFile(filepath.Join(outputDirectory, "bundle.tar.gz"), binaryContent, 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(outputDirectory, "bin", "run.sh"), []byte("#!/bin/sh"), 0755)).To(Succeed())
+				Expect(os.Symlink("bin/run.sh", filepath.Join(outputDirectory, "run.sh"))).To(Succeed())
+				Expect(os.Symlink("bin", filepath.Join(outputDirectory, "scripts"))).To(Succeed())
+			})
+
+			It("stores binary files base64 encoded, keeps modes and follows the symlinks", func() {
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+
+				workResource := getWork(expectedNamespace, resourceWorkName)
+				mode := int32(0755)
+				Expect(workResource.Spec.WorkloadGroups[0].Workloads).To(ConsistOf(
+					v1alpha1.Workload{Filepath: "bundle.tar.gz", Content: base64.StdEncoding.EncodeToString(binaryContent), Encoding: v1alpha1.WorkloadEncodingBase64},
+					v1alpha1.Workload{Filepath: "bin/run.sh", Content: "#!/bin/sh", Mode: &mode},
+					v1alpha1.Workload{Filepath: "run.sh", Content: "#!/bin/sh", Mode: &mode},
+					v1alpha1.Workload{Filepath: "scripts/run.sh", Content: "#!/bin/sh", Mode: &mode},
+				))
+
+				workloads, err := workloadcontent.Decode(context.Background(), k8sClient, expectedNamespace, workResource.Spec.WorkloadGroups[0].Workloads)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(workloads).To(ContainElement(v1alpha1.Workload{Filepath: "bundle.tar.gz", Content: string(binaryContent)}))
+			})
+
+			It("errors when a symlink resolves outside of the pipeline output", func() {
+				Expect(os.Symlink(filepath.Join(mockPipelineDirectory, "metadata"), filepath.Join(outputDirectory, "metadata"))).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(mockPipelineDirectory, "metadata"), 0755)).To(Succeed())
+
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError(ContainSubstring("invalid symlink metadata: resolves to")))
+			})
+
+			It("errors when a symlink links to a parent directory", func() {
+				Expect(os.Symlink("..", filepath.Join(outputDirectory, "bin", "loop"))).To(Succeed())
+
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError(ContainSubstring("invalid symlink bin/loop: links to a parent directory")))
+			})
+		})
+
+		Describe("validating the pipeline output", func() {
+			var mockPipelineDirectory string
+
+			writeFile := func(path, content string) {
+				path = filepath.Join(mockPipelineDirectory, path)
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				mockPipelineDirectory = GinkgoT().TempDir()
+				writeFile("input/namespace.yaml", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: team-a\n")
+				writeFile("input/README.md", "not: [a manifest")
+				writeFile("input/kustomization.yaml", "resources:\n- namespace.yaml\n")
+			})
+
+			It("accepts valid manifests and files that are not YAML", func() {
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+			})
+
+			It("leaves templates to be validated once rendered", func() {
+				writeFile("input/config.yaml.kratix.tmpl", "{{ range .Destination.Labels }}- {{ . }}\n{{ end }}")
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+			})
+
+			It("accepts YAML and JSON files that are not manifests", func() {
+				writeFile("input/values.yaml", "replicaCount: 2\nimage:\n  tag: latest\n")
+				writeFile("input/hosts.json", `["a.example.com", "b.example.com"]`)
+				writeFile("input/list.yml", "- one\n- two\n")
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "", "", "promise")).To(Succeed())
+			})
+
+			It("rejects malformed YAML", func() {
+				writeFile("input/broken.yaml", "apiVersion: v1\nkind: ConfigMap\n---\nkind: [\n")
+
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError(ContainSubstring("broken.yaml: document 2: malformed YAML")))
+
+				work := &v1alpha1.Work{}
+				Expect(k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: resourceWorkName}, work)).NotTo(Succeed())
+			})
+
+			When("the Promise restricts the workloads", func() {
+				BeforeEach(func() {
+					writeFile("kratix-system/"+kratixpipeline.PromiseWorkloadPolicyFile, `
+allowedKinds:
+- kind: Namespace
+- group: apps
+  kind: Deployment
+allowedNamespaces:
+- team-a
+`)
+				})
+
+				It("accepts the manifests the policy allows", func() {
+					writeFile("input/deployment.yaml", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n  namespace: team-a\n")
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+				})
+
+				It("rejects the manifests of other kinds and namespaces", func() {
+					writeFile("input/secret.yaml", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\n  namespace: team-a\n")
+					writeFile("input/other-namespace.yaml", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: kube-system\n")
+
+					err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+					Expect(err).To(MatchError(ContainSubstring("secret.yaml: document 1: kind /v1, Kind=Secret is not allowed by the Promise")))
+					Expect(err).To(MatchError(ContainSubstring("other-namespace.yaml: document 1: namespace kube-system is not allowed by the Promise")))
+				})
+
+				It("rejects the documents that are not manifests", func() {
+					writeFile("input/nested/no-kind.yml", "apiVersion: v1\nmetadata:\n  name: foo\n")
+					writeFile("input/list.yaml", "- one\n")
+
+					err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+					Expect(err).To(MatchError(ContainSubstring("nested/no-kind.yml: document 1: apiVersion and kind are required")))
+					Expect(err).To(MatchError(ContainSubstring("list.yaml: document 1: apiVersion and kind are required")))
+				})
+
+				It("does not apply the policy to Promise workflows", func() {
+					writeFile("input/secret.yaml", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\n")
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "", "", "promise")).To(Succeed())
+				})
+
+				It("reports the invalid workloads in the status of the object", func() {
+					redis := newRedis()
+					workCreator.K8sClient = newClientWithRESTMapper(redis)
+					workCreator.Object = &pipeline.ObjectReference{Kind: "redis", Group: "marketplace.kratix.io", Name: "example", Namespace: "default"}
+					writeFile("input/secret.yaml", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: creds\n")
+
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).NotTo(Succeed())
+
+					obj := &unstructured.Unstructured{}
+					obj.SetGroupVersionKind(redisGVK)
+					Expect(workCreator.K8sClient.Get(context.Background(), types.NamespacedName{Name: "example", Namespace: "default"}, obj)).To(Succeed())
+					condition := resourceutil.GetCondition(obj, resourceutil.PipelineCompletedCondition)
+					Expect(condition).NotTo(BeNil())
+					Expect(string(condition.Status)).To(Equal("False"))
+					Expect(condition.Reason).To(Equal("InvalidWorkloads"))
+					Expect(condition.Message).To(ContainSubstring("secret.yaml: document 1: kind /v1, Kind=Secret is not allowed by the Promise"))
+				})
+			})
+		})
+
+		Describe("stamping ownership onto the pipeline output", func() {
+			var mockPipelineDirectory string
+
+			writeFile := func(path, content string) {
+				path = filepath.Join(mockPipelineDirectory, path)
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+
+			getWorkloads := func(namespace, name string) map[string]string {
+				work := &v1alpha1.Work{}
+				Expect(k8sClient.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, work)).To(Succeed())
+				workloads := map[string]string{}
+				for _, workload := range work.Spec.WorkloadGroups[0].Workloads {
+					workloads[workload.Filepath] = workload.Content
+				}
+				return workloads
+			}
+
+			BeforeEach(func() {
+				mockPipelineDirectory = GinkgoT().TempDir()
+				workCreator.PipelineRunName = "kratix-promise-name-resource-name-abc12"
+				writeFile("input/manifests.yaml", "# a comment\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: team-a\n  labels:\n    team: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")
+				writeFile("input/kustomization.yaml", "# kept as is\nresources:\n- manifests.yaml\n")
+				writeFile("input/README.md", "kind: not a manifest\n")
+			})
+
+			It("leaves the manifests untouched when the Promise does not stamp workloads", func() {
+				writeFile("kratix-system/"+kratixpipeline.PromiseStampWorkloadsFile, "false")
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+				Expect(getWorkloads("default", resourceWorkName)["manifests.yaml"]).To(HavePrefix("# a comment"))
+			})
+
+			When("the Promise stamps workloads", func() {
+				BeforeEach(func() {
+					writeFile("kratix-system/"+kratixpipeline.PromiseStampWorkloadsFile, "true")
+				})
+
+				It("adds the ownership labels and annotations to every manifest of a resource workflow", func() {
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+
+					workloads := getWorkloads("default", resourceWorkName)
+					Expect(workloads["kustomization.yaml"]).To(Equal("# kept as is\nresources:\n- manifests.yaml\n"))
+					Expect(workloads["README.md"]).To(Equal("kind: not a manifest\n"))
+
+					manifests := strings.Split(workloads["manifests.yaml"], "---\n")
+					Expect(manifests).To(HaveLen(2))
+					for _, manifest := range manifests {
+						object := &unstructured.Unstructured{}
+						Expect(yaml.Unmarshal([]byte(manifest), &object.Object)).To(Succeed())
+						Expect(object.GetLabels()).To(HaveKeyWithValue("kratix.io/promise-name", "promise-name"))
+						Expect(object.GetLabels()).To(HaveKeyWithValue("kratix.io/resource-name", "resource-name"))
+						Expect(object.GetAnnotations()).To(Equal(map[string]string{
+							"kratix.io/promise-name":       "promise-name",
+							"kratix.io/resource-name":      "resource-name",
+							"kratix.io/resource-namespace": "default",
+							"kratix.io/pipeline-run":       "kratix-promise-name-resource-name-abc12",
+						}))
+					}
+
+					object := &unstructured.Unstructured{}
+					Expect(yaml.Unmarshal([]byte(manifests[0]), &object.Object)).To(Succeed())
+					Expect(object.GetLabels()).To(HaveKeyWithValue("team", "a"))
+				})
+
+				It("keeps the documents that are not objects", func() {
+					writeFile("input/hosts.json", `["a.example.com"]`)
+					writeFile("input/mixed.yaml", "- one\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+
+					workloads := getWorkloads("default", resourceWorkName)
+					Expect(workloads["hosts.json"]).To(Equal(`["a.example.com"]`))
+					documents := strings.Split(workloads["mixed.yaml"], "---\n")
+					Expect(documents).To(HaveLen(2))
+					Expect(documents[0]).To(Equal("- one\n"))
+					Expect(documents[1]).To(ContainSubstring("kratix.io/promise-name: promise-name"))
+				})
+
+				It("keeps names that are not valid label values in the annotations only", func() {
+					longName := strings.Repeat("a", 64)
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", longName, "resource")).To(Succeed())
+
+					object := &unstructured.Unstructured{}
+					manifest := strings.Split(getWorkloads("default", "promise-name-"+longName)["manifests.yaml"], "---\n")[0]
+					Expect(yaml.Unmarshal([]byte(manifest), &object.Object)).To(Succeed())
+					Expect(object.GetLabels()).NotTo(HaveKey("kratix.io/resource-name"))
+					Expect(object.GetAnnotations()).To(HaveKeyWithValue("kratix.io/resource-name", longName))
+				})
+
+				It("leaves templates to be stamped once rendered, recording the pipeline run on the Work", func() {
+					template := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Destination.Name }}\n"
+					writeFile("input/config.yaml.kratix.tmpl", template)
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+
+					Expect(getWorkloads("default", resourceWorkName)["config.yaml.kratix.tmpl"]).To(Equal(template))
+					work := getWork("default", resourceWorkName)
+					Expect(work.GetAnnotations()).To(HaveKeyWithValue("kratix.io/pipeline-run", "kratix-promise-name-resource-name-abc12"))
+				})
+
+				It("stamps only the Promise name for Promise workflows", func() {
+					writeFile("input/config.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config"}}`)
+					Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "", "", "promise")).To(Succeed())
+
+					object := &unstructured.Unstructured{}
+					Expect(json.Unmarshal([]byte(getWorkloads("kratix-platform-system", promiseWorkName)["config.json"]), &object.Object)).To(Succeed())
+					Expect(object.GetLabels()).To(Equal(map[string]string{"kratix.io/promise-name": "promise-name"}))
+					Expect(object.GetAnnotations()).To(Equal(map[string]string{
+						"kratix.io/promise-name": "promise-name",
+						"kratix.io/pipeline-run": "kratix-promise-name-resource-name-abc12",
+					}))
+				})
+			})
+		})
+
+		Describe("ordering the workload groups in waves", func() {
+			var mockPipelineDirectory string
+
+			writeFile := func(path, content string) {
+				path = filepath.Join(mockPipelineDirectory, path)
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				mockPipelineDirectory = GinkgoT().TempDir()
+				writeFile("input/crds/crd.yaml", "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: redis.example.com\n")
+				writeFile("input/redis.yaml", "apiVersion: example.com/v1\nkind: Redis\nmetadata:\n  name: redis\n")
+			})
+
+			It("sets the wave of each WorkloadGroup from destination-selectors.yaml", func() {
+				writeFile("metadata/destination-selectors.yaml", "- directory: crds\n- directory: .\n  wave: 1\n")
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+
+				work := getWork("default", resourceWorkName)
+				Expect(work.Spec.WorkloadGroups).To(ConsistOf(
+					SatisfyAll(HaveField("Directory", "crds"), HaveField("Wave", 0)),
+					SatisfyAll(HaveField("Directory", "."), HaveField("Wave", 1)),
+				))
+			})
+
+			It("rejects negative waves", func() {
+				writeFile("metadata/destination-selectors.yaml", "- directory: crds\n  wave: -1\n")
+				err := workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")
+				Expect(err).To(MatchError("invalid wave for directory crds in destination-selectors.yaml: -1, wave must not be negative"))
+			})
+		})
+
+		Describe("scheduling the dependency groups of the Promise", func() {
+			var mockPipelineDirectory string
+
+			writeFile := func(path, content string) {
+				path = filepath.Join(mockPipelineDirectory, path)
+				Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+				Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				mockPipelineDirectory = GinkgoT().TempDir()
+				writeFile("input/static/dependencies.yaml", "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: crds\n")
+				writeFile("input/operator/dependencies.yaml", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: operator\n")
+				writeFile("kratix-system/promise-scheduling", "- matchLabels:\n    environment: dev\n  source: promise\n")
+				writeFile("kratix-system/"+kratixpipeline.PromiseDependencyGroupsFile, "- directory: operator\n  matchLabels:\n    tier: data\n- directory: removed-by-pipeline\n")
+			})
+
+			It("schedules each dependency group in the output with its selectors and those of the Promise", func() {
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "", "", "promise")).To(Succeed())
+
+				work := getWork("kratix-platform-system", promiseWorkName)
+				Expect(work.Spec.WorkloadGroups).To(HaveLen(2))
+
+				Expect(work.Spec.WorkloadGroups[0].Directory).To(Equal("operator"))
+				Expect(work.Spec.WorkloadGroups[0].Workloads).To(ConsistOf(HaveField("Filepath", "operator/dependencies.yaml")))
+				Expect(work.Spec.WorkloadGroups[0].DestinationSelectors).To(ConsistOf(
+					v1alpha1.WorkloadGroupScheduling{MatchLabels: map[string]string{"tier": "data"}, Source: "promise-dependency-group"},
+					v1alpha1.WorkloadGroupScheduling{MatchLabels: map[string]string{"environment": "dev"}, Source: "promise"},
+				))
+
+				Expect(work.Spec.WorkloadGroups[1].Directory).To(Equal("."))
+				Expect(work.Spec.WorkloadGroups[1].Workloads).To(ConsistOf(HaveField("Filepath", "static/dependencies.yaml")))
+			})
+
+			It("lets the pipeline schedule the directory of a dependency group itself", func() {
+				writeFile("metadata/destination-selectors.yaml", "- directory: operator\n  matchLabels:\n    tier: cache\n")
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "", "", "promise")).To(Succeed())
+
+				work := getWork("kratix-platform-system", promiseWorkName)
+				Expect(work.Spec.WorkloadGroups[0].Directory).To(Equal("operator"))
+				Expect(work.Spec.WorkloadGroups[0].DestinationSelectors).To(ConsistOf(
+					v1alpha1.WorkloadGroupScheduling{MatchLabels: map[string]string{"tier": "cache"}, Source: "promise-workflow"},
+				))
+			})
+
+			It("ignores the dependency groups in resource workflows", func() {
+				Expect(workCreator.Execute(mockPipelineDirectory, "promise-name", "default", "resource-name", "resource")).To(Succeed())
+
+				work := getWork("default", resourceWorkName)
+				Expect(work.Spec.WorkloadGroups).To(HaveLen(1))
+				Expect(work.Spec.WorkloadGroups[0].Directory).To(Equal("."))
+			})
+		})
+
 		Context("complete set of inputs for a Promise", func() {
 			BeforeEach(func() {
 				err := workCreator.Execute(filepath.Join(getRootDirectory(), "complete-for-promise"), "promise-name", "", "resource-name", "promise")